@@ -64,18 +64,22 @@ type Client interface {
 	Apply(string) error
 	Close() error
 	CreateClusterObject(*clusterv1.Cluster) error
+	CreateConfigMap(*apiv1.ConfigMap) error
 	CreateMachineClass(*clusterv1.MachineClass) error
 	CreateMachineDeployments([]*clusterv1.MachineDeployment, string) error
 	CreateMachineSets([]*clusterv1.MachineSet, string) error
 	CreateMachines([]*clusterv1.Machine, string) error
+	CreateSecret(*apiv1.Secret) error
 	Delete(string) error
 	DeleteClusters(string) error
+	DeleteConfigMap(namespace, name string) error
 	DeleteNamespace(string) error
 	DeleteMachineClasses(string) error
 	DeleteMachineClass(namespace, name string) error
 	DeleteMachineDeployments(string) error
 	DeleteMachineSets(string) error
 	DeleteMachines(string) error
+	DeleteSecret(namespace, name string) error
 	ForceDeleteCluster(namespace, name string) error
 	ForceDeleteMachine(namespace, name string) error
 	ForceDeleteMachineSet(namespace, name string) error
@@ -83,8 +87,11 @@ type Client interface {
 	EnsureNamespace(string) error
 	GetClusters(string) ([]*clusterv1.Cluster, error)
 	GetCluster(string, string) (*clusterv1.Cluster, error)
+	GetConfigMap(namespace, name string) (*apiv1.ConfigMap, error)
+	GetConfigMaps(namespace string) ([]*apiv1.ConfigMap, error)
 	GetContextNamespace() string
 	GetMachineClasses(namespace string) ([]*clusterv1.MachineClass, error)
+	GetMachine(namespace, name string) (*clusterv1.Machine, error)
 	GetMachineDeployment(namespace, name string) (*clusterv1.MachineDeployment, error)
 	GetMachineDeploymentsForCluster(*clusterv1.Cluster) ([]*clusterv1.MachineDeployment, error)
 	GetMachineDeployments(string) ([]*clusterv1.MachineDeployment, error)
@@ -95,9 +102,22 @@ type Client interface {
 	GetMachines(namespace string) ([]*clusterv1.Machine, error)
 	GetMachinesForCluster(*clusterv1.Cluster) ([]*clusterv1.Machine, error)
 	GetMachinesForMachineSet(*clusterv1.MachineSet) ([]*clusterv1.Machine, error)
+	GetSecret(namespace, name string) (*apiv1.Secret, error)
+	GetSecrets(namespace string) ([]*apiv1.Secret, error)
+	ScaleDeployment(namespace, name string, scale int32) error
 	ScaleStatefulSet(namespace, name string, scale int32) error
 	WaitForClusterV1alpha1Ready() error
+	UpdateClusterObject(*clusterv1.Cluster) error
 	UpdateClusterObjectEndpoint(string, string, string) error
+	UpdateClusterObjectStatus(*clusterv1.Cluster) error
+	UpdateConfigMap(*apiv1.ConfigMap) error
+	UpdateMachine(*clusterv1.Machine) error
+	UpdateMachineStatus(*clusterv1.Machine) error
+	UpdateMachineDeployment(*clusterv1.MachineDeployment) error
+	UpdateMachineDeploymentStatus(*clusterv1.MachineDeployment) error
+	UpdateMachineSet(*clusterv1.MachineSet) error
+	UpdateMachineSetStatus(*clusterv1.MachineSet) error
+	UpdateSecret(*apiv1.Secret) error
 	WaitForResourceStatuses() error
 }
 
@@ -146,6 +166,27 @@ func (c *client) EnsureNamespace(namespaceName string) error {
 	return nil
 }
 
+func (c *client) ScaleDeployment(ns string, name string, scale int32) error {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return errors.Wrap(err, "error creating core clientset")
+	}
+
+	_, err = clientset.AppsV1().Deployments(ns).UpdateScale(name, &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns,
+			Name:      name,
+		},
+		Spec: autoscalingv1.ScaleSpec{
+			Replicas: scale,
+		},
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 func (c *client) ScaleStatefulSet(ns string, name string, scale int32) error {
 	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
 	if err != nil {
@@ -167,6 +208,130 @@ func (c *client) ScaleStatefulSet(ns string, name string, scale int32) error {
 	return nil
 }
 
+func (c *client) GetSecret(namespace, name string) (*apiv1.Secret, error) {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating core clientset")
+	}
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting Secret %s/%s", namespace, name)
+	}
+	return secret, nil
+}
+
+// GetSecrets returns the Secrets in a namespace. If the namespace is empty then the Secrets in all namespaces are returned.
+func (c *client) GetSecrets(namespace string) ([]*apiv1.Secret, error) {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating core clientset")
+	}
+	secretList, err := clientset.CoreV1().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing Secrets in namespace %q", namespace)
+	}
+	secrets := make([]*apiv1.Secret, 0, len(secretList.Items))
+	for i := range secretList.Items {
+		secrets = append(secrets, &secretList.Items[i])
+	}
+	return secrets, nil
+}
+
+func (c *client) CreateSecret(secret *apiv1.Secret) error {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return errors.Wrap(err, "error creating core clientset")
+	}
+	if _, err := clientset.CoreV1().Secrets(secret.Namespace).Create(secret); err != nil {
+		return errors.Wrapf(err, "error creating Secret %s/%s", secret.Namespace, secret.Name)
+	}
+	return nil
+}
+
+func (c *client) UpdateSecret(secret *apiv1.Secret) error {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return errors.Wrap(err, "error creating core clientset")
+	}
+	if _, err := clientset.CoreV1().Secrets(secret.Namespace).Update(secret); err != nil {
+		return errors.Wrapf(err, "error updating Secret %s/%s", secret.Namespace, secret.Name)
+	}
+	return nil
+}
+
+func (c *client) DeleteSecret(namespace, name string) error {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return errors.Wrap(err, "error creating core clientset")
+	}
+	if err := clientset.CoreV1().Secrets(namespace).Delete(name, &metav1.DeleteOptions{}); err != nil {
+		return errors.Wrapf(err, "error deleting Secret %s/%s", namespace, name)
+	}
+	return nil
+}
+
+func (c *client) GetConfigMap(namespace, name string) (*apiv1.ConfigMap, error) {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating core clientset")
+	}
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting ConfigMap %s/%s", namespace, name)
+	}
+	return configMap, nil
+}
+
+// GetConfigMaps returns the ConfigMaps in a namespace. If the namespace is empty then the ConfigMaps in all namespaces are returned.
+func (c *client) GetConfigMaps(namespace string) ([]*apiv1.ConfigMap, error) {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating core clientset")
+	}
+	configMapList, err := clientset.CoreV1().ConfigMaps(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing ConfigMaps in namespace %q", namespace)
+	}
+	configMaps := make([]*apiv1.ConfigMap, 0, len(configMapList.Items))
+	for i := range configMapList.Items {
+		configMaps = append(configMaps, &configMapList.Items[i])
+	}
+	return configMaps, nil
+}
+
+func (c *client) CreateConfigMap(configMap *apiv1.ConfigMap) error {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return errors.Wrap(err, "error creating core clientset")
+	}
+	if _, err := clientset.CoreV1().ConfigMaps(configMap.Namespace).Create(configMap); err != nil {
+		return errors.Wrapf(err, "error creating ConfigMap %s/%s", configMap.Namespace, configMap.Name)
+	}
+	return nil
+}
+
+func (c *client) UpdateConfigMap(configMap *apiv1.ConfigMap) error {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return errors.Wrap(err, "error creating core clientset")
+	}
+	if _, err := clientset.CoreV1().ConfigMaps(configMap.Namespace).Update(configMap); err != nil {
+		return errors.Wrapf(err, "error updating ConfigMap %s/%s", configMap.Namespace, configMap.Name)
+	}
+	return nil
+}
+
+func (c *client) DeleteConfigMap(namespace, name string) error {
+	clientset, err := clientcmd.NewCoreClientSetForDefaultSearchPath(c.kubeconfigFile, clientcmd.NewConfigOverrides())
+	if err != nil {
+		return errors.Wrap(err, "error creating core clientset")
+	}
+	if err := clientset.CoreV1().ConfigMaps(namespace).Delete(name, &metav1.DeleteOptions{}); err != nil {
+		return errors.Wrapf(err, "error deleting ConfigMap %s/%s", namespace, name)
+	}
+	return nil
+}
+
 func (c *client) DeleteNamespace(namespaceName string) error {
 	if namespaceName == apiv1.NamespaceDefault {
 		return nil
@@ -289,6 +454,20 @@ func (c *client) GetMachineDeployment(namespace, name string) (*clusterv1.Machin
 	return machineDeployment, nil
 }
 
+func (c *client) UpdateMachineDeployment(md *clusterv1.MachineDeployment) error {
+	if _, err := c.clientSet.ClusterV1alpha1().MachineDeployments(md.Namespace).Update(md); err != nil {
+		return errors.Wrapf(err, "error updating MachineDeployment: %s/%s", md.Namespace, md.Name)
+	}
+	return nil
+}
+
+func (c *client) UpdateMachineDeploymentStatus(md *clusterv1.MachineDeployment) error {
+	if _, err := c.clientSet.ClusterV1alpha1().MachineDeployments(md.Namespace).UpdateStatus(md); err != nil {
+		return errors.Wrapf(err, "error updating MachineDeployment status: %s/%s", md.Namespace, md.Name)
+	}
+	return nil
+}
+
 func (c *client) GetMachineDeploymentsForCluster(cluster *clusterv1.Cluster) ([]*clusterv1.MachineDeployment, error) {
 	machineDeploymentList, err := c.clientSet.ClusterV1alpha1().MachineDeployments(cluster.Namespace).List(metav1.ListOptions{
 		LabelSelector: fmt.Sprintf("%s=%s", machineClusterLabelName, cluster.Name),
@@ -328,6 +507,20 @@ func (c *client) GetMachineSet(namespace, name string) (*clusterv1.MachineSet, e
 	return machineSet, nil
 }
 
+func (c *client) UpdateMachineSet(ms *clusterv1.MachineSet) error {
+	if _, err := c.clientSet.ClusterV1alpha1().MachineSets(ms.Namespace).Update(ms); err != nil {
+		return errors.Wrapf(err, "error updating MachineSet: %s/%s", ms.Namespace, ms.Name)
+	}
+	return nil
+}
+
+func (c *client) UpdateMachineSetStatus(ms *clusterv1.MachineSet) error {
+	if _, err := c.clientSet.ClusterV1alpha1().MachineSets(ms.Namespace).UpdateStatus(ms); err != nil {
+		return errors.Wrapf(err, "error updating MachineSet status: %s/%s", ms.Namespace, ms.Name)
+	}
+	return nil
+}
+
 func (c *client) GetMachineSets(namespace string) ([]*clusterv1.MachineSet, error) {
 	machineSetList, err := c.clientSet.ClusterV1alpha1().MachineSets(namespace).List(metav1.ListOptions{})
 	if err != nil {
@@ -373,6 +566,28 @@ func (c *client) GetMachineSetsForMachineDeployment(md *clusterv1.MachineDeploym
 	return controlledMachineSets, nil
 }
 
+func (c *client) GetMachine(namespace, name string) (*clusterv1.Machine, error) {
+	machine, err := c.clientSet.ClusterV1alpha1().Machines(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting Machine: %s/%s", namespace, name)
+	}
+	return machine, nil
+}
+
+func (c *client) UpdateMachine(machine *clusterv1.Machine) error {
+	if _, err := c.clientSet.ClusterV1alpha1().Machines(machine.Namespace).Update(machine); err != nil {
+		return errors.Wrapf(err, "error updating Machine: %s/%s", machine.Namespace, machine.Name)
+	}
+	return nil
+}
+
+func (c *client) UpdateMachineStatus(machine *clusterv1.Machine) error {
+	if _, err := c.clientSet.ClusterV1alpha1().Machines(machine.Namespace).UpdateStatus(machine); err != nil {
+		return errors.Wrapf(err, "error updating Machine status: %s/%s", machine.Namespace, machine.Name)
+	}
+	return nil
+}
+
 func (c *client) GetMachines(namespace string) ([]*clusterv1.Machine, error) {
 	machines := []*clusterv1.Machine{}
 	machineslist, err := c.clientSet.ClusterV1alpha1().Machines(namespace).List(metav1.ListOptions{})
@@ -742,6 +957,20 @@ func (c *client) UpdateClusterObjectEndpoint(clusterEndpoint, clusterName, names
 	return err
 }
 
+func (c *client) UpdateClusterObject(cluster *clusterv1.Cluster) error {
+	if _, err := c.clientSet.ClusterV1alpha1().Clusters(cluster.Namespace).Update(cluster); err != nil {
+		return errors.Wrapf(err, "error updating Cluster: %s/%s", cluster.Namespace, cluster.Name)
+	}
+	return nil
+}
+
+func (c *client) UpdateClusterObjectStatus(cluster *clusterv1.Cluster) error {
+	if _, err := c.clientSet.ClusterV1alpha1().Clusters(cluster.Namespace).UpdateStatus(cluster); err != nil {
+		return errors.Wrapf(err, "error updating Cluster status: %s/%s", cluster.Namespace, cluster.Name)
+	}
+	return nil
+}
+
 func (c *client) WaitForClusterV1alpha1Ready() error {
 	return waitForClusterResourceReady(c.clientSet)
 }
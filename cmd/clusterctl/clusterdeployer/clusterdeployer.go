@@ -36,6 +36,7 @@ type ClusterDeployer struct {
 	addonComponents         string
 	bootstrapComponents     string
 	cleanupBootstrapCluster bool
+	pivotJournal            string
 }
 
 func New(
@@ -44,7 +45,8 @@ func New(
 	providerComponents string,
 	addonComponents string,
 	bootstrapComponents string,
-	cleanupBootstrapCluster bool) *ClusterDeployer {
+	cleanupBootstrapCluster bool,
+	pivotJournal string) *ClusterDeployer {
 	return &ClusterDeployer{
 		bootstrapProvisioner:    bootstrapProvisioner,
 		clientFactory:           clientFactory,
@@ -52,6 +54,7 @@ func New(
 		addonComponents:         addonComponents,
 		bootstrapComponents:     bootstrapComponents,
 		cleanupBootstrapCluster: cleanupBootstrapCluster,
+		pivotJournal:            pivotJournal,
 	}
 }
 
@@ -63,7 +66,13 @@ func (d *ClusterDeployer) Create(cluster *clusterv1.Cluster, machines []*cluster
 	}
 
 	bootstrapClient, cleanupBootstrapCluster, err := phases.CreateBootstrapCluster(d.bootstrapProvisioner, d.cleanupBootstrapCluster, d.clientFactory)
-	defer cleanupBootstrapCluster()
+	// The bootstrap cluster is kept if the pivot fails halfway, so that it can be resumed or rolled back.
+	keepBootstrapCluster := false
+	defer func() {
+		if !keepBootstrapCluster {
+			cleanupBootstrapCluster()
+		}
+	}()
 	if err != nil {
 		return errors.Wrap(err, "could not create bootstrap cluster")
 	}
@@ -118,7 +127,8 @@ func (d *ClusterDeployer) Create(cluster *clusterv1.Cluster, machines []*cluster
 	}
 
 	klog.Info("Pivoting Cluster API stack to target cluster")
-	if err := phases.Pivot(bootstrapClient, targetClient, d.providerComponents); err != nil {
+	if err := d.pivot(bootstrapClient, targetClient, dependentsLister(provider)); err != nil {
+		keepBootstrapCluster = d.pivotJournal != ""
 		return errors.Wrap(err, "unable to pivot cluster api stack to target cluster")
 	}
 
@@ -156,17 +166,26 @@ func (d *ClusterDeployer) Create(cluster *clusterv1.Cluster, machines []*cluster
 	return nil
 }
 
-func (d *ClusterDeployer) Delete(targetClient clusterclient.Client) error {
+// Delete pivots the Cluster API stack of the target cluster to a bootstrap cluster and deletes the cluster from there.
+// The Secrets and ConfigMaps listed by lister, if it is not nil, are pivoted along with the Cluster API objects.
+func (d *ClusterDeployer) Delete(targetClient clusterclient.Client, lister provider.DependentsLister) error {
 	klog.Info("Creating bootstrap cluster")
 	bootstrapClient, cleanupBootstrapCluster, err := phases.CreateBootstrapCluster(d.bootstrapProvisioner, d.cleanupBootstrapCluster, d.clientFactory)
-	defer cleanupBootstrapCluster()
+	// The bootstrap cluster is kept if the pivot fails halfway, so that it can be resumed or rolled back.
+	keepBootstrapCluster := false
+	defer func() {
+		if !keepBootstrapCluster {
+			cleanupBootstrapCluster()
+		}
+	}()
 	if err != nil {
 		return errors.Wrap(err, "could not create bootstrap cluster")
 	}
 	defer closeClient(bootstrapClient, "bootstrap")
 
 	klog.Info("Pivoting Cluster API stack to bootstrap cluster")
-	if err := phases.Pivot(targetClient, bootstrapClient, d.providerComponents); err != nil {
+	if err := d.pivot(targetClient, bootstrapClient, lister); err != nil {
+		keepBootstrapCluster = d.pivotJournal != ""
 		return errors.Wrap(err, "unable to pivot Cluster API stack to bootstrap cluster")
 	}
 
@@ -185,6 +204,26 @@ func (d *ClusterDeployer) Delete(targetClient clusterclient.Client) error {
 	return nil
 }
 
+// pivot moves the Cluster API stack from one cluster to the other, along with the Secrets and ConfigMaps
+// listed by lister if it is not nil, recording its progress in the pivot journal if one is configured.
+func (d *ClusterDeployer) pivot(from, to clusterclient.Client, lister provider.DependentsLister) error {
+	options := phases.PivotOptions{Dependents: lister}
+	if d.pivotJournal == "" {
+		return phases.PivotWithOptions(from, to, d.providerComponents, options)
+	}
+	options.Journal = phases.NewFileJournalStore(d.pivotJournal)
+	if err := phases.PivotWithOptions(from, to, d.providerComponents, options); err != nil {
+		return errors.Wrapf(err, "the pivot can be resumed or rolled back with `clusterctl alpha phases pivot --journal %s`", d.pivotJournal)
+	}
+	return nil
+}
+
+// dependentsLister returns the deployer if it lists the Secrets and ConfigMaps its objects depend on.
+func dependentsLister(deployer provider.Deployer) provider.DependentsLister {
+	lister, _ := deployer.(provider.DependentsLister)
+	return lister
+}
+
 func (d *ClusterDeployer) updateClusterEndpoint(client clusterclient.Client, provider provider.Deployer, clusterName, namespace string) error {
 	// Update cluster endpoint. Needed till this logic moves into cluster controller.
 	// TODO: https://github.com/kubernetes-sigs/cluster-api/issues/158
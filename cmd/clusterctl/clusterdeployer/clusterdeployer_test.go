@@ -324,6 +324,55 @@ func (c *testClusterClient) DeleteMachines(ns string) error {
 func (c *testClusterClient) UpdateClusterObjectEndpoint(string, string, string) error {
 	return c.UpdateClusterObjectEndpointErr
 }
+func (c *testClusterClient) UpdateMachineDeployment(md *clusterv1.MachineDeployment) error {
+	for i, existing := range c.machineDeployments[md.Namespace] {
+		if existing.Name == md.Name {
+			c.machineDeployments[md.Namespace][i] = md
+			return nil
+		}
+	}
+	return errors.Errorf("machine deployment %s/%s not found", md.Namespace, md.Name)
+}
+func (c *testClusterClient) UpdateMachineDeploymentStatus(md *clusterv1.MachineDeployment) error {
+	return c.UpdateMachineDeployment(md)
+}
+func (c *testClusterClient) UpdateMachineSet(ms *clusterv1.MachineSet) error {
+	for i, existing := range c.machineSets[ms.Namespace] {
+		if existing.Name == ms.Name {
+			c.machineSets[ms.Namespace][i] = ms
+			return nil
+		}
+	}
+	return errors.Errorf("machine set %s/%s not found", ms.Namespace, ms.Name)
+}
+func (c *testClusterClient) UpdateMachineSetStatus(ms *clusterv1.MachineSet) error {
+	return c.UpdateMachineSet(ms)
+}
+func (c *testClusterClient) UpdateMachine(m *clusterv1.Machine) error {
+	for i, existing := range c.machines[m.Namespace] {
+		if existing.Name == m.Name {
+			c.machines[m.Namespace][i] = m
+			return nil
+		}
+	}
+	return errors.Errorf("machine %s/%s not found", m.Namespace, m.Name)
+}
+func (c *testClusterClient) UpdateMachineStatus(m *clusterv1.Machine) error {
+	return c.UpdateMachine(m)
+}
+func (c *testClusterClient) UpdateClusterObject(cluster *clusterv1.Cluster) error {
+	return c.UpdateClusterObjectStatus(cluster)
+}
+func (c *testClusterClient) UpdateClusterObjectStatus(cluster *clusterv1.Cluster) error {
+	for i, existing := range c.clusters[cluster.Namespace] {
+		if existing.Name == cluster.Name {
+			c.clusters[cluster.Namespace][i] = cluster
+			return nil
+		}
+	}
+	return errors.Errorf("cluster %s/%s not found", cluster.Namespace, cluster.Name)
+}
+
 func (c *testClusterClient) Close() error {
 	return c.CloseErr
 }
@@ -352,6 +401,10 @@ func (c *testClusterClient) DeleteNamespace(namespaceName string) error {
 	return c.DeleteNamespaceErr
 }
 
+func (c *testClusterClient) ScaleDeployment(ns string, name string, scale int32) error {
+	return nil
+}
+
 func (c *testClusterClient) ScaleStatefulSet(ns string, name string, scale int32) error {
 	return nil
 }
@@ -395,6 +448,15 @@ func (c *testClusterClient) GetMachineDeployment(namespace, name string) (*clust
 	return nil, nil
 }
 
+func (c *testClusterClient) GetMachine(namespace, name string) (*clusterv1.Machine, error) {
+	for _, m := range c.machines[namespace] {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
 func (c *testClusterClient) GetMachineSet(namespace, name string) (*clusterv1.MachineSet, error) {
 	for _, ms := range c.machineSets[namespace] {
 		if ms.Name == name {
@@ -491,6 +553,46 @@ func (c *testClusterClient) WaitForResourceStatuses() error {
 }
 
 // TODO: implement GetMachineClasses for testClusterClient and add tests
+func (c *testClusterClient) GetSecret(namespace, name string) (*apiv1.Secret, error) {
+	return nil, errors.Errorf("secret %s/%s not found", namespace, name)
+}
+
+func (c *testClusterClient) GetSecrets(namespace string) ([]*apiv1.Secret, error) {
+	return nil, nil
+}
+
+func (c *testClusterClient) CreateSecret(*apiv1.Secret) error {
+	return nil
+}
+
+func (c *testClusterClient) UpdateSecret(*apiv1.Secret) error {
+	return nil
+}
+
+func (c *testClusterClient) DeleteSecret(namespace, name string) error {
+	return nil
+}
+
+func (c *testClusterClient) GetConfigMap(namespace, name string) (*apiv1.ConfigMap, error) {
+	return nil, errors.Errorf("config map %s/%s not found", namespace, name)
+}
+
+func (c *testClusterClient) GetConfigMaps(namespace string) ([]*apiv1.ConfigMap, error) {
+	return nil, nil
+}
+
+func (c *testClusterClient) CreateConfigMap(*apiv1.ConfigMap) error {
+	return nil
+}
+
+func (c *testClusterClient) UpdateConfigMap(*apiv1.ConfigMap) error {
+	return nil
+}
+
+func (c *testClusterClient) DeleteConfigMap(namespace, name string) error {
+	return nil
+}
+
 func (c *testClusterClient) GetMachineClasses(namespace string) ([]*clusterv1.MachineClass, error) {
 	return c.machineClasses[namespace], c.GetMachineClassesErr
 }
@@ -846,7 +948,7 @@ func TestClusterCreate(t *testing.T) {
 
 			pcStore := mockProviderComponentsStore{}
 			pcFactory := mockProviderComponentsStoreFactory{NewFromCoreclientsetPCStore: &pcStore}
-			d := New(p, f, "", "", bootstrapComponent, testcase.cleanupExternal)
+			d := New(p, f, "", "", bootstrapComponent, testcase.cleanupExternal, "")
 
 			inputMachines := make(map[string][]*clusterv1.Machine)
 
@@ -961,7 +1063,7 @@ func TestCreateProviderComponentsScenarios(t *testing.T) {
 			pcFactory := mockProviderComponentsStoreFactory{NewFromCoreclientsetPCStore: &tc.pcStore}
 			providerComponentsYaml := "---\nyaml: definition"
 			addonsYaml := "---\nyaml: definition"
-			d := New(p, f, providerComponentsYaml, addonsYaml, "", false)
+			d := New(p, f, providerComponentsYaml, addonsYaml, "", false, "")
 			err := d.Create(inputCluster, inputMachines, pd, kubeconfigOut, &pcFactory)
 			if err == nil && tc.expectedError != "" {
 				t.Fatalf("error mismatch: got '%v', want '%v'", err, tc.expectedError)
@@ -1102,8 +1204,8 @@ func TestDeleteCleanupExternalCluster(t *testing.T) {
 			f := newTestClusterClientFactory()
 			f.clusterClients[bootstrapKubeconfig] = tc.bootstrapClient
 			f.clusterClients[targetKubeconfig] = tc.targetClient
-			d := New(p, f, "", "", "", tc.cleanupExternalCluster)
-			err := d.Delete(tc.targetClient)
+			d := New(p, f, "", "", "", tc.cleanupExternalCluster, "")
+			err := d.Delete(tc.targetClient, nil)
 			if err != nil || tc.expectedErrorMessage != "" {
 				if err == nil {
 					t.Errorf("expected error %q", tc.expectedErrorMessage)
@@ -1348,9 +1450,9 @@ func TestClusterDelete(t *testing.T) {
 			f := newTestClusterClientFactory()
 			f.clusterClients[bootstrapKubeconfig] = testCase.bootstrapClient
 			f.ClusterClientErr = testCase.NewCoreClientsetErr
-			d := New(p, f, "", "", "", true)
+			d := New(p, f, "", "", "", true, "")
 
-			err := d.Delete(testCase.targetClient)
+			err := d.Delete(testCase.targetClient, nil)
 			if err != nil || testCase.expectedErrorMessage != "" {
 				if err == nil {
 					t.Errorf("expected error %q", testCase.expectedErrorMessage)
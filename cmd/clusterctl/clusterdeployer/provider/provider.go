@@ -17,6 +17,8 @@ limitations under the License.
 package provider
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 )
@@ -30,6 +32,14 @@ type Deployer interface {
 	GetKubeConfig(cluster *clusterv1.Cluster, master *clusterv1.Machine) (string, error)
 }
 
+// DependentsLister is an optional interface for providers whose objects depend on Secrets or ConfigMaps, for example
+// referenced from their ProviderSpec, which must be pivoted along with them.
+type DependentsLister interface {
+	// ListDependents returns references to the Secrets and ConfigMaps the Cluster, MachineClass, MachineDeployment,
+	// MachineSet or Machine depends on. References without a namespace are in the namespace of the object.
+	ListDependents(obj runtime.Object) ([]corev1.ObjectReference, error)
+}
+
 // ComponentsStore is an interface for saving and loading Provider Components
 type ComponentsStore interface {
 	Save(providerComponents string) error
@@ -23,6 +23,7 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/klog"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/clusterclient"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/provider"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/phases"
 )
 
@@ -30,6 +31,10 @@ type AlphaPhasePivotOptions struct {
 	SourceKubeconfig   string
 	TargetKubeconfig   string
 	ProviderComponents string
+	Provider           string
+	Journal            string
+	Resume             bool
+	Rollback           bool
 }
 
 var ppo = &AlphaPhasePivotOptions{}
@@ -39,10 +44,18 @@ var alphaPhasePivotCmd = &cobra.Command{
 	Short: "Pivot",
 	Long:  `Pivot`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if ppo.ProviderComponents == "" {
+		if ppo.ProviderComponents == "" && !ppo.Rollback {
 			exitWithHelp(cmd, "Please provide yaml file for provider components definition.")
 		}
 
+		if (ppo.Resume || ppo.Rollback) && ppo.Journal == "" {
+			exitWithHelp(cmd, "Please provide the pivot journal to resume or roll back.")
+		}
+
+		if ppo.Resume && ppo.Rollback {
+			exitWithHelp(cmd, "Please provide only one of --resume and --rollback.")
+		}
+
 		if ppo.SourceKubeconfig == "" {
 			exitWithHelp(cmd, "Please provide a source kubeconfig file.")
 		}
@@ -68,11 +81,6 @@ func RunAlphaPhasePivot(ppo *AlphaPhasePivotOptions) error {
 		return err
 	}
 
-	providerComponents, err := ioutil.ReadFile(ppo.ProviderComponents)
-	if err != nil {
-		return fmt.Errorf("error loading addons file '%v': %v", ppo.ProviderComponents, err)
-	}
-
 	clientFactory := clusterclient.NewFactory()
 	sourceClient, err := clientFactory.NewClientFromKubeconfig(string(sourceKubeconfig))
 	if err != nil {
@@ -84,7 +92,37 @@ func RunAlphaPhasePivot(ppo *AlphaPhasePivotOptions) error {
 		return fmt.Errorf("unable to create target cluster client: %v", err)
 	}
 
-	if err := phases.Pivot(sourceClient, targetClient, string(providerComponents)); err != nil {
+	var journal phases.JournalStore
+	if ppo.Journal != "" {
+		journal = phases.NewFileJournalStore(ppo.Journal)
+	}
+
+	if ppo.Rollback {
+		if err := phases.RollbackPivot(sourceClient, targetClient, journal); err != nil {
+			return fmt.Errorf("unable to roll back the pivot: %v", err)
+		}
+		return nil
+	}
+
+	providerComponents, err := ioutil.ReadFile(ppo.ProviderComponents)
+	if err != nil {
+		return fmt.Errorf("error loading addons file '%v': %v", ppo.ProviderComponents, err)
+	}
+
+	options := phases.PivotOptions{Journal: journal, Resume: ppo.Resume}
+	if ppo.Provider != "" {
+		pd, err := getProvider(ppo.Provider)
+		if err != nil {
+			return err
+		}
+		if lister, ok := pd.(provider.DependentsLister); ok {
+			options.Dependents = lister
+		}
+	}
+	if err := phases.PivotWithOptions(sourceClient, targetClient, string(providerComponents), options); err != nil {
+		if journal != nil {
+			return fmt.Errorf("unable to pivot Cluster API Components: %v. The pivot can be continued with --resume or undone with --rollback", err)
+		}
 		return fmt.Errorf("unable to pivot Cluster API Components: %v", err)
 	}
 
@@ -96,5 +134,11 @@ func init() {
 	alphaPhasePivotCmd.Flags().StringVarP(&ppo.SourceKubeconfig, "source-kubeconfig", "s", "", "Path for the source kubeconfig file to use")
 	alphaPhasePivotCmd.Flags().StringVarP(&ppo.TargetKubeconfig, "target-kubeconfig", "t", "", "Path for the target kubeconfig file to use")
 	alphaPhasePivotCmd.Flags().StringVarP(&ppo.ProviderComponents, "provider-components", "p", "", "A yaml file containing provider components to apply to the cluster")
+
+	// Optional flags
+	alphaPhasePivotCmd.Flags().StringVarP(&ppo.Provider, "provider", "", "", "Which provider deployment logic to use to find the Secrets and ConfigMaps to copy along with the labeled ones")
+	alphaPhasePivotCmd.Flags().StringVarP(&ppo.Journal, "journal", "", "", "A file recording the progress of the pivot, so that it can be resumed or rolled back if it fails")
+	alphaPhasePivotCmd.Flags().BoolVarP(&ppo.Resume, "resume", "", false, "Continue the unfinished pivot recorded in the journal")
+	alphaPhasePivotCmd.Flags().BoolVarP(&ppo.Rollback, "rollback", "", false, "Undo the unfinished pivot recorded in the journal, restoring the objects in the source cluster and scaling its controllers back up")
 	alphaPhasesCmd.AddCommand(alphaPhasePivotCmd)
 }
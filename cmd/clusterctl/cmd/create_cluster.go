@@ -38,6 +38,7 @@ type CreateOptions struct {
 	BootstrapOnlyComponents string
 	Provider                string
 	KubeconfigOutput        string
+	PivotJournal            string
 	BootstrapFlags          bootstrap.Options
 }
 
@@ -107,7 +108,8 @@ func RunCreate(co *CreateOptions) error {
 		string(pc),
 		string(ac),
 		string(bc),
-		co.BootstrapFlags.Cleanup)
+		co.BootstrapFlags.Cleanup,
+		co.PivotJournal)
 
 	return d.Create(c, m, pd, co.KubeconfigOutput, pcsFactory)
 }
@@ -128,6 +130,7 @@ func init() {
 	createClusterCmd.Flags().StringVarP(&co.AddonComponents, "addon-components", "a", "", "A yaml file containing cluster addons to apply to the internal cluster")
 	createClusterCmd.Flags().StringVarP(&co.BootstrapOnlyComponents, "bootstrap-only-components", "", "", "A yaml file containing components to apply only on the bootstrap cluster (before the provider components are applied) but not the provisioned cluster")
 	createClusterCmd.Flags().StringVarP(&co.KubeconfigOutput, "kubeconfig-out", "", "kubeconfig", "Where to output the kubeconfig for the provisioned cluster")
+	createClusterCmd.Flags().StringVarP(&co.PivotJournal, "pivot-journal", "", "", "A file recording the progress of the pivot to the provisioned cluster. If the pivot fails, the bootstrap cluster is kept so that the pivot can be resumed or rolled back with clusterctl alpha phases pivot")
 
 	co.BootstrapFlags.AddFlags(createClusterCmd.Flags())
 	createCmd.AddCommand(createClusterCmd)
@@ -26,13 +26,16 @@ import (
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/bootstrap"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/clusterclient"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/provider"
 	"sigs.k8s.io/cluster-api/cmd/clusterctl/providercomponents"
 )
 
 type DeleteOptions struct {
 	KubeconfigPath      string
 	ProviderComponents  string
+	Provider            string
 	KubeconfigOverrides tcmd.ConfigOverrides
+	PivotJournal        string
 	BootstrapFlags      bootstrap.Options
 }
 
@@ -59,6 +62,8 @@ func init() {
 	// Required flags
 	deleteClusterCmd.Flags().StringVarP(&do.KubeconfigPath, "kubeconfig", "", "", "Path to the kubeconfig file to use for connecting to the cluster to be deleted, if empty, the default KUBECONFIG load path is used.")
 	deleteClusterCmd.Flags().StringVarP(&do.ProviderComponents, "provider-components", "p", "", "A yaml file containing cluster api provider controllers and supporting objects, if empty the value is loaded from the cluster's configuration store.")
+	deleteClusterCmd.Flags().StringVarP(&do.Provider, "provider", "", "", "Which provider deployment logic to use to find the Secrets and ConfigMaps to pivot to the bootstrap cluster along with the labeled ones")
+	deleteClusterCmd.Flags().StringVarP(&do.PivotJournal, "pivot-journal", "", "", "A file recording the progress of the pivot to the bootstrap cluster. If the pivot fails, the bootstrap cluster is kept so that the pivot can be resumed or rolled back with clusterctl alpha phases pivot")
 
 	// BindContextFlags will bind the flags cluster, namespace, and user
 	tcmd.BindContextFlags(&do.KubeconfigOverrides.Context, deleteClusterCmd.Flags(), tcmd.RecommendedContextOverrideFlags(""))
@@ -83,15 +88,25 @@ func RunDelete() error {
 		return err
 	}
 
+	var lister provider.DependentsLister
+	if do.Provider != "" {
+		pd, err := getProvider(do.Provider)
+		if err != nil {
+			return err
+		}
+		lister, _ = pd.(provider.DependentsLister)
+	}
+
 	deployer := clusterdeployer.New(
 		bootstrapProvider,
 		clusterclient.NewFactory(),
 		providerComponents,
 		"",
 		"",
-		do.BootstrapFlags.Cleanup)
+		do.BootstrapFlags.Cleanup,
+		do.PivotJournal)
 
-	return deployer.Delete(clusterClient)
+	return deployer.Delete(clusterClient, lister)
 }
 
 func loadProviderComponents() (string, error) {
@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/clusterclient"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/provider"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/phases"
+)
+
+type MoveOptions struct {
+	FromKubeconfig     string
+	ToKubeconfig       string
+	ProviderComponents string
+	Provider           string
+	Namespace          string
+	Cluster            string
+	DryRun             bool
+}
+
+var mo = &MoveOptions{}
+
+var moveCmd = &cobra.Command{
+	Use:   "move",
+	Short: "Move Cluster API objects between management clusters",
+	Long: `Move a Cluster, or all the Clusters of a namespace, from a management cluster to another one already running the providers.
+The MachineDeployments, MachineSets and Machines of the Clusters are moved with them, as well as the MachineClasses, Secrets and ConfigMaps they use.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if mo.FromKubeconfig == "" {
+			exitWithHelp(cmd, "Please provide the kubeconfig file of the management cluster to move from.")
+		}
+
+		if mo.ToKubeconfig == "" && !mo.DryRun {
+			exitWithHelp(cmd, "Please provide the kubeconfig file of the management cluster to move to.")
+		}
+
+		if err := RunMove(mo, os.Stdout); err != nil {
+			klog.Exit(err)
+		}
+	},
+}
+
+func RunMove(mo *MoveOptions, out io.Writer) error {
+	fromKubeconfig, err := ioutil.ReadFile(mo.FromKubeconfig)
+	if err != nil {
+		return err
+	}
+
+	clientFactory := clusterclient.NewFactory()
+	fromClient, err := clientFactory.NewClientFromKubeconfig(string(fromKubeconfig))
+	if err != nil {
+		return fmt.Errorf("unable to create source cluster client: %v", err)
+	}
+	defer fromClient.Close()
+
+	options := phases.MoveOptions{Namespace: mo.Namespace, Cluster: mo.Cluster}
+	if options.Cluster != "" && options.Namespace == "" {
+		options.Namespace = fromClient.GetContextNamespace()
+	}
+	if mo.Provider != "" {
+		pd, err := getProvider(mo.Provider)
+		if err != nil {
+			return err
+		}
+		if lister, ok := pd.(provider.DependentsLister); ok {
+			options.Dependents = lister
+		}
+	}
+
+	if mo.DryRun {
+		graph, err := phases.BuildMoveGraph(fromClient, options)
+		if err != nil {
+			return fmt.Errorf("unable to list the objects to move: %v", err)
+		}
+		printMoveGraph(out, graph, "")
+		return nil
+	}
+
+	toKubeconfig, err := ioutil.ReadFile(mo.ToKubeconfig)
+	if err != nil {
+		return err
+	}
+
+	toClient, err := clientFactory.NewClientFromKubeconfig(string(toKubeconfig))
+	if err != nil {
+		return fmt.Errorf("unable to create target cluster client: %v", err)
+	}
+	defer toClient.Close()
+
+	var providerComponents []byte
+	if mo.ProviderComponents != "" {
+		providerComponents, err = ioutil.ReadFile(mo.ProviderComponents)
+		if err != nil {
+			return fmt.Errorf("error loading provider components file '%v': %v", mo.ProviderComponents, err)
+		}
+	}
+
+	if err := phases.Move(fromClient, toClient, string(providerComponents), options); err != nil {
+		return fmt.Errorf("unable to move Cluster API objects: %v", err)
+	}
+	return nil
+}
+
+// printMoveGraph prints a line per object to move, indenting the objects
+// moved along with another one.
+func printMoveGraph(out io.Writer, graph []*phases.MoveNode, indent string) {
+	for _, n := range graph {
+		fmt.Fprintf(out, "%s%s %s/%s\n", indent, n.Kind, n.Namespace, n.Name)
+		printMoveGraph(out, n.Children, indent+"  ")
+	}
+}
+
+func init() {
+	// Required flags
+	moveCmd.Flags().StringVarP(&mo.FromKubeconfig, "from-kubeconfig", "", "", "The kubeconfig file of the management cluster to move the objects from")
+	moveCmd.Flags().StringVarP(&mo.ToKubeconfig, "to-kubeconfig", "", "", "The kubeconfig file of the management cluster to move the objects to")
+
+	// Optional flags
+	moveCmd.Flags().StringVarP(&mo.ProviderComponents, "provider-components", "p", "", "A yaml file containing the provider components of the management cluster to move the objects from, whose controllers are scaled down during the move if they don't honor the paused annotation")
+	moveCmd.Flags().StringVarP(&mo.Provider, "provider", "", "", "Which provider deployment logic to use to find the Secrets and ConfigMaps to move along with the labeled and owned ones")
+	moveCmd.Flags().StringVarP(&mo.Namespace, "namespace", "n", "", "The namespace of the objects to move. All namespaces are moved if empty, or the namespace of the context if a cluster is given")
+	moveCmd.Flags().StringVarP(&mo.Cluster, "cluster", "", "", "The name of the Cluster to move with its objects. All the Clusters of the namespace are moved if empty")
+	moveCmd.Flags().BoolVarP(&mo.DryRun, "dry-run", "", false, "Print the objects that would be moved without moving them")
+	RootCmd.AddCommand(moveCmd)
+}
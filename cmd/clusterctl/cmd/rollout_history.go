@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	tcmd "k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/clusterclient"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/rollout"
+)
+
+type RolloutHistoryOptions struct {
+	Kubeconfig          string
+	KubeconfigOverrides tcmd.ConfigOverrides
+	Revision            int64
+}
+
+var rho = &RolloutHistoryOptions{}
+
+var rolloutHistoryCmd = &cobra.Command{
+	Use:   "history [machinedeployment name]",
+	Short: "View the rollout history of a MachineDeployment.",
+	Long:  `View the previous revisions of a MachineDeployment along with the changes of their machine templates.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			exitWithHelp(cmd, "Please provide the name of a MachineDeployment.")
+		}
+
+		if err := RunRolloutHistory(rho, args[0]); err != nil {
+			klog.Exit(err)
+		}
+	},
+}
+
+func RunRolloutHistory(rho *RolloutHistoryOptions, name string) error {
+	c, err := clusterclient.NewFromDefaultSearchPath(rho.Kubeconfig, rho.KubeconfigOverrides)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	return rollout.History(c, os.Stdout, c.GetContextNamespace(), name, rho.Revision)
+}
+
+func init() {
+	rolloutHistoryCmd.Flags().StringVarP(&rho.Kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use")
+	rolloutHistoryCmd.Flags().Int64VarP(&rho.Revision, "revision", "", 0, "Show the machine template of this revision")
+	// BindContextFlags will bind the flags cluster, namespace, and user
+	tcmd.BindContextFlags(&rho.KubeconfigOverrides.Context, rolloutHistoryCmd.Flags(), tcmd.RecommendedContextOverrideFlags(""))
+	rolloutCmd.AddCommand(rolloutHistoryCmd)
+}
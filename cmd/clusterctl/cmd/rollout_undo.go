@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	tcmd "k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/clusterclient"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/rollout"
+)
+
+type RolloutUndoOptions struct {
+	Kubeconfig          string
+	KubeconfigOverrides tcmd.ConfigOverrides
+	ToRevision          int64
+}
+
+var ruo = &RolloutUndoOptions{}
+
+var rolloutUndoCmd = &cobra.Command{
+	Use:   "undo [machinedeployment name]",
+	Short: "Roll back a MachineDeployment to a previous revision.",
+	Long:  `Roll back a MachineDeployment to a previous revision. By default the MachineDeployment is rolled back to the revision before the current one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			exitWithHelp(cmd, "Please provide the name of a MachineDeployment.")
+		}
+
+		if err := RunRolloutUndo(ruo, args[0]); err != nil {
+			klog.Exit(err)
+		}
+	},
+}
+
+func RunRolloutUndo(ruo *RolloutUndoOptions, name string) error {
+	c, err := clusterclient.NewFromDefaultSearchPath(ruo.Kubeconfig, ruo.KubeconfigOverrides)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := rollout.Undo(c, c.GetContextNamespace(), name, ruo.ToRevision); err != nil {
+		return err
+	}
+
+	fmt.Printf("MachineDeployment %q rolled back\n", name)
+	return nil
+}
+
+func init() {
+	rolloutUndoCmd.Flags().StringVarP(&ruo.Kubeconfig, "kubeconfig", "", "", "Path to the kubeconfig file to use")
+	rolloutUndoCmd.Flags().Int64VarP(&ruo.ToRevision, "to-revision", "", 0, "The revision to roll back to. Default to 0 (last revision)")
+	// BindContextFlags will bind the flags cluster, namespace, and user
+	tcmd.BindContextFlags(&ruo.KubeconfigOverrides.Context, rolloutUndoCmd.Flags(), tcmd.RecommendedContextOverrideFlags(""))
+	rolloutCmd.AddCommand(rolloutUndoCmd)
+}
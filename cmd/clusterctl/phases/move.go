@@ -0,0 +1,586 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/provider"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+type moveSourceClient interface {
+	sourceClient
+	DeleteConfigMap(namespace, name string) error
+	DeleteSecret(namespace, name string) error
+}
+
+// MoveOptions selects the objects moved between management clusters.
+type MoveOptions struct {
+	// Namespace restricts the move to the objects in this namespace. The
+	// objects in all namespaces are moved if it is empty.
+	Namespace string
+	// Cluster restricts the move to the Cluster with this name in the
+	// namespace and the objects associated with it.
+	Cluster string
+	// Dependents, if set, lists the Secrets and ConfigMaps the moved objects
+	// depend on along with the labeled and owned ones.
+	Dependents provider.DependentsLister
+}
+
+// MoveNode is an object moved between management clusters, with the objects
+// moved along with it.
+type MoveNode struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Children  []*MoveNode
+
+	object metav1.Object
+}
+
+// BuildMoveGraph returns the objects of the source cluster selected by the
+// options: the MachineClasses they use, then the Clusters and the
+// MachineDeployments, MachineSets and Machines not associated with a Cluster.
+// Secrets and ConfigMaps are moved with the objects owning them, or with the
+// Cluster whose name they are labeled with. The ones only listed by the
+// provider come last.
+func BuildMoveGraph(source moveSourceClient, options MoveOptions) ([]*MoveNode, error) {
+	if options.Cluster != "" && options.Namespace == "" {
+		return nil, errors.New("the namespace of the Cluster to move is required")
+	}
+	b := &moveGraphBuilder{source: source, seen: map[string]bool{}}
+
+	var clusters []*clusterv1.Cluster
+	if options.Cluster != "" {
+		cluster, err := source.GetCluster(options.Cluster, options.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if cluster == nil {
+			return nil, errors.Errorf("Cluster %s/%s not found in source cluster", options.Namespace, options.Cluster)
+		}
+		clusters = append(clusters, cluster)
+	} else {
+		var err error
+		if clusters, err = source.GetClusters(options.Namespace); err != nil {
+			return nil, err
+		}
+	}
+
+	var graph []*MoveNode
+	for _, cluster := range clusters {
+		n := b.add(&graph, kindCluster, cluster)
+		if err := b.addClusterChildren(n, cluster); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Cluster == "" {
+		machineDeployments, err := source.GetMachineDeployments(options.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, md := range machineDeployments {
+			if n := b.add(&graph, kindMachineDeployment, md); n != nil {
+				if err := b.addMachineDeploymentChildren(n, md); err != nil {
+					return nil, err
+				}
+			}
+		}
+		machineSets, err := source.GetMachineSets(options.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, ms := range machineSets {
+			if n := b.add(&graph, kindMachineSet, ms); n != nil {
+				if err := b.addMachineSetChildren(n, ms); err != nil {
+					return nil, err
+				}
+			}
+		}
+		machines, err := source.GetMachines(options.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range machines {
+			b.add(&graph, kindMachine, m)
+		}
+	}
+
+	machineClasses, err := b.machineClasses(graph, options)
+	if err != nil {
+		return nil, err
+	}
+	graph = append(machineClasses, graph...)
+
+	if err := b.addDependents(&graph, options.Dependents); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+type moveGraphBuilder struct {
+	source moveSourceClient
+	// seen records the objects already in the graph, by kind, namespace and name
+	seen map[string]bool
+}
+
+// add adds the object to the nodes, unless it is already in the graph.
+func (b *moveGraphBuilder) add(nodes *[]*MoveNode, kind string, obj metav1.Object) *MoveNode {
+	key := kind + "/" + obj.GetNamespace() + "/" + obj.GetName()
+	if b.seen[key] {
+		return nil
+	}
+	b.seen[key] = true
+	n := &MoveNode{Kind: kind, Namespace: obj.GetNamespace(), Name: obj.GetName(), object: obj}
+	*nodes = append(*nodes, n)
+	return n
+}
+
+// addClusterChildren adds the objects moved with the cluster, in the order
+// moveCluster moves them.
+func (b *moveGraphBuilder) addClusterChildren(n *MoveNode, cluster *clusterv1.Cluster) error {
+	machineDeployments, err := b.source.GetMachineDeploymentsForCluster(cluster)
+	if err != nil {
+		return err
+	}
+	for _, md := range machineDeployments {
+		if c := b.add(&n.Children, kindMachineDeployment, md); c != nil {
+			if err := b.addMachineDeploymentChildren(c, md); err != nil {
+				return err
+			}
+		}
+	}
+	machineSets, err := b.source.GetMachineSetsForCluster(cluster)
+	if err != nil {
+		return err
+	}
+	for _, ms := range machineSets {
+		if c := b.add(&n.Children, kindMachineSet, ms); c != nil {
+			if err := b.addMachineSetChildren(c, ms); err != nil {
+				return err
+			}
+		}
+	}
+	machines, err := b.source.GetMachinesForCluster(cluster)
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		b.add(&n.Children, kindMachine, m)
+	}
+	return nil
+}
+
+func (b *moveGraphBuilder) addMachineDeploymentChildren(n *MoveNode, md *clusterv1.MachineDeployment) error {
+	machineSets, err := b.source.GetMachineSetsForMachineDeployment(md)
+	if err != nil {
+		return err
+	}
+	for _, ms := range machineSets {
+		if c := b.add(&n.Children, kindMachineSet, ms); c != nil {
+			if err := b.addMachineSetChildren(c, ms); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *moveGraphBuilder) addMachineSetChildren(n *MoveNode, ms *clusterv1.MachineSet) error {
+	machines, err := b.source.GetMachinesForMachineSet(ms)
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		b.add(&n.Children, kindMachine, m)
+	}
+	return nil
+}
+
+// addDependents adds the Secrets and ConfigMaps the objects in the graph
+// depend on to their first owner in the graph, or else to the Cluster whose
+// name they are labeled with. The ones only listed by the provider are added
+// to the top of the graph.
+func (b *moveGraphBuilder) addDependents(graph *[]*MoveNode, lister provider.DependentsLister) error {
+	var objects []metav1.Object
+	owners := map[types.UID]*MoveNode{}
+	clusters := map[string]*MoveNode{}
+	walkMoveGraph(*graph, func(n *MoveNode) {
+		objects = append(objects, n.object)
+		owners[n.object.GetUID()] = n
+		if n.Kind == kindCluster {
+			clusters[n.Namespace+"/"+n.Name] = n
+		}
+	})
+
+	d, err := listDependents(b.source, objects, lister)
+	if err != nil {
+		return err
+	}
+	parent := func(obj metav1.Object) *[]*MoveNode {
+		for _, ref := range obj.GetOwnerReferences() {
+			if n, ok := owners[ref.UID]; ok {
+				return &n.Children
+			}
+		}
+		if n, ok := clusters[obj.GetNamespace()+"/"+obj.GetLabels()[clusterv1.MachineClusterLabelName]]; ok {
+			return &n.Children
+		}
+		return graph
+	}
+	for _, secret := range d.secrets {
+		b.add(parent(secret), kindSecret, secret)
+	}
+	for _, configMap := range d.configMaps {
+		b.add(parent(configMap), kindConfigMap, configMap)
+	}
+	return nil
+}
+
+// machineClasses returns the MachineClasses used by the objects in the graph,
+// and all the MachineClasses of the namespace unless a single Cluster is moved.
+func (b *moveGraphBuilder) machineClasses(graph []*MoveNode, options MoveOptions) ([]*MoveNode, error) {
+	refs := map[string]bool{}
+	namespaces := map[string]bool{}
+	walkMoveGraph(graph, func(n *MoveNode) {
+		if namespace, name := machineClassRef(n.object); name != "" {
+			refs[namespace+"/"+name] = true
+			namespaces[namespace] = true
+		}
+	})
+	if options.Cluster == "" {
+		namespaces[options.Namespace] = true
+	}
+
+	var nodes []*MoveNode
+	for _, namespace := range sortedKeys(namespaces) {
+		machineClasses, err := b.source.GetMachineClasses(namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, mc := range machineClasses {
+			inNamespace := options.Cluster == "" && (options.Namespace == "" || mc.Namespace == options.Namespace)
+			if inNamespace || refs[mc.Namespace+"/"+mc.Name] {
+				b.add(&nodes, kindMachineClass, mc)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// machineClassRef returns the namespace and name of the MachineClass the
+// provider configuration of the object is sourced from, if any.
+func machineClassRef(obj metav1.Object) (string, string) {
+	var providerSpec clusterv1.ProviderSpec
+	switch o := obj.(type) {
+	case *clusterv1.Machine:
+		providerSpec = o.Spec.ProviderSpec
+	case *clusterv1.MachineSet:
+		providerSpec = o.Spec.Template.Spec.ProviderSpec
+	case *clusterv1.MachineDeployment:
+		providerSpec = o.Spec.Template.Spec.ProviderSpec
+	}
+	if providerSpec.ValueFrom == nil || providerSpec.ValueFrom.MachineClass == nil || providerSpec.ValueFrom.MachineClass.ObjectReference == nil {
+		return "", ""
+	}
+	ref := providerSpec.ValueFrom.MachineClass.ObjectReference
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+	return namespace, ref.Name
+}
+
+// walkMoveGraph calls fn for every node of the graph, parents first.
+func walkMoveGraph(nodes []*MoveNode, fn func(*MoveNode)) {
+	for _, n := range nodes {
+		fn(n)
+		walkMoveGraph(n.Children, fn)
+	}
+}
+
+// moveGraphNamespaces returns the namespaces of the objects in the graph.
+func moveGraphNamespaces(graph []*MoveNode) []string {
+	namespaces := map[string]bool{}
+	walkMoveGraph(graph, func(n *MoveNode) {
+		namespaces[n.Namespace] = true
+	})
+	return sortedKeys(namespaces)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Move moves the objects selected by the options from the source management
+// cluster to the target one, which must already run the providers. The
+// objects are paused in the source cluster during the move. The controllers
+// of the provider components, which may be empty, are scaled down as well for
+// the ones which don't honor the paused annotation. Whatever the outcome, the
+// controllers are scaled back up afterwards and the objects which were not
+// moved are unpaused, as the source cluster keeps managing them.
+func Move(source moveSourceClient, target targetClient, providerComponents string, options MoveOptions) (err error) {
+	klog.V(4).Info("Ensuring cluster v1alpha1 resources are available on the source cluster")
+	if err := source.WaitForClusterV1alpha1Ready(); err != nil {
+		return errors.New("cluster v1alpha1 resource not ready on source cluster")
+	}
+
+	klog.V(4).Info("Ensuring cluster v1alpha1 resources are available on the target cluster")
+	if err := target.WaitForClusterV1alpha1Ready(); err != nil {
+		return errors.New("cluster v1alpha1 resource not ready on target cluster")
+	}
+
+	klog.V(4).Info("Parsing list of cluster-api controllers from provider components")
+	controllers, err := parseControllers(providerComponents)
+	if err != nil {
+		return errors.Wrap(err, "Failed to extract Cluster API Controllers from the provider components")
+	}
+
+	klog.V(4).Info("Retrieving list of objects to move")
+	graph, err := BuildMoveGraph(source, options)
+	if err != nil {
+		return err
+	}
+
+	var paused []*MoveNode
+	walkMoveGraph(graph, func(n *MoveNode) {
+		switch n.Kind {
+		case kindCluster, kindMachineDeployment, kindMachineSet, kindMachine:
+			paused = append(paused, n)
+		}
+	})
+	j := &journal{state: &PivotJournal{}}
+	defer func() {
+		if resumeErr := resumeMoveSource(source, controllers, paused, j); resumeErr != nil {
+			if err != nil {
+				klog.Error(resumeErr)
+				return
+			}
+			err = resumeErr
+		}
+	}()
+
+	klog.V(4).Info("Pausing the objects to move")
+	for _, n := range paused {
+		if err := setPaused(source, n.Kind, n.Namespace, n.Name, true); err != nil {
+			return err
+		}
+	}
+
+	for _, controller := range controllers {
+		klog.V(4).Infof("Scaling down controller %s/%s", controller.Namespace, controller.Name)
+		if err := scaleController(source, controller, 0); err != nil {
+			return errors.Wrapf(err, "Failed to scale down %s/%s", controller.Namespace, controller.Name)
+		}
+	}
+
+	if err := move(source, target, graph, j); err != nil {
+		return errors.Wrap(err, "unable to move Cluster API objects")
+	}
+	return nil
+}
+
+// resumeMoveSource unpauses the objects which were not moved and scales the
+// controllers back up in the source cluster. The objects a failed move copied
+// to the target cluster without deleting them from the source cluster are left
+// paused there, so that the two management clusters don't both manage them.
+func resumeMoveSource(source moveSourceClient, controllers []JournalController, paused []*MoveNode, j *journal) error {
+	var copied []string
+	for _, n := range paused {
+		if o := j.find(n.Kind, n.Namespace, n.Name); o != nil && o.State == ObjectCopied {
+			copied = append(copied, n.Kind+" "+n.Namespace+"/"+n.Name)
+			continue
+		}
+		if err := setPaused(source, n.Kind, n.Namespace, n.Name, false); err != nil {
+			return err
+		}
+	}
+	if len(copied) > 0 {
+		klog.Warningf("Left paused in the source cluster as they were copied to the target cluster: %s", strings.Join(copied, ", "))
+	}
+
+	for _, controller := range controllers {
+		klog.V(4).Infof("Scaling up controller %s/%s", controller.Namespace, controller.Name)
+		if err := scaleController(source, controller, controller.Replicas); err != nil {
+			return errors.Wrapf(err, "Failed to scale up %s/%s", controller.Namespace, controller.Name)
+		}
+	}
+	return nil
+}
+
+func move(from moveSourceClient, to targetClient, graph []*MoveNode, j *journal) error {
+	uids := uidMap{}
+
+	for _, namespace := range moveGraphNamespaces(graph) {
+		klog.V(4).Infof("Ensuring namespace %q exists on target cluster", namespace)
+		if err := to.EnsureNamespace(namespace); err != nil {
+			return errors.Wrapf(err, "unable to ensure namespace %q in target cluster", namespace)
+		}
+	}
+
+	var machineClasses []*clusterv1.MachineClass
+	d := &dependents{seen: map[string]bool{}}
+	clusters := map[string]bool{}
+	walkMoveGraph(graph, func(n *MoveNode) {
+		switch obj := n.object.(type) {
+		case *clusterv1.MachineClass:
+			machineClasses = append(machineClasses, obj)
+		case *clusterv1.Cluster:
+			clusters[obj.Namespace+"/"+obj.Name] = true
+		case *corev1.Secret:
+			d.addSecret(obj)
+		case *corev1.ConfigMap:
+			d.addConfigMap(obj)
+		}
+	})
+
+	for _, mc := range machineClasses {
+		// New objects cannot have a specified resource version. Clear it out.
+		mc.SetResourceVersion("")
+		// MachineClasses may be shared with objects already in the target cluster.
+		if err := to.CreateMachineClass(mc); err != nil && !apierrors.IsAlreadyExists(errors.Cause(err)) {
+			return errors.Wrapf(err, "error copying MachineClass %s/%s to target cluster", mc.Namespace, mc.Name)
+		}
+	}
+
+	// Deleting the owners of the Secrets and ConfigMaps from the source cluster
+	// deletes them, so they are copied first and adopted once their owners are.
+	copied, err := copyDependents(to, d, j)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range graph {
+		var err error
+		switch obj := n.object.(type) {
+		case *clusterv1.Cluster:
+			err = moveCluster(from, to, obj, j, uids)
+		case *clusterv1.MachineDeployment:
+			err = moveMachineDeployment(from, to, obj, j, uids)
+		case *clusterv1.MachineSet:
+			err = moveMachineSet(from, to, obj, j, uids)
+		case *clusterv1.Machine:
+			err = moveMachine(from, to, obj, j, uids)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to move %s %s/%s", n.Kind, n.Namespace, n.Name)
+		}
+	}
+
+	if err := adoptDependents(to, copied, uids); err != nil {
+		return err
+	}
+	if err := deleteMovedDependents(from, copied, clusters, uids); err != nil {
+		return err
+	}
+
+	return deleteUnusedMachineClasses(from, machineClasses)
+}
+
+// deleteMovedDependents deletes the copied Secrets and ConfigMaps owned by the
+// moved objects or labeled with the name of a moved Cluster from the source
+// cluster. The ones only listed by the provider are kept, as objects left in
+// the source cluster may still use them.
+func deleteMovedDependents(from moveSourceClient, copied *dependents, clusters map[string]bool, uids uidMap) error {
+	moved := func(obj metav1.Object) bool {
+		if clusters[obj.GetNamespace()+"/"+obj.GetLabels()[clusterv1.MachineClusterLabelName]] {
+			return true
+		}
+		for _, ref := range obj.GetOwnerReferences() {
+			if _, ok := uids[ref.UID]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	for _, secret := range copied.secrets {
+		if !moved(secret) {
+			continue
+		}
+		if err := from.DeleteSecret(secret.Namespace, secret.Name); err != nil && !apierrors.IsNotFound(errors.Cause(err)) {
+			return errors.Wrapf(err, "error deleting Secret %s/%s from source cluster", secret.Namespace, secret.Name)
+		}
+	}
+	for _, configMap := range copied.configMaps {
+		if !moved(configMap) {
+			continue
+		}
+		if err := from.DeleteConfigMap(configMap.Namespace, configMap.Name); err != nil && !apierrors.IsNotFound(errors.Cause(err)) {
+			return errors.Wrapf(err, "error deleting ConfigMap %s/%s from source cluster", configMap.Namespace, configMap.Name)
+		}
+	}
+	return nil
+}
+
+// deleteUnusedMachineClasses deletes the moved MachineClasses from the source
+// cluster, unless objects left in the source cluster still use them.
+func deleteUnusedMachineClasses(from moveSourceClient, machineClasses []*clusterv1.MachineClass) error {
+	if len(machineClasses) == 0 {
+		return nil
+	}
+
+	used := map[string]bool{}
+	machineDeployments, err := from.GetMachineDeployments("")
+	if err != nil {
+		return err
+	}
+	for _, md := range machineDeployments {
+		namespace, name := machineClassRef(md)
+		used[namespace+"/"+name] = true
+	}
+	machineSets, err := from.GetMachineSets("")
+	if err != nil {
+		return err
+	}
+	for _, ms := range machineSets {
+		namespace, name := machineClassRef(ms)
+		used[namespace+"/"+name] = true
+	}
+	machines, err := from.GetMachines("")
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		namespace, name := machineClassRef(m)
+		used[namespace+"/"+name] = true
+	}
+
+	for _, mc := range machineClasses {
+		if used[mc.Namespace+"/"+mc.Name] {
+			klog.V(4).Infof("Keeping MachineClass %s/%s still used in source cluster", mc.Namespace, mc.Name)
+			continue
+		}
+		if err := from.DeleteMachineClass(mc.Namespace, mc.Name); err != nil {
+			return errors.Wrapf(err, "error deleting MachineClass %s/%s from source cluster", mc.Namespace, mc.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// WithSecret adds a Secret, labeled with the name of the cluster and owned by
+// the machine if they are not empty.
+func (s *sourcer) WithSecret(ns, cluster, machine, name string) *sourcer {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			UID:       sourceUID("Secret", ns, name),
+		},
+	}
+	if cluster != "" {
+		secret.Labels = map[string]string{clusterv1.MachineClusterLabelName: cluster}
+	}
+	if machine != "" {
+		secret.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: clusterv1.SchemeGroupVersion.String(),
+				Kind:       "Machine",
+				Name:       machine,
+				UID:        sourceUID("Machine", ns, machine),
+			},
+		}
+	}
+	s.secrets[ns] = append(s.secrets[ns], secret)
+	return s
+}
+
+// WithMachineClassRef sources the provider configuration of the machine from
+// the machine class.
+func (s *sourcer) WithMachineClassRef(ns, machine, machineClass string) *sourcer {
+	for _, m := range s.machines[ns] {
+		if m.Name == machine {
+			m.Spec.ProviderSpec.ValueFrom = &clusterv1.ProviderSpecSource{
+				MachineClass: &clusterv1.MachineClassRef{ObjectReference: &corev1.ObjectReference{Name: machineClass}},
+			}
+		}
+	}
+	return s
+}
+
+func (s *sourcer) GetCluster(name, ns string) (*clusterv1.Cluster, error) {
+	for _, c := range s.clusters[ns] {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+func (s *sourcer) GetSecret(ns, name string) (*corev1.Secret, error) {
+	for _, secret := range s.secrets[ns] {
+		if secret.Name == name {
+			return secret, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+}
+func (s *sourcer) GetSecrets(ns string) ([]*corev1.Secret, error) {
+	return s.secrets[ns], nil
+}
+func (s *sourcer) DeleteSecret(ns, name string) error {
+	newSecrets := []*corev1.Secret{}
+	for _, secret := range s.secrets[ns] {
+		if secret.Name != name {
+			newSecrets = append(newSecrets, secret)
+		}
+	}
+	s.secrets[ns] = newSecrets
+	return nil
+}
+
+func (t *target) CreateSecret(secret *corev1.Secret) error {
+	if _, err := t.GetSecret(secret.Namespace, secret.Name); err == nil {
+		return apierrors.NewAlreadyExists(schema.GroupResource{Resource: "secrets"}, secret.Name)
+	}
+	t.secrets[secret.Namespace] = append(t.secrets[secret.Namespace], &corev1.Secret{ObjectMeta: t.created(secret.ObjectMeta), Data: secret.Data})
+	return nil
+}
+func (t *target) GetSecret(ns, name string) (*corev1.Secret, error) {
+	for _, secret := range t.secrets[ns] {
+		if secret.Name == name {
+			return secret.DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+}
+func (t *target) UpdateSecret(secret *corev1.Secret) error {
+	for _, existing := range t.secrets[secret.Namespace] {
+		if existing.Name == secret.Name {
+			existing.ObjectMeta = secret.ObjectMeta
+			existing.Data = secret.Data
+			return nil
+		}
+	}
+	return fmt.Errorf("no secret found in ns %q with name %q", secret.Namespace, secret.Name)
+}
+
+func newMoveTestSource() *sourcer {
+	return newSourcer().
+		WithCluster("ns1", "cluster1").
+		WithMachineDeployment("ns1", "cluster1", "deployment1").
+		WithMachineSet("ns1", "cluster1", "deployment1", "machineset1").
+		WithMachine("ns1", "cluster1", "machineset1", "machine1").
+		WithMachine("ns1", "cluster1", "", "machine2").
+		WithCluster("ns1", "cluster2").
+		WithMachine("ns1", "cluster2", "", "machine3").
+		WithMachine("ns1", "", "", "machine4").
+		WithMachine("ns2", "", "", "machine5").
+		WithMachineClass("ns1", "shared").
+		WithMachineClass("ns1", "exclusive").
+		WithMachineClass("ns1", "unused").
+		WithMachineClassRef("ns1", "machine1", "shared").
+		WithMachineClassRef("ns1", "machine2", "exclusive").
+		WithMachineClassRef("ns1", "machine3", "shared").
+		WithSecret("ns1", "cluster1", "", "cluster1-kubeconfig").
+		WithSecret("ns1", "", "machine1", "machine1-bootstrap").
+		WithSecret("ns1", "", "", "unrelated")
+}
+
+// formatMoveGraph returns a line per node of the graph, indented by depth.
+func formatMoveGraph(nodes []*MoveNode, indent string) []string {
+	var lines []string
+	for _, n := range nodes {
+		lines = append(lines, fmt.Sprintf("%s%s %s/%s", indent, n.Kind, n.Namespace, n.Name))
+		lines = append(lines, formatMoveGraph(n.Children, indent+"  ")...)
+	}
+	return lines
+}
+
+func TestBuildMoveGraph(t *testing.T) {
+	graph, err := BuildMoveGraph(newMoveTestSource(), MoveOptions{Namespace: "ns1", Cluster: "cluster1"})
+	if err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+
+	expected := []string{
+		"MachineClass ns1/shared",
+		"MachineClass ns1/exclusive",
+		"Cluster ns1/cluster1",
+		"  MachineDeployment ns1/deployment1",
+		"    MachineSet ns1/machineset1",
+		"      Machine ns1/machine1",
+		"        Secret ns1/machine1-bootstrap",
+		"  Machine ns1/machine2",
+		"  Secret ns1/cluster1-kubeconfig",
+	}
+	if got := formatMoveGraph(graph, ""); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected graph\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestBuildMoveGraphMissingCluster(t *testing.T) {
+	if _, err := BuildMoveGraph(newMoveTestSource(), MoveOptions{Namespace: "ns1", Cluster: "missing"}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if _, err := BuildMoveGraph(newMoveTestSource(), MoveOptions{Cluster: "cluster1"}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+}
+
+func names(objects ...metav1.Object) []string {
+	var names []string
+	for _, o := range objects {
+		names = append(names, o.GetName())
+	}
+	return names
+}
+
+func TestMoveCluster(t *testing.T) {
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newMoveTestSource()
+	target := newTarget()
+
+	if err := Move(source, target, pc.String(), MoveOptions{Namespace: "ns1", Cluster: "cluster1"}); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+
+	if got := targetCounts(target); got != [5]int{1, 1, 1, 2, 2} {
+		t.Errorf("expected the objects of cluster1 to be moved to the target cluster, got counts %v", got)
+	}
+	if got := sourceCounts(source); got != [5]int{1, 0, 0, 3, 2} {
+		t.Errorf("expected the other objects to be left in the source cluster, got counts %v", got)
+	}
+	var sourceClasses []metav1.Object
+	for _, mc := range source.machineClasses["ns1"] {
+		sourceClasses = append(sourceClasses, mc)
+	}
+	if got := strings.Join(names(sourceClasses...), ","); got != "shared,unused" {
+		t.Errorf("expected the MachineClasses still used in the source cluster to be kept, got %s", got)
+	}
+
+	var sourceSecrets, targetSecrets []metav1.Object
+	for _, s := range source.secrets["ns1"] {
+		sourceSecrets = append(sourceSecrets, s)
+	}
+	for _, s := range target.secrets["ns1"] {
+		targetSecrets = append(targetSecrets, s)
+	}
+	if got := strings.Join(names(sourceSecrets...), ","); got != "unrelated" {
+		t.Errorf("expected only the unrelated Secret to be left in the source cluster, got %s", got)
+	}
+	if got := strings.Join(names(targetSecrets...), ","); got != "machine1-bootstrap,cluster1-kubeconfig" {
+		t.Errorf("expected the Secrets of cluster1 to be moved, got %s", got)
+	}
+
+	machine, err := target.GetMachine("ns1", "machine1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := target.GetSecret("ns1", "machine1-bootstrap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].UID != machine.UID {
+		t.Errorf("expected the Secret to be owned by %q in the target cluster, got %v", machine.UID, secret.OwnerReferences)
+	}
+
+	if source.replicas["controller"] != 1 {
+		t.Errorf("expected the controller to be scaled back up in the source cluster, got %d replicas", source.replicas["controller"])
+	}
+}
+
+func TestMoveDependents(t *testing.T) {
+	source := newMoveTestSource().
+		WithSecret("ns1", "", "", "credentials").
+		WithConfigMap("ns1", "cluster1", "cluster1-config")
+	lister := dependentsLister{"cluster1": {{Kind: "Secret", Name: "credentials"}}}
+	target := newTarget()
+	target.secrets["ns1"] = []*corev1.Secret{{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1-kubeconfig", Namespace: "ns1"},
+		Data:       map[string][]byte{"value": []byte("existing")},
+	}}
+
+	graph, err := BuildMoveGraph(source, MoveOptions{Namespace: "ns1", Cluster: "cluster1", Dependents: lister})
+	if err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	expected := []string{
+		"MachineClass ns1/shared",
+		"MachineClass ns1/exclusive",
+		"Cluster ns1/cluster1",
+		"  MachineDeployment ns1/deployment1",
+		"    MachineSet ns1/machineset1",
+		"      Machine ns1/machine1",
+		"        Secret ns1/machine1-bootstrap",
+		"  Machine ns1/machine2",
+		"  Secret ns1/cluster1-kubeconfig",
+		"  ConfigMap ns1/cluster1-config",
+		"Secret ns1/credentials",
+	}
+	if got := formatMoveGraph(graph, ""); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected graph\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	if err := Move(source, target, "", MoveOptions{Namespace: "ns1", Cluster: "cluster1", Dependents: lister}); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	if got := targetNames(target); got != "Secret/ns1/cluster1-kubeconfig,Secret/ns1/machine1-bootstrap,Secret/ns1/credentials,ConfigMap/ns1/cluster1-config" {
+		t.Errorf("expected the Secrets and ConfigMaps of cluster1 in the target cluster, got %q", got)
+	}
+	if got := string(target.secrets["ns1"][0].Data["value"]); got != "existing" {
+		t.Errorf("expected the existing Secret to be left alone, got %q", got)
+	}
+	var sourceSecrets []metav1.Object
+	for _, s := range source.secrets["ns1"] {
+		sourceSecrets = append(sourceSecrets, s)
+	}
+	if got := strings.Join(names(sourceSecrets...), ","); got != "cluster1-kubeconfig,unrelated,credentials" {
+		t.Errorf("expected the Secrets not copied or only listed by the provider to be left in the source cluster, got %s", got)
+	}
+	if len(source.configMaps["ns1"]) != 0 {
+		t.Errorf("expected the ConfigMap to be moved, got %v", source.configMaps["ns1"])
+	}
+}
+
+func TestMoveNamespace(t *testing.T) {
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newMoveTestSource()
+	target := newTarget()
+
+	if err := Move(source, target, pc.String(), MoveOptions{Namespace: "ns1"}); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+
+	if got := targetCounts(target); got != [5]int{2, 1, 1, 4, 3} {
+		t.Errorf("expected the objects of ns1 to be moved to the target cluster, got counts %v", got)
+	}
+	if got := sourceCounts(source); got != [5]int{0, 0, 0, 1, 0} {
+		t.Errorf("expected the objects of ns2 to be left in the source cluster, got counts %v", got)
+	}
+}
+
+// failDeleteSourcer fails to delete the machine named failMachine.
+type failDeleteSourcer struct {
+	*sourcer
+	failMachine string
+}
+
+func (s *failDeleteSourcer) ForceDeleteMachine(ns, name string) error {
+	if name == s.failMachine {
+		return errors.New("connection reset by peer")
+	}
+	return s.sourcer.ForceDeleteMachine(ns, name)
+}
+
+func TestMoveFailureResumesSource(t *testing.T) {
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newMoveTestSource()
+	target := &flakyTarget{target: newTarget(), failMachine: "machine2", fail: true}
+
+	if err := Move(source, target, pc.String(), MoveOptions{Namespace: "ns1"}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if _, err := source.GetMachine("ns1", "machine1"); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected machine1 to be moved before the failure, got %v", err)
+	}
+	if got := pausedValues(source.machines); len(got) != 0 {
+		t.Errorf("expected the machines left in the source cluster to be unpaused, got %v", got)
+	}
+	// cluster1 is copied before its Machines and deleted after them.
+	for _, c := range source.clusters["ns1"] {
+		_, ok := c.Annotations[clusterv1.PausedAnnotation]
+		if c.Name == "cluster1" && !ok {
+			t.Errorf("expected Cluster %q copied to the target cluster to stay paused in the source cluster", c.Name)
+		}
+		if c.Name != "cluster1" && ok {
+			t.Errorf("expected Cluster %q to be unpaused in the source cluster", c.Name)
+		}
+	}
+	if source.replicas["controller"] != 1 {
+		t.Errorf("expected the controller to be scaled back up in the source cluster, got %d replicas", source.replicas["controller"])
+	}
+}
+
+func TestMoveFailureLeavesCopiesPaused(t *testing.T) {
+	pc := &providerComponents{names: []string{"controller"}}
+	source := &failDeleteSourcer{sourcer: newMoveTestSource(), failMachine: "machine2"}
+	target := newTarget()
+
+	if err := Move(source, target, pc.String(), MoveOptions{Namespace: "ns1", Cluster: "cluster1"}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if _, err := target.GetMachine("ns1", "machine2"); err != nil {
+		t.Fatalf("expected machine2 to be copied to the target cluster, got %v", err)
+	}
+	expected := map[string]string{"machine2": pausedByClusterctl}
+	if got := pausedValues(source.machines); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected only the machine copied to the target cluster to stay paused, got %v", got)
+	}
+	if source.replicas["controller"] != 1 {
+		t.Errorf("expected the controller to be scaled back up in the source cluster, got %d replicas", source.replicas["controller"])
+	}
+}
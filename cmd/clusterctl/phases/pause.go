@@ -0,0 +1,204 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+const (
+	kindDeployment  = "Deployment"
+	kindStatefulSet = "StatefulSet"
+
+	// pausedByClusterctl is the value of the paused annotation set by clusterctl, which tells it apart from the
+	// annotations set by users that clusterctl leaves alone.
+	pausedByClusterctl = "clusterctl"
+)
+
+// pauseClient pauses the Cluster API objects of a cluster.
+type pauseClient interface {
+	GetCluster(string, string) (*clusterv1.Cluster, error)
+	GetClusters(string) ([]*clusterv1.Cluster, error)
+	GetMachine(namespace, name string) (*clusterv1.Machine, error)
+	GetMachineDeployment(namespace, name string) (*clusterv1.MachineDeployment, error)
+	GetMachineDeployments(string) ([]*clusterv1.MachineDeployment, error)
+	GetMachines(namespace string) ([]*clusterv1.Machine, error)
+	GetMachineSet(string, string) (*clusterv1.MachineSet, error)
+	GetMachineSets(namespace string) ([]*clusterv1.MachineSet, error)
+	UpdateClusterObject(*clusterv1.Cluster) error
+	UpdateMachine(*clusterv1.Machine) error
+	UpdateMachineDeployment(*clusterv1.MachineDeployment) error
+	UpdateMachineSet(*clusterv1.MachineSet) error
+}
+
+// scaleClient scales the controllers of a cluster.
+type scaleClient interface {
+	ScaleDeployment(string, string, int32) error
+	ScaleStatefulSet(string, string, int32) error
+}
+
+// setObjectsPaused sets or removes the paused annotation of clusterctl on all the Clusters, MachineDeployments,
+// MachineSets and Machines of the cluster.
+func setObjectsPaused(c pauseClient, paused bool) error {
+	clusters, err := c.GetClusters("")
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		if err := setPaused(c, kindCluster, cluster.Namespace, cluster.Name, paused); err != nil {
+			return err
+		}
+	}
+	machineDeployments, err := c.GetMachineDeployments("")
+	if err != nil {
+		return err
+	}
+	for _, md := range machineDeployments {
+		if err := setPaused(c, kindMachineDeployment, md.Namespace, md.Name, paused); err != nil {
+			return err
+		}
+	}
+	machineSets, err := c.GetMachineSets("")
+	if err != nil {
+		return err
+	}
+	for _, ms := range machineSets {
+		if err := setPaused(c, kindMachineSet, ms.Namespace, ms.Name, paused); err != nil {
+			return err
+		}
+	}
+	machines, err := c.GetMachines("")
+	if err != nil {
+		return err
+	}
+	for _, m := range machines {
+		if err := setPaused(c, kindMachine, m.Namespace, m.Name, paused); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPaused sets or removes the paused annotation of clusterctl on the object, so that the controllers which honor it
+// stop or resume reconciling it. Objects which no longer exist are ignored.
+func setPaused(c pauseClient, kind, namespace, name string, paused bool) error {
+	klog.V(4).Infof("Setting %s %s/%s paused to %v", kind, namespace, name, paused)
+	err := retryOnConflict(func() error {
+		switch kind {
+		case kindCluster:
+			cluster, err := c.GetCluster(name, namespace)
+			if err != nil || cluster == nil || !updatePaused(cluster, paused) {
+				return err
+			}
+			return c.UpdateClusterObject(cluster)
+		case kindMachineDeployment:
+			md, err := c.GetMachineDeployment(namespace, name)
+			if err != nil || !updatePaused(md, paused) {
+				return err
+			}
+			return c.UpdateMachineDeployment(md)
+		case kindMachineSet:
+			ms, err := c.GetMachineSet(namespace, name)
+			if err != nil || !updatePaused(ms, paused) {
+				return err
+			}
+			return c.UpdateMachineSet(ms)
+		case kindMachine:
+			m, err := c.GetMachine(namespace, name)
+			if err != nil || !updatePaused(m, paused) {
+				return err
+			}
+			return c.UpdateMachine(m)
+		}
+		return nil
+	})
+	if err != nil && !apierrors.IsNotFound(errors.Cause(err)) {
+		return errors.Wrapf(err, "unable to set %s %s/%s paused to %v", kind, namespace, name, paused)
+	}
+	return nil
+}
+
+// updatePaused sets or removes the paused annotation of clusterctl on the object and reports whether it changed. The
+// paused annotations set by users are left alone.
+func updatePaused(obj metav1.Object, paused bool) bool {
+	annotations := obj.GetAnnotations()
+	value, ok := annotations[clusterv1.PausedAnnotation]
+	if paused == ok || (!paused && value != pausedByClusterctl) {
+		return false
+	}
+	if paused {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[clusterv1.PausedAnnotation] = pausedByClusterctl
+	} else {
+		delete(annotations, clusterv1.PausedAnnotation)
+	}
+	obj.SetAnnotations(annotations)
+	return true
+}
+
+// scaleController scales the StatefulSet or Deployment of the controller.
+func scaleController(c scaleClient, controller JournalController, replicas int32) error {
+	if controller.Kind == kindDeployment {
+		return c.ScaleDeployment(controller.Namespace, controller.Name, replicas)
+	}
+	return c.ScaleStatefulSet(controller.Namespace, controller.Name, replicas)
+}
+
+// parseControllers returns the StatefulSets and Deployments of the provider components, with the number of replicas
+// they run.
+func parseControllers(providerComponents string) ([]JournalController, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(providerComponents), 32)
+
+	controllers := []JournalController{}
+
+	for {
+		var out struct {
+			metav1.TypeMeta   `json:",inline"`
+			metav1.ObjectMeta `json:"metadata,omitempty"`
+			Spec              struct {
+				Replicas *int32 `json:"replicas,omitempty"`
+			} `json:"spec,omitempty"`
+		}
+		err := decoder.Decode(&out)
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if out.Kind == kindStatefulSet || out.Kind == kindDeployment {
+			replicas := int32(1)
+			if out.Spec.Replicas != nil {
+				replicas = *out.Spec.Replicas
+			}
+			controllers = append(controllers, JournalController{Kind: out.Kind, Namespace: out.Namespace, Name: out.Name, Replicas: replicas})
+		}
+	}
+
+	return controllers, nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"reflect"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// The methods below are used to pause the objects of the sourcer.
+
+func (s *sourcer) GetMachine(ns, name string) (*clusterv1.Machine, error) {
+	for _, m := range s.machines[ns] {
+		if m.Name == name {
+			return m.DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "machines"}, name)
+}
+func (s *sourcer) GetMachineDeployment(ns, name string) (*clusterv1.MachineDeployment, error) {
+	for _, md := range s.machineDeployments[ns] {
+		if md.Name == name {
+			return md.DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "machinedeployments"}, name)
+}
+func (s *sourcer) GetMachineSet(ns, name string) (*clusterv1.MachineSet, error) {
+	for _, ms := range s.machineSets[ns] {
+		if ms.Name == name {
+			return ms.DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "machinesets"}, name)
+}
+func (s *sourcer) UpdateClusterObject(c *clusterv1.Cluster) error {
+	for _, existing := range s.clusters[c.Namespace] {
+		if existing.Name == c.Name {
+			existing.ObjectMeta = c.ObjectMeta
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{Resource: "clusters"}, c.Name)
+}
+func (s *sourcer) UpdateMachine(m *clusterv1.Machine) error {
+	for _, existing := range s.machines[m.Namespace] {
+		if existing.Name == m.Name {
+			existing.ObjectMeta = m.ObjectMeta
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{Resource: "machines"}, m.Name)
+}
+func (s *sourcer) UpdateMachineDeployment(md *clusterv1.MachineDeployment) error {
+	for _, existing := range s.machineDeployments[md.Namespace] {
+		if existing.Name == md.Name {
+			existing.ObjectMeta = md.ObjectMeta
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{Resource: "machinedeployments"}, md.Name)
+}
+func (s *sourcer) UpdateMachineSet(ms *clusterv1.MachineSet) error {
+	for _, existing := range s.machineSets[ms.Namespace] {
+		if existing.Name == ms.Name {
+			existing.ObjectMeta = ms.ObjectMeta
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{Resource: "machinesets"}, ms.Name)
+}
+
+// pausedValues returns the values of the paused annotations of the machines, by name.
+func pausedValues(machines map[string][]*clusterv1.Machine) map[string]string {
+	values := map[string]string{}
+	for _, l := range machines {
+		for _, m := range l {
+			if value, ok := m.Annotations[clusterv1.PausedAnnotation]; ok {
+				values[m.Name] = value
+			}
+		}
+	}
+	return values
+}
+
+func TestParseControllers(t *testing.T) {
+	providerComponents := `kind: StatefulSet
+apiVersion: apps/v1
+metadata:
+  name: controller
+  namespace: system
+---
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: manager
+  namespace: system
+spec:
+  replicas: 2
+---
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  name: config
+  namespace: system
+`
+	controllers, err := parseControllers(providerComponents)
+	if err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	expected := []JournalController{
+		{Kind: "StatefulSet", Namespace: "system", Name: "controller", Replicas: 1},
+		{Kind: "Deployment", Namespace: "system", Name: "manager", Replicas: 2},
+	}
+	if !reflect.DeepEqual(controllers, expected) {
+		t.Errorf("expected controllers %v, got %v", expected, controllers)
+	}
+}
+
+func TestPivotPausesSourceObjects(t *testing.T) {
+	store, cleanup := newJournalStore(t)
+	defer cleanup()
+	providerComponents := `kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: manager
+spec:
+  replicas: 2
+`
+	source := newJournalTestSource()
+	source.machines["ns1"][2].Annotations = map[string]string{clusterv1.PausedAnnotation: "true"}
+	target := &flakyTarget{target: newTarget(), failMachine: "machine3", fail: true}
+
+	if err := PivotWithOptions(source, target, providerComponents, PivotOptions{Journal: store}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if source.replicas["manager"] != 0 {
+		t.Errorf("expected the Deployment to be scaled down, got %d replicas", source.replicas["manager"])
+	}
+	expected := map[string]string{"machine3": "true", "machine4": pausedByClusterctl}
+	if got := pausedValues(source.machines); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the machines left in the source cluster to be paused with %v, got %v", expected, got)
+	}
+	for _, c := range source.clusters["ns1"] {
+		if c.Annotations[clusterv1.PausedAnnotation] != pausedByClusterctl {
+			t.Errorf("expected Cluster %q to be paused in the source cluster", c.Name)
+		}
+	}
+	if got := pausedValues(target.machines); len(got) != 0 {
+		t.Errorf("expected the copies not to be paused, got %v", got)
+	}
+	for _, l := range target.clusters {
+		for _, c := range l {
+			if _, ok := c.Annotations[clusterv1.PausedAnnotation]; ok {
+				t.Errorf("expected the copy of Cluster %q not to be paused", c.Name)
+			}
+		}
+	}
+
+	if err := RollbackPivot(source, target, store); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	expected = map[string]string{"machine3": "true"}
+	if got := pausedValues(source.machines); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected only the machines paused by the user to stay paused, got %v", got)
+	}
+	for _, c := range source.clusters["ns1"] {
+		if _, ok := c.Annotations[clusterv1.PausedAnnotation]; ok {
+			t.Errorf("expected Cluster %q to be unpaused in the source cluster", c.Name)
+		}
+	}
+	if source.replicas["manager"] != 2 {
+		t.Errorf("expected the Deployment to be scaled back up, got %d replicas", source.replicas["manager"])
+	}
+}
@@ -17,69 +17,144 @@ limitations under the License.
 package phases
 
 import (
-	"io"
-	"strings"
+	"reflect"
 
 	"github.com/pkg/errors"
-	appsv1 "k8s.io/api/apps/v1"
-	"k8s.io/apimachinery/pkg/util/yaml"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/provider"
 	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 )
 
+// Kinds of the objects recorded in the pivot journal.
+const (
+	kindCluster           = "Cluster"
+	kindConfigMap         = "ConfigMap"
+	kindMachine           = "Machine"
+	kindMachineClass      = "MachineClass"
+	kindMachineDeployment = "MachineDeployment"
+	kindMachineSet        = "MachineSet"
+	kindSecret            = "Secret"
+)
+
 type sourceClient interface {
+	pauseClient
+	scaleClient
 	Delete(string) error
 	DeleteMachineClass(namespace, name string) error
 	ForceDeleteCluster(string, string) error
 	ForceDeleteMachine(string, string) error
 	ForceDeleteMachineDeployment(string, string) error
 	ForceDeleteMachineSet(namespace, name string) error
-	GetClusters(string) ([]*clusterv1.Cluster, error)
+	GetConfigMap(namespace, name string) (*corev1.ConfigMap, error)
+	GetConfigMaps(namespace string) ([]*corev1.ConfigMap, error)
 	GetMachineClasses(string) ([]*clusterv1.MachineClass, error)
-	GetMachineDeployments(string) ([]*clusterv1.MachineDeployment, error)
 	GetMachineDeploymentsForCluster(*clusterv1.Cluster) ([]*clusterv1.MachineDeployment, error)
-	GetMachines(namespace string) ([]*clusterv1.Machine, error)
-	GetMachineSets(namespace string) ([]*clusterv1.MachineSet, error)
 	GetMachineSetsForCluster(*clusterv1.Cluster) ([]*clusterv1.MachineSet, error)
 	GetMachineSetsForMachineDeployment(*clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error)
 	GetMachinesForCluster(*clusterv1.Cluster) ([]*clusterv1.Machine, error)
 	GetMachinesForMachineSet(*clusterv1.MachineSet) ([]*clusterv1.Machine, error)
-	ScaleStatefulSet(string, string, int32) error
+	GetSecret(namespace, name string) (*corev1.Secret, error)
+	GetSecrets(namespace string) ([]*corev1.Secret, error)
 	WaitForClusterV1alpha1Ready() error
 }
 
 type targetClient interface {
 	Apply(string) error
 	CreateClusterObject(*clusterv1.Cluster) error
+	CreateConfigMap(*corev1.ConfigMap) error
 	CreateMachineClass(*clusterv1.MachineClass) error
 	CreateMachineDeployments([]*clusterv1.MachineDeployment, string) error
 	CreateMachines([]*clusterv1.Machine, string) error
 	CreateMachineSets([]*clusterv1.MachineSet, string) error
+	CreateSecret(*corev1.Secret) error
 	EnsureNamespace(string) error
+	GetCluster(string, string) (*clusterv1.Cluster, error)
+	GetConfigMap(namespace, name string) (*corev1.ConfigMap, error)
+	GetMachine(namespace, name string) (*clusterv1.Machine, error)
 	GetMachineDeployment(namespace, name string) (*clusterv1.MachineDeployment, error)
 	GetMachineSet(string, string) (*clusterv1.MachineSet, error)
+	GetSecret(namespace, name string) (*corev1.Secret, error)
+	UpdateClusterObjectStatus(*clusterv1.Cluster) error
+	UpdateConfigMap(*corev1.ConfigMap) error
+	UpdateMachine(*clusterv1.Machine) error
+	UpdateMachineDeploymentStatus(*clusterv1.MachineDeployment) error
+	UpdateMachineSet(*clusterv1.MachineSet) error
+	UpdateMachineSetStatus(*clusterv1.MachineSet) error
+	UpdateMachineStatus(*clusterv1.Machine) error
+	UpdateSecret(*corev1.Secret) error
 	WaitForClusterV1alpha1Ready() error
 }
 
+// uidMap maps the UIDs of the objects in the source cluster to the UIDs of
+// their copies in the target cluster.
+type uidMap map[types.UID]types.UID
+
+// ownerReferences returns the owner references pointing at the copies of the
+// owners in the target cluster. The garbage collector would delete an object
+// whose owner does not exist, so references to owners that have not been
+// copied yet are dropped; they are restored once the owner is copied.
+func (u uidMap) ownerReferences(refs []metav1.OwnerReference) []metav1.OwnerReference {
+	var copied []metav1.OwnerReference
+	for _, ref := range refs {
+		uid, ok := u[ref.UID]
+		if !ok {
+			continue
+		}
+		ref.UID = uid
+		copied = append(copied, ref)
+	}
+	return copied
+}
+
+// PivotOptions configures how the progress of a pivot is recorded.
+type PivotOptions struct {
+	// Journal, if set, records the progress of the pivot so that it can be
+	// resumed or rolled back with RollbackPivot if it fails.
+	Journal JournalStore
+	// Resume continues the pivot recorded in the Journal instead of starting
+	// a new one.
+	Resume bool
+	// Dependents, if set, lists the Secrets and ConfigMaps the objects of the
+	// provider depend on, which are copied along with the labeled ones.
+	Dependents provider.DependentsLister
+}
+
 // Pivot deploys the provided provider components to a target cluster and then migrates
 // all cluster-api resources from the source cluster to the target cluster
 func Pivot(source sourceClient, target targetClient, providerComponents string) error {
+	return PivotWithOptions(source, target, providerComponents, PivotOptions{})
+}
+
+// PivotWithOptions is like Pivot, recording its progress as configured by the options.
+func PivotWithOptions(source sourceClient, target targetClient, providerComponents string, options PivotOptions) error {
+	j, err := newJournal(options.Journal, options.Resume)
+	if err != nil {
+		return err
+	}
+	if j.state.Completed {
+		klog.Info("The pivot recorded in the journal has already completed")
+		return nil
+	}
+
 	klog.Info("Applying Cluster API Provider Components to Target Cluster")
 	if err := target.Apply(providerComponents); err != nil {
 		return errors.Wrap(err, "unable to apply cluster api controllers")
 	}
 
 	klog.Info("Pivoting Cluster API objects from bootstrap to target cluster.")
-	if err := pivot(source, target, providerComponents); err != nil {
+	if err := pivot(source, target, providerComponents, j, options.Dependents); err != nil {
 		return errors.Wrap(err, "unable to pivot cluster API objects")
 	}
 
 	return nil
 }
 
-func pivot(from sourceClient, to targetClient, providerComponents string) error {
-	// TODO: Attempt to handle rollback in case of pivot failure
-
+func pivot(from sourceClient, to targetClient, providerComponents string, j *journal, lister provider.DependentsLister) error {
 	klog.V(4).Info("Ensuring cluster v1alpha1 resources are available on the source cluster")
 	if err := from.WaitForClusterV1alpha1Ready(); err != nil {
 		return errors.New("cluster v1alpha1 resource not ready on source cluster")
@@ -96,21 +171,40 @@ func pivot(from sourceClient, to targetClient, providerComponents string) error
 		return errors.Wrap(err, "Failed to extract Cluster API Controllers from the provider components")
 	}
 
-	// Scale down the controller managers in the source cluster
+	if err := j.recordControllers(controllers); err != nil {
+		return err
+	}
+
+	klog.V(4).Info("Pausing the Cluster API objects of the source cluster")
+	if err := setObjectsPaused(from, true); err != nil {
+		return err
+	}
+
+	// Scale down the controller managers in the source cluster, for the ones which don't honor the paused annotation.
 	for _, controller := range controllers {
 		klog.V(4).Infof("Scaling down controller %s/%s", controller.Namespace, controller.Name)
-		if err := from.ScaleStatefulSet(controller.Namespace, controller.Name, 0); err != nil {
+		if err := scaleController(from, controller, 0); err != nil {
 			return errors.Wrapf(err, "Failed to scale down %s/%s", controller.Namespace, controller.Name)
 		}
 	}
 
+	klog.V(4).Info("Retrieving list of Secrets and ConfigMaps to copy")
+	deps, err := listPivotDependents(from, lister)
+	if err != nil {
+		return err
+	}
+	copied, err := copyDependents(to, deps, j)
+	if err != nil {
+		return err
+	}
+
 	klog.V(4).Info("Retrieving list of MachineClasses to move")
 	machineClasses, err := from.GetMachineClasses("")
 	if err != nil {
 		return err
 	}
 
-	if err := copyMachineClasses(from, to, machineClasses); err != nil {
+	if err := copyMachineClasses(from, to, machineClasses, j); err != nil {
 		return err
 	}
 
@@ -120,7 +214,8 @@ func pivot(from sourceClient, to targetClient, providerComponents string) error
 		return err
 	}
 
-	if err := moveClusters(from, to, clusters); err != nil {
+	uids := uidMap{}
+	if err := moveClusters(from, to, clusters, j, uids); err != nil {
 		return err
 	}
 
@@ -129,7 +224,7 @@ func pivot(from sourceClient, to targetClient, providerComponents string) error
 	if err != nil {
 		return err
 	}
-	if err := moveMachineDeployments(from, to, machineDeployments); err != nil {
+	if err := moveMachineDeployments(from, to, machineDeployments, j, uids); err != nil {
 		return err
 	}
 
@@ -138,7 +233,7 @@ func pivot(from sourceClient, to targetClient, providerComponents string) error
 	if err != nil {
 		return err
 	}
-	if err := moveMachineSets(from, to, machineSets); err != nil {
+	if err := moveMachineSets(from, to, machineSets, j, uids); err != nil {
 		return err
 	}
 
@@ -147,11 +242,15 @@ func pivot(from sourceClient, to targetClient, providerComponents string) error
 	if err != nil {
 		return err
 	}
-	if err := moveMachines(from, to, machines); err != nil {
+	if err := moveMachines(from, to, machines, j, uids); err != nil {
+		return err
+	}
+
+	if err := adoptDependents(to, copied, uids); err != nil {
 		return err
 	}
 
-	if err := deleteMachineClasses(from, machineClasses); err != nil {
+	if err := deleteMachineClasses(from, machineClasses, j); err != nil {
 		return err
 	}
 
@@ -160,10 +259,10 @@ func pivot(from sourceClient, to targetClient, providerComponents string) error
 		klog.Warningf("Could not delete the provider components from the source cluster: %v", err)
 	}
 
-	return nil
+	return j.complete()
 }
 
-func moveClusters(from sourceClient, to targetClient, clusters []*clusterv1.Cluster) error {
+func moveClusters(from sourceClient, to targetClient, clusters []*clusterv1.Cluster, j *journal, uids uidMap) error {
 	clusterNames := make([]string, 0, len(clusters))
 	for _, c := range clusters {
 		clusterNames = append(clusterNames, c.Name)
@@ -171,14 +270,14 @@ func moveClusters(from sourceClient, to targetClient, clusters []*clusterv1.Clus
 	klog.V(4).Infof("Preparing to move Clusters: %v", clusterNames)
 
 	for _, c := range clusters {
-		if err := moveCluster(from, to, c); err != nil {
+		if err := moveCluster(from, to, c, j, uids); err != nil {
 			return errors.Wrapf(err, "Failed to move cluster: %s/%s", c.Namespace, c.Name)
 		}
 	}
 	return nil
 }
 
-func deleteMachineClasses(client sourceClient, machineClasses []*clusterv1.MachineClass) error {
+func deleteMachineClasses(client sourceClient, machineClasses []*clusterv1.MachineClass, j *journal) error {
 	machineClassNames := make([]string, 0, len(machineClasses))
 	for _, mc := range machineClasses {
 		machineClassNames = append(machineClassNames, mc.Name)
@@ -186,17 +285,19 @@ func deleteMachineClasses(client sourceClient, machineClasses []*clusterv1.Machi
 	klog.V(4).Infof("Preparing to delete MachineClasses: %v", machineClassNames)
 
 	for _, mc := range machineClasses {
-		if err := deleteMachineClass(client, mc); err != nil {
+		if err := deleteMachineClass(client, mc, j); err != nil {
 			return errors.Wrapf(err, "failed to delete MachineClass %s:%s", mc.Namespace, mc.Name)
 		}
 	}
 	return nil
 }
 
-func deleteMachineClass(client sourceClient, machineClass *clusterv1.MachineClass) error {
+func deleteMachineClass(client sourceClient, machineClass *clusterv1.MachineClass, j *journal) error {
 	// New objects cannot have a specified resource version. Clear it out.
 	machineClass.SetResourceVersion("")
-	if err := client.DeleteMachineClass(machineClass.Namespace, machineClass.Name); err != nil {
+	if err := j.deleteObject(kindMachineClass, machineClass, func() error {
+		return client.DeleteMachineClass(machineClass.Namespace, machineClass.Name)
+	}); err != nil {
 		return errors.Wrapf(err, "error deleting MachineClass %s/%s from source cluster", machineClass.Namespace, machineClass.Name)
 	}
 
@@ -204,7 +305,7 @@ func deleteMachineClass(client sourceClient, machineClass *clusterv1.MachineClas
 	return nil
 }
 
-func copyMachineClasses(from sourceClient, to targetClient, machineClasses []*clusterv1.MachineClass) error {
+func copyMachineClasses(from sourceClient, to targetClient, machineClasses []*clusterv1.MachineClass, j *journal) error {
 	machineClassNames := make([]string, 0, len(machineClasses))
 	for _, mc := range machineClasses {
 		machineClassNames = append(machineClassNames, mc.Name)
@@ -212,17 +313,19 @@ func copyMachineClasses(from sourceClient, to targetClient, machineClasses []*cl
 	klog.V(4).Infof("Preparing to copy MachineClasses: %v", machineClassNames)
 
 	for _, mc := range machineClasses {
-		if err := copyMachineClass(from, to, mc); err != nil {
+		if err := copyMachineClass(from, to, mc, j); err != nil {
 			return errors.Wrapf(err, "failed to copy MachineClass %s:%s", mc.Namespace, mc.Name)
 		}
 	}
 	return nil
 }
 
-func copyMachineClass(from sourceClient, to targetClient, machineClass *clusterv1.MachineClass) error {
+func copyMachineClass(from sourceClient, to targetClient, machineClass *clusterv1.MachineClass, j *journal) error {
 	// New objects cannot have a specified resource version. Clear it out.
 	machineClass.SetResourceVersion("")
-	if err := to.CreateMachineClass(machineClass); err != nil {
+	if err := j.copyObject(kindMachineClass, machineClass, func() error {
+		return to.CreateMachineClass(machineClass)
+	}); err != nil {
 		return errors.Wrapf(err, "error copying MachineClass %s/%s to target cluster", machineClass.Namespace, machineClass.Name)
 	}
 
@@ -230,7 +333,7 @@ func copyMachineClass(from sourceClient, to targetClient, machineClass *clusterv
 	return nil
 }
 
-func moveCluster(from sourceClient, to targetClient, cluster *clusterv1.Cluster) error {
+func moveCluster(from sourceClient, to targetClient, cluster *clusterv1.Cluster, j *journal, uids uidMap) error {
 	klog.V(4).Infof("Moving Cluster %s/%s", cluster.Namespace, cluster.Name)
 
 	klog.V(4).Infof("Ensuring namespace %q exists on target cluster", cluster.Namespace)
@@ -240,16 +343,26 @@ func moveCluster(from sourceClient, to targetClient, cluster *clusterv1.Cluster)
 
 	// New objects cannot have a specified resource version. Clear it out.
 	cluster.SetResourceVersion("")
-	if err := to.CreateClusterObject(cluster); err != nil {
+
+	// The copy is reconciled by the controllers of the target cluster, unless
+	// it was paused before the pivot.
+	copied := cluster.DeepCopy()
+	updatePaused(copied, false)
+	if err := j.copyObject(kindCluster, cluster, func() error {
+		return to.CreateClusterObject(copied)
+	}); err != nil {
 		return errors.Wrapf(err, "error copying Cluster %s/%s to target cluster", cluster.Namespace, cluster.Name)
 	}
+	if err := copyClusterStatus(to, cluster, uids); err != nil {
+		return errors.Wrapf(err, "error copying status of Cluster %s/%s to target cluster", cluster.Namespace, cluster.Name)
+	}
 
 	klog.V(4).Infof("Retrieving list of MachineDeployments to move for Cluster %s/%s", cluster.Namespace, cluster.Name)
 	machineDeployments, err := from.GetMachineDeploymentsForCluster(cluster)
 	if err != nil {
 		return err
 	}
-	if err := moveMachineDeployments(from, to, machineDeployments); err != nil {
+	if err := moveMachineDeployments(from, to, machineDeployments, j, uids); err != nil {
 		return err
 	}
 
@@ -258,7 +371,7 @@ func moveCluster(from sourceClient, to targetClient, cluster *clusterv1.Cluster)
 	if err != nil {
 		return err
 	}
-	if err := moveMachineSets(from, to, machineSets); err != nil {
+	if err := moveMachineSets(from, to, machineSets, j, uids); err != nil {
 		return err
 	}
 
@@ -267,11 +380,13 @@ func moveCluster(from sourceClient, to targetClient, cluster *clusterv1.Cluster)
 	if err != nil {
 		return err
 	}
-	if err := moveMachines(from, to, machines); err != nil {
+	if err := moveMachines(from, to, machines, j, uids); err != nil {
 		return err
 	}
 
-	if err := from.ForceDeleteCluster(cluster.Namespace, cluster.Name); err != nil {
+	if err := j.deleteObject(kindCluster, cluster, func() error {
+		return from.ForceDeleteCluster(cluster.Namespace, cluster.Name)
+	}); err != nil {
 		return errors.Wrapf(err, "error force deleting cluster %s/%s", cluster.Namespace, cluster.Name)
 	}
 
@@ -279,7 +394,7 @@ func moveCluster(from sourceClient, to targetClient, cluster *clusterv1.Cluster)
 	return nil
 }
 
-func moveMachineDeployments(from sourceClient, to targetClient, machineDeployments []*clusterv1.MachineDeployment) error {
+func moveMachineDeployments(from sourceClient, to targetClient, machineDeployments []*clusterv1.MachineDeployment, j *journal, uids uidMap) error {
 	machineDeploymentNames := make([]string, 0, len(machineDeployments))
 	for _, md := range machineDeployments {
 		machineDeploymentNames = append(machineDeploymentNames, md.Name)
@@ -287,14 +402,14 @@ func moveMachineDeployments(from sourceClient, to targetClient, machineDeploymen
 	klog.V(4).Infof("Preparing to move MachineDeployments: %v", machineDeploymentNames)
 
 	for _, md := range machineDeployments {
-		if err := moveMachineDeployment(from, to, md); err != nil {
+		if err := moveMachineDeployment(from, to, md, j, uids); err != nil {
 			return errors.Wrapf(err, "failed to move MachineDeployment %s:%s", md.Namespace, md.Name)
 		}
 	}
 	return nil
 }
 
-func moveMachineDeployment(from sourceClient, to targetClient, md *clusterv1.MachineDeployment) error {
+func moveMachineDeployment(from sourceClient, to targetClient, md *clusterv1.MachineDeployment, j *journal, uids uidMap) error {
 	klog.V(4).Infof("Moving MachineDeployment %s/%s", md.Namespace, md.Name)
 	klog.V(4).Infof("Retrieving list of MachineSets for MachineDeployment %s/%s", md.Namespace, md.Name)
 	machineSets, err := from.GetMachineSetsForMachineDeployment(md)
@@ -302,28 +417,38 @@ func moveMachineDeployment(from sourceClient, to targetClient, md *clusterv1.Mac
 		return err
 	}
 
-	if err := moveMachineSets(from, to, machineSets); err != nil {
+	if err := moveMachineSets(from, to, machineSets, j, uids); err != nil {
 		return err
 	}
 
 	// New objects cannot have a specified resource version. Clear it out.
 	md.SetResourceVersion("")
 
-	// Remove owner reference. This currently assumes that the only owner reference would be a Cluster.
-	md.SetOwnerReferences(nil)
-
-	if err := to.CreateMachineDeployments([]*clusterv1.MachineDeployment{md}, md.Namespace); err != nil {
+	copied := md.DeepCopy()
+	copied.SetOwnerReferences(uids.ownerReferences(md.OwnerReferences))
+	updatePaused(copied, false)
+	if err := j.copyObject(kindMachineDeployment, md, func() error {
+		return to.CreateMachineDeployments([]*clusterv1.MachineDeployment{copied}, md.Namespace)
+	}); err != nil {
 		return errors.Wrapf(err, "error copying MachineDeployment %s/%s to target cluster", md.Namespace, md.Name)
 	}
+	if err := copyMachineDeploymentStatus(to, md, uids); err != nil {
+		return errors.Wrapf(err, "error copying status of MachineDeployment %s/%s to target cluster", md.Namespace, md.Name)
+	}
+	if err := adoptMachineSets(to, machineSets, uids); err != nil {
+		return err
+	}
 
-	if err := from.ForceDeleteMachineDeployment(md.Namespace, md.Name); err != nil {
+	if err := j.deleteObject(kindMachineDeployment, md, func() error {
+		return from.ForceDeleteMachineDeployment(md.Namespace, md.Name)
+	}); err != nil {
 		return errors.Wrapf(err, "error force deleting MachineDeployment %s/%s from source cluster", md.Namespace, md.Name)
 	}
 	klog.V(4).Infof("Successfully moved MachineDeployment %s/%s", md.Namespace, md.Name)
 	return nil
 }
 
-func moveMachineSets(from sourceClient, to targetClient, machineSets []*clusterv1.MachineSet) error {
+func moveMachineSets(from sourceClient, to targetClient, machineSets []*clusterv1.MachineSet, j *journal, uids uidMap) error {
 	machineSetNames := make([]string, 0, len(machineSets))
 	for _, ms := range machineSets {
 		machineSetNames = append(machineSetNames, ms.Name)
@@ -331,14 +456,14 @@ func moveMachineSets(from sourceClient, to targetClient, machineSets []*clusterv
 	klog.V(4).Infof("Preparing to move MachineSets: %v", machineSetNames)
 
 	for _, ms := range machineSets {
-		if err := moveMachineSet(from, to, ms); err != nil {
+		if err := moveMachineSet(from, to, ms, j, uids); err != nil {
 			return errors.Wrapf(err, "failed to move MachineSet %s:%s", ms.Namespace, ms.Name)
 		}
 	}
 	return nil
 }
 
-func moveMachineSet(from sourceClient, to targetClient, ms *clusterv1.MachineSet) error {
+func moveMachineSet(from sourceClient, to targetClient, ms *clusterv1.MachineSet, j *journal, uids uidMap) error {
 	klog.V(4).Infof("Moving MachineSet %s/%s", ms.Namespace, ms.Name)
 	klog.V(4).Infof("Retrieving list of Machines for MachineSet %s/%s", ms.Namespace, ms.Name)
 	machines, err := from.GetMachinesForMachineSet(ms)
@@ -346,27 +471,39 @@ func moveMachineSet(from sourceClient, to targetClient, ms *clusterv1.MachineSet
 		return err
 	}
 
-	if err := moveMachines(from, to, machines); err != nil {
+	if err := moveMachines(from, to, machines, j, uids); err != nil {
 		return err
 	}
 
 	// New objects cannot have a specified resource version. Clear it out.
 	ms.SetResourceVersion("")
 
-	// Remove owner reference. This currently assumes that the only owner references would be a MachineDeployment and/or a Cluster.
-	ms.SetOwnerReferences(nil)
-
-	if err := to.CreateMachineSets([]*clusterv1.MachineSet{ms}, ms.Namespace); err != nil {
+	// The MachineDeployment owning the MachineSet is copied after it, and
+	// restores the reference in adoptMachineSets.
+	copied := ms.DeepCopy()
+	copied.SetOwnerReferences(uids.ownerReferences(ms.OwnerReferences))
+	updatePaused(copied, false)
+	if err := j.copyObject(kindMachineSet, ms, func() error {
+		return to.CreateMachineSets([]*clusterv1.MachineSet{copied}, ms.Namespace)
+	}); err != nil {
 		return errors.Wrapf(err, "error copying MachineSet %s/%s to target cluster", ms.Namespace, ms.Name)
 	}
-	if err := from.ForceDeleteMachineSet(ms.Namespace, ms.Name); err != nil {
+	if err := copyMachineSetStatus(to, ms, uids); err != nil {
+		return errors.Wrapf(err, "error copying status of MachineSet %s/%s to target cluster", ms.Namespace, ms.Name)
+	}
+	if err := adoptMachines(to, machines, uids); err != nil {
+		return err
+	}
+	if err := j.deleteObject(kindMachineSet, ms, func() error {
+		return from.ForceDeleteMachineSet(ms.Namespace, ms.Name)
+	}); err != nil {
 		return errors.Wrapf(err, "error force deleting MachineSet %s/%s from source cluster", ms.Namespace, ms.Name)
 	}
 	klog.V(4).Infof("Successfully moved MachineSet %s/%s", ms.Namespace, ms.Name)
 	return nil
 }
 
-func moveMachines(from sourceClient, to targetClient, machines []*clusterv1.Machine) error {
+func moveMachines(from sourceClient, to targetClient, machines []*clusterv1.Machine, j *journal, uids uidMap) error {
 	machineNames := make([]string, 0, len(machines))
 	for _, m := range machines {
 		machineNames = append(machineNames, m.Name)
@@ -374,51 +511,180 @@ func moveMachines(from sourceClient, to targetClient, machines []*clusterv1.Mach
 	klog.V(4).Infof("Preparing to move Machines: %v", machineNames)
 
 	for _, m := range machines {
-		if err := moveMachine(from, to, m); err != nil {
+		if err := moveMachine(from, to, m, j, uids); err != nil {
 			return errors.Wrapf(err, "failed to move Machine %s:%s", m.Namespace, m.Name)
 		}
 	}
 	return nil
 }
 
-func moveMachine(from sourceClient, to targetClient, m *clusterv1.Machine) error {
+func moveMachine(from sourceClient, to targetClient, m *clusterv1.Machine, j *journal, uids uidMap) error {
 	klog.V(4).Infof("Moving Machine %s/%s", m.Namespace, m.Name)
 
 	// New objects cannot have a specified resource version. Clear it out.
 	m.SetResourceVersion("")
 
-	// Remove owner reference. This currently assumes that the only owner references would be a MachineSet and/or a Cluster.
-	m.SetOwnerReferences(nil)
-
-	if err := to.CreateMachines([]*clusterv1.Machine{m}, m.Namespace); err != nil {
+	// The MachineSet owning the Machine is copied after it, and restores the
+	// reference in adoptMachines.
+	copied := m.DeepCopy()
+	copied.SetOwnerReferences(uids.ownerReferences(m.OwnerReferences))
+	updatePaused(copied, false)
+	if err := j.copyObject(kindMachine, m, func() error {
+		return to.CreateMachines([]*clusterv1.Machine{copied}, m.Namespace)
+	}); err != nil {
 		return errors.Wrapf(err, "error copying Machine %s/%s to target cluster", m.Namespace, m.Name)
 	}
-	if err := from.ForceDeleteMachine(m.Namespace, m.Name); err != nil {
+	if err := copyMachineStatus(to, m, uids); err != nil {
+		return errors.Wrapf(err, "error copying status of Machine %s/%s to target cluster", m.Namespace, m.Name)
+	}
+	if err := j.deleteObject(kindMachine, m, func() error {
+		return from.ForceDeleteMachine(m.Namespace, m.Name)
+	}); err != nil {
 		return errors.Wrapf(err, "error force deleting Machine %s/%s from source cluster", m.Namespace, m.Name)
 	}
 	klog.V(4).Infof("Successfully moved Machine %s/%s", m.Namespace, m.Name)
 	return nil
 }
 
-func parseControllers(providerComponents string) ([]*appsv1.StatefulSet, error) {
-	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(providerComponents), 32)
+// copyClusterStatus copies the status of the cluster to its copy in the target
+// cluster, which does not keep it on creation, and records the UID of the copy.
+func copyClusterStatus(to targetClient, cluster *clusterv1.Cluster, uids uidMap) error {
+	return retryOnConflict(func() error {
+		copied, err := to.GetCluster(cluster.Name, cluster.Namespace)
+		if err != nil {
+			return err
+		}
+		if copied == nil {
+			return errors.Errorf("Cluster %s/%s not found in target cluster", cluster.Namespace, cluster.Name)
+		}
+		uids[cluster.UID] = copied.UID
+		if reflect.DeepEqual(copied.Status, cluster.Status) {
+			return nil
+		}
+		copied.Status = *cluster.Status.DeepCopy()
+		return to.UpdateClusterObjectStatus(copied)
+	})
+}
 
-	controllers := []*appsv1.StatefulSet{}
+// copyMachineDeploymentStatus copies the status of the MachineDeployment to its
+// copy in the target cluster and records the UID of the copy.
+func copyMachineDeploymentStatus(to targetClient, md *clusterv1.MachineDeployment, uids uidMap) error {
+	return retryOnConflict(func() error {
+		copied, err := to.GetMachineDeployment(md.Namespace, md.Name)
+		if err != nil {
+			return err
+		}
+		if copied == nil {
+			return errors.Errorf("MachineDeployment %s/%s not found in target cluster", md.Namespace, md.Name)
+		}
+		uids[md.UID] = copied.UID
+		if reflect.DeepEqual(copied.Status, md.Status) {
+			return nil
+		}
+		copied.Status = *md.Status.DeepCopy()
+		return to.UpdateMachineDeploymentStatus(copied)
+	})
+}
 
-	for {
-		var out appsv1.StatefulSet
-		err := decoder.Decode(&out)
+// copyMachineSetStatus copies the status of the MachineSet to its copy in the
+// target cluster and records the UID of the copy.
+func copyMachineSetStatus(to targetClient, ms *clusterv1.MachineSet, uids uidMap) error {
+	return retryOnConflict(func() error {
+		copied, err := to.GetMachineSet(ms.Namespace, ms.Name)
+		if err != nil {
+			return err
+		}
+		if copied == nil {
+			return errors.Errorf("MachineSet %s/%s not found in target cluster", ms.Namespace, ms.Name)
+		}
+		uids[ms.UID] = copied.UID
+		if reflect.DeepEqual(copied.Status, ms.Status) {
+			return nil
+		}
+		copied.Status = *ms.Status.DeepCopy()
+		return to.UpdateMachineSetStatus(copied)
+	})
+}
 
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return nil, err
+// copyMachineStatus copies the status of the Machine to its copy in the target
+// cluster and records the UID of the copy.
+func copyMachineStatus(to targetClient, m *clusterv1.Machine, uids uidMap) error {
+	return retryOnConflict(func() error {
+		copied, err := to.GetMachine(m.Namespace, m.Name)
+		if err != nil {
+			return err
+		}
+		if copied == nil {
+			return errors.Errorf("Machine %s/%s not found in target cluster", m.Namespace, m.Name)
+		}
+		uids[m.UID] = copied.UID
+		if reflect.DeepEqual(copied.Status, m.Status) {
+			return nil
 		}
+		copied.Status = *m.Status.DeepCopy()
+		return to.UpdateMachineStatus(copied)
+	})
+}
 
-		if out.TypeMeta.Kind == "StatefulSet" {
-			controllers = append(controllers, &out)
+// adoptMachineSets restores the owner references of the copies of the
+// MachineSets in the target cluster once their owners have been copied.
+func adoptMachineSets(to targetClient, machineSets []*clusterv1.MachineSet, uids uidMap) error {
+	for _, ms := range machineSets {
+		refs := uids.ownerReferences(ms.OwnerReferences)
+		if err := retryOnConflict(func() error {
+			copied, err := to.GetMachineSet(ms.Namespace, ms.Name)
+			if err != nil {
+				return err
+			}
+			if copied == nil {
+				return errors.Errorf("MachineSet %s/%s not found in target cluster", ms.Namespace, ms.Name)
+			}
+			if reflect.DeepEqual(copied.OwnerReferences, refs) {
+				return nil
+			}
+			copied.SetOwnerReferences(refs)
+			return to.UpdateMachineSet(copied)
+		}); err != nil {
+			return errors.Wrapf(err, "error restoring owner references of MachineSet %s/%s in target cluster", ms.Namespace, ms.Name)
 		}
 	}
+	return nil
+}
 
-	return controllers, nil
+// adoptMachines restores the owner references of the copies of the Machines in
+// the target cluster once their owners have been copied.
+func adoptMachines(to targetClient, machines []*clusterv1.Machine, uids uidMap) error {
+	for _, m := range machines {
+		refs := uids.ownerReferences(m.OwnerReferences)
+		if err := retryOnConflict(func() error {
+			copied, err := to.GetMachine(m.Namespace, m.Name)
+			if err != nil {
+				return err
+			}
+			if copied == nil {
+				return errors.Errorf("Machine %s/%s not found in target cluster", m.Namespace, m.Name)
+			}
+			if reflect.DeepEqual(copied.OwnerReferences, refs) {
+				return nil
+			}
+			copied.SetOwnerReferences(refs)
+			return to.UpdateMachine(copied)
+		}); err != nil {
+			return errors.Wrapf(err, "error restoring owner references of Machine %s/%s in target cluster", m.Namespace, m.Name)
+		}
+	}
+	return nil
+}
+
+// retryOnConflict calls fn again when the object it updates was modified
+// concurrently, e.g. by the controllers already running in the target cluster.
+func retryOnConflict(fn func() error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := fn()
+		if apierrors.IsConflict(errors.Cause(err)) {
+			// RetryOnConflict does not look through wrapped errors.
+			return errors.Cause(err)
+		}
+		return err
+	})
 }
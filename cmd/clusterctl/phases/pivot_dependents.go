@@ -0,0 +1,288 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/cmd/clusterctl/clusterdeployer/provider"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// dependents are the Secrets and ConfigMaps copied along with the Cluster API
+// objects.
+type dependents struct {
+	secrets    []*corev1.Secret
+	configMaps []*corev1.ConfigMap
+	seen       map[string]bool
+}
+
+func (d *dependents) addSecret(secret *corev1.Secret) {
+	if key := kindSecret + "/" + secret.Namespace + "/" + secret.Name; !d.seen[key] {
+		d.seen[key] = true
+		d.secrets = append(d.secrets, secret)
+	}
+}
+
+func (d *dependents) addConfigMap(configMap *corev1.ConfigMap) {
+	if key := kindConfigMap + "/" + configMap.Namespace + "/" + configMap.Name; !d.seen[key] {
+		d.seen[key] = true
+		d.configMaps = append(d.configMaps, configMap)
+	}
+}
+
+// listPivotDependents returns the Secrets and ConfigMaps the Cluster API
+// objects of the source cluster depend on.
+func listPivotDependents(from sourceClient, lister provider.DependentsLister) (*dependents, error) {
+	var objects []metav1.Object
+	clusters, err := from.GetClusters("")
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		objects = append(objects, cluster)
+	}
+	machineClasses, err := from.GetMachineClasses("")
+	if err != nil {
+		return nil, err
+	}
+	for _, machineClass := range machineClasses {
+		objects = append(objects, machineClass)
+	}
+	machineDeployments, err := from.GetMachineDeployments("")
+	if err != nil {
+		return nil, err
+	}
+	for _, md := range machineDeployments {
+		objects = append(objects, md)
+	}
+	machineSets, err := from.GetMachineSets("")
+	if err != nil {
+		return nil, err
+	}
+	for _, ms := range machineSets {
+		objects = append(objects, ms)
+	}
+	machines, err := from.GetMachines("")
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range machines {
+		objects = append(objects, m)
+	}
+	return listDependents(from, objects, lister)
+}
+
+// listDependents returns the Secrets and ConfigMaps in the namespaces of the
+// objects which are labeled with the name of one of the Clusters or owned by
+// one of the objects, followed by the ones listed by the provider, if any.
+func listDependents(from sourceClient, objects []metav1.Object, lister provider.DependentsLister) (*dependents, error) {
+	namespaces := map[string]bool{}
+	clusters := map[string]bool{}
+	owners := map[types.UID]bool{}
+	for _, obj := range objects {
+		namespaces[obj.GetNamespace()] = true
+		if _, ok := obj.(*clusterv1.Cluster); ok {
+			clusters[obj.GetNamespace()+"/"+obj.GetName()] = true
+		}
+		if obj.GetUID() != "" {
+			owners[obj.GetUID()] = true
+		}
+	}
+	selected := func(obj metav1.Object) bool {
+		if name := obj.GetLabels()[clusterv1.MachineClusterLabelName]; name != "" && clusters[obj.GetNamespace()+"/"+name] {
+			return true
+		}
+		for _, ref := range obj.GetOwnerReferences() {
+			if owners[ref.UID] {
+				return true
+			}
+		}
+		return false
+	}
+
+	d := &dependents{seen: map[string]bool{}}
+	for _, namespace := range sortedKeys(namespaces) {
+		secrets, err := from.GetSecrets(namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets {
+			if selected(secret) {
+				d.addSecret(secret)
+			}
+		}
+		configMaps, err := from.GetConfigMaps(namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, configMap := range configMaps {
+			if selected(configMap) {
+				d.addConfigMap(configMap)
+			}
+		}
+	}
+
+	if lister == nil {
+		return d, nil
+	}
+	for _, obj := range objects {
+		refs, err := lister.ListDependents(obj.(runtime.Object))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error listing dependents of %s/%s", obj.GetNamespace(), obj.GetName())
+		}
+		for _, ref := range refs {
+			namespace := ref.Namespace
+			if namespace == "" {
+				namespace = obj.GetNamespace()
+			}
+			switch ref.Kind {
+			case kindSecret:
+				secret, err := from.GetSecret(namespace, ref.Name)
+				if err != nil {
+					return nil, errors.Wrapf(err, "error getting Secret %s/%s referenced by %s/%s", namespace, ref.Name, obj.GetNamespace(), obj.GetName())
+				}
+				d.addSecret(secret)
+			case kindConfigMap:
+				configMap, err := from.GetConfigMap(namespace, ref.Name)
+				if err != nil {
+					return nil, errors.Wrapf(err, "error getting ConfigMap %s/%s referenced by %s/%s", namespace, ref.Name, obj.GetNamespace(), obj.GetName())
+				}
+				d.addConfigMap(configMap)
+			default:
+				return nil, errors.Errorf("unsupported kind %q of dependent %s/%s of %s/%s", ref.Kind, namespace, ref.Name, obj.GetNamespace(), obj.GetName())
+			}
+		}
+	}
+	return d, nil
+}
+
+// copyDependents copies the Secrets and ConfigMaps to the target cluster,
+// where the objects depending on them expect to find them, and returns the
+// ones which did not exist there yet. They are left in the source cluster.
+// Only their metadata is recorded in the journal, keeping the data of the
+// Secrets out of it.
+func copyDependents(to targetClient, d *dependents, j *journal) (*dependents, error) {
+	copied := &dependents{seen: map[string]bool{}}
+	for _, secret := range d.secrets {
+		s := secret.DeepCopy()
+		s.SetOwnerReferences(nil)
+		s.SetResourceVersion("")
+		ok, err := copyDependent(to, kindSecret, &s.ObjectMeta, j, func() error {
+			_, err := to.GetSecret(s.Namespace, s.Name)
+			return err
+		}, func() error {
+			return to.CreateSecret(s)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error copying Secret %s/%s to target cluster", secret.Namespace, secret.Name)
+		}
+		if ok {
+			copied.addSecret(secret)
+		}
+	}
+	for _, configMap := range d.configMaps {
+		c := configMap.DeepCopy()
+		c.SetOwnerReferences(nil)
+		c.SetResourceVersion("")
+		ok, err := copyDependent(to, kindConfigMap, &c.ObjectMeta, j, func() error {
+			_, err := to.GetConfigMap(c.Namespace, c.Name)
+			return err
+		}, func() error {
+			return to.CreateConfigMap(c)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "error copying ConfigMap %s/%s to target cluster", configMap.Namespace, configMap.Name)
+		}
+		if ok {
+			copied.addConfigMap(configMap)
+		}
+	}
+	return copied, nil
+}
+
+// copyDependent creates the object in the target cluster and reports whether
+// it was copied, leaving alone the ones which already existed there before the
+// pivot.
+func copyDependent(to targetClient, kind string, meta *metav1.ObjectMeta, j *journal, get, create func() error) (bool, error) {
+	if j.find(kind, meta.Namespace, meta.Name) == nil {
+		err := get()
+		if err == nil {
+			klog.V(4).Infof("%s %s/%s already exists in target cluster, not copying it", kind, meta.Namespace, meta.Name)
+			return false, nil
+		}
+		if !apierrors.IsNotFound(errors.Cause(err)) {
+			return false, err
+		}
+	}
+	if err := to.EnsureNamespace(meta.Namespace); err != nil {
+		return false, errors.Wrapf(err, "unable to ensure namespace %q in target cluster", meta.Namespace)
+	}
+	return true, j.copyObject(kind, meta, create)
+}
+
+// adoptDependents restores the owner references of the copies of the Secrets
+// and ConfigMaps in the target cluster once their owners have been copied.
+func adoptDependents(to targetClient, d *dependents, uids uidMap) error {
+	if err := adoptSecrets(to, d.secrets, uids); err != nil {
+		return err
+	}
+	for _, configMap := range d.configMaps {
+		refs := uids.ownerReferences(configMap.OwnerReferences)
+		if len(refs) == 0 {
+			continue
+		}
+		if err := retryOnConflict(func() error {
+			copied, err := to.GetConfigMap(configMap.Namespace, configMap.Name)
+			if err != nil {
+				return err
+			}
+			copied.SetOwnerReferences(refs)
+			return to.UpdateConfigMap(copied)
+		}); err != nil {
+			return errors.Wrapf(err, "error restoring owner references of ConfigMap %s/%s in target cluster", configMap.Namespace, configMap.Name)
+		}
+	}
+	return nil
+}
+
+// adoptSecrets restores the owner references of the copies of the Secrets in
+// the target cluster once their owners have been copied.
+func adoptSecrets(to targetClient, secrets []*corev1.Secret, uids uidMap) error {
+	for _, secret := range secrets {
+		refs := uids.ownerReferences(secret.OwnerReferences)
+		if len(refs) == 0 {
+			continue
+		}
+		if err := retryOnConflict(func() error {
+			copied, err := to.GetSecret(secret.Namespace, secret.Name)
+			if err != nil {
+				return err
+			}
+			copied.SetOwnerReferences(refs)
+			return to.UpdateSecret(copied)
+		}); err != nil {
+			return errors.Wrapf(err, "error restoring owner references of Secret %s/%s in target cluster", secret.Namespace, secret.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// WithConfigMap adds a ConfigMap, labeled with the name of the cluster if it
+// is not empty.
+func (s *sourcer) WithConfigMap(ns, cluster, name string) *sourcer {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			UID:       sourceUID("ConfigMap", ns, name),
+		},
+		Data: map[string]string{"config": name},
+	}
+	if cluster != "" {
+		configMap.Labels = map[string]string{clusterv1.MachineClusterLabelName: cluster}
+	}
+	s.configMaps[ns] = append(s.configMaps[ns], configMap)
+	return s
+}
+
+func (s *sourcer) GetConfigMap(ns, name string) (*corev1.ConfigMap, error) {
+	for _, configMap := range s.configMaps[ns] {
+		if configMap.Name == name {
+			return configMap, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+}
+func (s *sourcer) GetConfigMaps(ns string) ([]*corev1.ConfigMap, error) {
+	return s.configMaps[ns], nil
+}
+func (s *sourcer) DeleteConfigMap(ns, name string) error {
+	newConfigMaps := []*corev1.ConfigMap{}
+	for _, configMap := range s.configMaps[ns] {
+		if configMap.Name != name {
+			newConfigMaps = append(newConfigMaps, configMap)
+		}
+	}
+	s.configMaps[ns] = newConfigMaps
+	return nil
+}
+
+func (t *target) CreateConfigMap(configMap *corev1.ConfigMap) error {
+	if _, err := t.GetConfigMap(configMap.Namespace, configMap.Name); err == nil {
+		return apierrors.NewAlreadyExists(schema.GroupResource{Resource: "configmaps"}, configMap.Name)
+	}
+	t.configMaps[configMap.Namespace] = append(t.configMaps[configMap.Namespace], &corev1.ConfigMap{ObjectMeta: t.created(configMap.ObjectMeta), Data: configMap.Data})
+	return nil
+}
+func (t *target) DeleteConfigMap(ns, name string) error {
+	for i, configMap := range t.configMaps[ns] {
+		if configMap.Name == name {
+			t.configMaps[ns] = append(t.configMaps[ns][:i], t.configMaps[ns][i+1:]...)
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+}
+func (t *target) DeleteSecret(ns, name string) error {
+	for i, secret := range t.secrets[ns] {
+		if secret.Name == name {
+			t.secrets[ns] = append(t.secrets[ns][:i], t.secrets[ns][i+1:]...)
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+}
+func (t *target) GetConfigMap(ns, name string) (*corev1.ConfigMap, error) {
+	for _, configMap := range t.configMaps[ns] {
+		if configMap.Name == name {
+			return configMap.DeepCopy(), nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+}
+func (t *target) UpdateConfigMap(configMap *corev1.ConfigMap) error {
+	for _, existing := range t.configMaps[configMap.Namespace] {
+		if existing.Name == configMap.Name {
+			existing.ObjectMeta = configMap.ObjectMeta
+			existing.Data = configMap.Data
+			return nil
+		}
+	}
+	return apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, configMap.Name)
+}
+
+// dependentsLister lists the dependents of the Clusters.
+type dependentsLister map[string][]corev1.ObjectReference
+
+func (l dependentsLister) ListDependents(obj runtime.Object) ([]corev1.ObjectReference, error) {
+	if cluster, ok := obj.(*clusterv1.Cluster); ok {
+		return l[cluster.Name], nil
+	}
+	return nil, nil
+}
+
+func targetNames(t *target) string {
+	var names []string
+	for _, ns := range []string{"ns1", "shared"} {
+		for _, secret := range t.secrets[ns] {
+			names = append(names, "Secret/"+ns+"/"+secret.Name)
+		}
+		for _, configMap := range t.configMaps[ns] {
+			names = append(names, "ConfigMap/"+ns+"/"+configMap.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPivotCopiesDependents(t *testing.T) {
+	store, cleanup := newJournalStore(t)
+	defer cleanup()
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newSourcer().
+		WithCluster("ns1", "cluster1").
+		WithMachine("ns1", "cluster1", "", "machine1").
+		WithSecret("ns1", "cluster1", "", "cluster1-kubeconfig").
+		WithSecret("ns1", "", "machine1", "machine1-bootstrap").
+		WithSecret("ns1", "", "", "credentials").
+		WithSecret("ns1", "", "", "unrelated").
+		WithConfigMap("ns1", "cluster1", "cluster1-config").
+		WithConfigMap("ns1", "other", "other-config").
+		WithConfigMap("shared", "", "provider-config")
+	source.secrets["ns1"][0].Data = map[string][]byte{"value": []byte("secret-kubeconfig")}
+	lister := dependentsLister{"cluster1": {
+		{Kind: "Secret", Name: "credentials"},
+		{Kind: "ConfigMap", Namespace: "shared", Name: "provider-config"},
+	}}
+	target := newTarget()
+
+	if err := PivotWithOptions(source, target, pc.String(), PivotOptions{Journal: store, Dependents: lister}); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+
+	expected := "Secret/ns1/cluster1-kubeconfig,Secret/ns1/machine1-bootstrap,Secret/ns1/credentials,ConfigMap/ns1/cluster1-config,ConfigMap/shared/provider-config"
+	if got := targetNames(target); got != expected {
+		t.Errorf("expected %q in the target cluster, got %q", expected, got)
+	}
+	if len(source.secrets["ns1"]) != 4 || len(source.configMaps["ns1"]) != 2 || len(source.configMaps["shared"]) != 1 {
+		t.Errorf("expected the Secrets and ConfigMaps to be left in the source cluster")
+	}
+	if string(target.secrets["ns1"][0].Data["value"]) != "secret-kubeconfig" {
+		t.Errorf("expected the data of the Secret to be copied, got %v", target.secrets["ns1"][0].Data)
+	}
+	bootstrap := target.secrets["ns1"][1]
+	if len(bootstrap.OwnerReferences) != 1 || bootstrap.OwnerReferences[0].UID != target.machines["ns1"][0].UID {
+		t.Errorf("expected the Secret to be owned by %q in the target cluster, got %v", target.machines["ns1"][0].UID, bootstrap.OwnerReferences)
+	}
+
+	journal, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, o := range journal.Objects {
+		if i < 5 && o.Kind != kindSecret && o.Kind != kindConfigMap {
+			t.Errorf("expected the Secrets and ConfigMaps to be copied first, got %s %s/%s at %d", o.Kind, o.Namespace, o.Name, i)
+		}
+		if strings.Contains(string(o.Object), "c2VjcmV0LWt1YmVjb25maWc") {
+			t.Errorf("expected the data of the Secret to be kept out of the journal, got %s", o.Object)
+		}
+	}
+}
+
+func TestPivotLeavesExistingDependents(t *testing.T) {
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newSourcer().
+		WithCluster("ns1", "cluster1").
+		WithConfigMap("ns1", "cluster1", "cluster1-config")
+	target := newTarget()
+	target.configMaps["ns1"] = []*corev1.ConfigMap{{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1-config", Namespace: "ns1"},
+		Data:       map[string]string{"config": "existing"},
+	}}
+
+	if err := Pivot(source, target, pc.String()); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	if got := target.configMaps["ns1"]; len(got) != 1 || got[0].Data["config"] != "existing" {
+		t.Errorf("expected the existing ConfigMap to be left alone, got %v", got)
+	}
+}
+
+func TestPivotMissingDependent(t *testing.T) {
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newSourcer().WithCluster("ns1", "cluster1")
+	lister := dependentsLister{"cluster1": {{Kind: "Secret", Name: "missing"}}}
+
+	if err := PivotWithOptions(source, newTarget(), pc.String(), PivotOptions{Dependents: lister}); err == nil {
+		t.Error("expected an error but got nil")
+	}
+}
+
+func TestPivotRollbackDependents(t *testing.T) {
+	store, cleanup := newJournalStore(t)
+	defer cleanup()
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newJournalTestSource().
+		WithSecret("ns1", "cluster1", "", "cluster1-kubeconfig").
+		WithConfigMap("ns1", "cluster1", "cluster1-config")
+	target := &flakyTarget{target: newTarget(), failMachine: "machine3", fail: true}
+
+	if err := PivotWithOptions(source, target, pc.String(), PivotOptions{Journal: store}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if got := targetNames(target.target); got != "Secret/ns1/cluster1-kubeconfig,ConfigMap/ns1/cluster1-config" {
+		t.Fatalf("expected the Secret and ConfigMap to be copied before the failure, got %q", got)
+	}
+
+	if err := RollbackPivot(source, target, store); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	if got := targetNames(target.target); got != "" {
+		t.Errorf("expected the Secret and ConfigMap to be deleted from the target cluster, got %q", got)
+	}
+	if len(source.secrets["ns1"]) != 1 || len(source.configMaps["ns1"]) != 1 {
+		t.Errorf("expected the Secret and ConfigMap to be left in the source cluster")
+	}
+}
+
+func TestPivotRollbackOwnedDependents(t *testing.T) {
+	store, cleanup := newJournalStore(t)
+	defer cleanup()
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newJournalTestSource().
+		WithSecret("ns1", "", "machine1", "machine1-bootstrap")
+	source.secrets["ns1"][0].Data = map[string][]byte{"value": []byte("bootstrap")}
+	target := &flakyTarget{target: newTarget(), failMachine: "machine3", fail: true}
+
+	if err := PivotWithOptions(source, target, pc.String(), PivotOptions{Journal: store}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if _, err := source.GetMachine("ns1", "machine1"); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected machine1 to be moved before the failure, got %v", err)
+	}
+	// The Secret is garbage collected along with its owner in the source cluster.
+	if err := source.DeleteSecret("ns1", "machine1-bootstrap"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RollbackPivot(source, target, store); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	if got := targetNames(target.target); got != "" {
+		t.Errorf("expected the Secret to be deleted from the target cluster, got %q", got)
+	}
+	secret, err := source.GetSecret("ns1", "machine1-bootstrap")
+	if err != nil {
+		t.Fatalf("expected the Secret to be restored in the source cluster, got %v", err)
+	}
+	if string(secret.Data["value"]) != "bootstrap" {
+		t.Errorf("expected the data of the Secret to be restored, got %v", secret.Data)
+	}
+	if secret.UID != "" || secret.ResourceVersion != "" || len(secret.OwnerReferences) != 0 {
+		t.Errorf("expected the Secret to be restored without the UID and owner references of its copy, got %v", secret.ObjectMeta)
+	}
+}
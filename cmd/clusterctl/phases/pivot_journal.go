@@ -0,0 +1,252 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// PivotJournal records the progress of a pivot, so that a pivot which failed
+// halfway can be resumed or rolled back.
+type PivotJournal struct {
+	// Controllers are the controllers scaled down in the source cluster.
+	Controllers []JournalController `json:"controllers,omitempty"`
+	// Objects are the objects moved to the target cluster, in the order in
+	// which they were copied.
+	Objects []JournalObject `json:"objects,omitempty"`
+	// Completed is set once the pivot has finished.
+	Completed bool `json:"completed,omitempty"`
+}
+
+// JournalController is a controller scaled down by a pivot.
+type JournalController struct {
+	// Kind is StatefulSet or Deployment. Journals written before Deployments
+	// were scaled down leave it empty for StatefulSets.
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Replicas is the number of replicas restored on rollback.
+	Replicas int32 `json:"replicas"`
+}
+
+// JournalObjectState is the progress of the move of an object.
+type JournalObjectState string
+
+const (
+	// ObjectCopying is recorded before creating an object in the target cluster.
+	ObjectCopying JournalObjectState = "Copying"
+	// ObjectCopied is recorded once an object exists in the target cluster.
+	ObjectCopied JournalObjectState = "Copied"
+	// ObjectMoved is recorded once an object has been deleted from the source cluster.
+	ObjectMoved JournalObjectState = "Moved"
+	// ObjectRolledBack is recorded once an object has been restored in the
+	// source cluster and deleted from the target cluster.
+	ObjectRolledBack JournalObjectState = "RolledBack"
+)
+
+// JournalObject is an object moved by a pivot.
+type JournalObject struct {
+	Kind      string             `json:"kind"`
+	Namespace string             `json:"namespace"`
+	Name      string             `json:"name"`
+	State     JournalObjectState `json:"state"`
+	// Object is the object as created in the target cluster, which is
+	// restored in the source cluster on rollback.
+	Object json.RawMessage `json:"object"`
+}
+
+// JournalStore persists a PivotJournal.
+type JournalStore interface {
+	// Load returns the saved journal, or nil if there is none.
+	Load() (*PivotJournal, error)
+	// Save replaces the saved journal.
+	Save(*PivotJournal) error
+	// Remove deletes the saved journal.
+	Remove() error
+}
+
+// NewFileJournalStore returns a JournalStore saving the journal as JSON to the
+// file at the given path.
+func NewFileJournalStore(path string) JournalStore {
+	return &fileJournalStore{path: path}
+}
+
+type fileJournalStore struct {
+	path string
+}
+
+func (s *fileJournalStore) Load() (*PivotJournal, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read pivot journal %q", s.path)
+	}
+	j := &PivotJournal{}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse pivot journal %q", s.path)
+	}
+	return j, nil
+}
+
+func (s *fileJournalStore) Save(j *PivotJournal) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize pivot journal")
+	}
+	// Write to a temporary file first, so that the journal isn't corrupted if clusterctl is
+	// interrupted while writing it.
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrapf(err, "unable to write pivot journal %q", tmp)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return errors.Wrapf(err, "unable to write pivot journal %q", s.path)
+	}
+	return nil
+}
+
+func (s *fileJournalStore) Remove() error {
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to remove pivot journal %q", s.path)
+	}
+	return nil
+}
+
+// journal records the progress of a pivot, saving it to the store, if any,
+// after each step.
+type journal struct {
+	store JournalStore
+	state *PivotJournal
+	// resumed is set when continuing a pivot recorded by a previous run.
+	resumed bool
+}
+
+// newJournal returns the journal of a new pivot or, if resume is set, of the
+// pivot saved in the store.
+func newJournal(store JournalStore, resume bool) (*journal, error) {
+	if store == nil {
+		if resume {
+			return nil, errors.New("a pivot journal is required to resume a pivot")
+		}
+		return &journal{state: &PivotJournal{}}, nil
+	}
+
+	state, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if resume {
+		if state == nil {
+			return nil, errors.New("no pivot to resume was found in the pivot journal")
+		}
+		return &journal{store: store, state: state, resumed: true}, nil
+	}
+	if state != nil && !state.Completed {
+		return nil, errors.New("the pivot journal records an unfinished pivot, resume or roll it back first")
+	}
+	j := &journal{store: store, state: &PivotJournal{}}
+	return j, j.save()
+}
+
+func (j *journal) save() error {
+	if j.store == nil {
+		return nil
+	}
+	return j.store.Save(j.state)
+}
+
+// recordControllers records the controllers scaled down in the source cluster,
+// unless a previous run did.
+func (j *journal) recordControllers(controllers []JournalController) error {
+	if len(j.state.Controllers) > 0 {
+		return nil
+	}
+	j.state.Controllers = controllers
+	return j.save()
+}
+
+func (j *journal) complete() error {
+	j.state.Completed = true
+	return j.save()
+}
+
+func (j *journal) find(kind, namespace, name string) *JournalObject {
+	for i := range j.state.Objects {
+		o := &j.state.Objects[i]
+		if o.Kind == kind && o.Namespace == namespace && o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// copyObject calls create to create the object in the target cluster, unless
+// the journal records that it was already copied.
+func (j *journal) copyObject(kind string, obj metav1.Object, create func() error) error {
+	entry := j.find(kind, obj.GetNamespace(), obj.GetName())
+	if entry != nil && entry.State != ObjectCopying {
+		klog.V(4).Infof("%s %s/%s was already copied to the target cluster", kind, obj.GetNamespace(), obj.GetName())
+		return nil
+	}
+
+	if entry == nil {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return errors.Wrapf(err, "unable to serialize %s %s/%s", kind, obj.GetNamespace(), obj.GetName())
+		}
+		j.state.Objects = append(j.state.Objects, JournalObject{
+			Kind:      kind,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			State:     ObjectCopying,
+			Object:    raw,
+		})
+		if err := j.save(); err != nil {
+			return err
+		}
+		entry = &j.state.Objects[len(j.state.Objects)-1]
+	}
+
+	// The previous run may have been interrupted after creating the object.
+	if err := create(); err != nil && !(entry.State == ObjectCopying && j.resumed && apierrors.IsAlreadyExists(errors.Cause(err))) {
+		return err
+	}
+	entry.State = ObjectCopied
+	return j.save()
+}
+
+// deleteObject calls del to delete the object from the source cluster and
+// records it as moved.
+func (j *journal) deleteObject(kind string, obj metav1.Object, del func() error) error {
+	// The previous run may have been interrupted after deleting the object.
+	if err := del(); err != nil && !(j.resumed && apierrors.IsNotFound(errors.Cause(err))) {
+		return err
+	}
+	if entry := j.find(kind, obj.GetNamespace(), obj.GetName()); entry != nil {
+		entry.State = ObjectMoved
+	}
+	return j.save()
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// The methods below are used to roll back the pivots from the sourcer to the target. Like the API
+// server, they fail to create objects which already exist.
+
+func alreadyExists(name string) error {
+	return errors.Wrap(apierrors.NewAlreadyExists(schema.GroupResource{}, name), "error creating object")
+}
+
+func (s *sourcer) CreateClusterObject(c *clusterv1.Cluster) error {
+	for _, existing := range s.clusters[c.Namespace] {
+		if existing.Name == c.Name {
+			return alreadyExists(c.Name)
+		}
+	}
+	s.clusters[c.Namespace] = append(s.clusters[c.Namespace], c)
+	return nil
+}
+func (s *sourcer) CreateConfigMap(configMap *corev1.ConfigMap) error {
+	if _, err := s.GetConfigMap(configMap.Namespace, configMap.Name); err == nil {
+		return alreadyExists(configMap.Name)
+	}
+	s.configMaps[configMap.Namespace] = append(s.configMaps[configMap.Namespace], configMap)
+	return nil
+}
+func (s *sourcer) CreateMachineClass(mc *clusterv1.MachineClass) error {
+	for _, existing := range s.machineClasses[mc.Namespace] {
+		if existing.Name == mc.Name {
+			return alreadyExists(mc.Name)
+		}
+	}
+	s.machineClasses[mc.Namespace] = append(s.machineClasses[mc.Namespace], mc)
+	return nil
+}
+func (s *sourcer) CreateMachineDeployments(deployments []*clusterv1.MachineDeployment, ns string) error {
+	for _, md := range deployments {
+		for _, existing := range s.machineDeployments[ns] {
+			if existing.Name == md.Name {
+				return alreadyExists(md.Name)
+			}
+		}
+		s.machineDeployments[ns] = append(s.machineDeployments[ns], md)
+	}
+	return nil
+}
+func (s *sourcer) CreateMachines(machines []*clusterv1.Machine, ns string) error {
+	for _, m := range machines {
+		for _, existing := range s.machines[ns] {
+			if existing.Name == m.Name {
+				return alreadyExists(m.Name)
+			}
+		}
+		s.machines[ns] = append(s.machines[ns], m)
+	}
+	return nil
+}
+func (s *sourcer) CreateMachineSets(machineSets []*clusterv1.MachineSet, ns string) error {
+	for _, ms := range machineSets {
+		for _, existing := range s.machineSets[ns] {
+			if existing.Name == ms.Name {
+				return alreadyExists(ms.Name)
+			}
+		}
+		s.machineSets[ns] = append(s.machineSets[ns], ms)
+	}
+	return nil
+}
+func (s *sourcer) CreateSecret(secret *corev1.Secret) error {
+	if _, err := s.GetSecret(secret.Namespace, secret.Name); err == nil {
+		return alreadyExists(secret.Name)
+	}
+	s.secrets[secret.Namespace] = append(s.secrets[secret.Namespace], secret)
+	return nil
+}
+func (s *sourcer) EnsureNamespace(string) error {
+	return nil
+}
+
+func (t *target) DeleteMachineClass(ns, name string) error {
+	return (&sourcer{machineClasses: t.machineClasses}).DeleteMachineClass(ns, name)
+}
+func (t *target) ForceDeleteCluster(ns, name string) error {
+	return (&sourcer{clusters: t.clusters}).ForceDeleteCluster(ns, name)
+}
+func (t *target) ForceDeleteMachine(ns, name string) error {
+	return (&sourcer{machines: t.machines}).ForceDeleteMachine(ns, name)
+}
+func (t *target) ForceDeleteMachineDeployment(ns, name string) error {
+	return (&sourcer{machineDeployments: t.machineDeployments}).ForceDeleteMachineDeployment(ns, name)
+}
+func (t *target) ForceDeleteMachineSet(ns, name string) error {
+	return (&sourcer{machineSets: t.machineSets}).ForceDeleteMachineSet(ns, name)
+}
+func (t *target) ScaleDeployment(string, string, int32) error {
+	return nil
+}
+func (t *target) ScaleStatefulSet(string, string, int32) error {
+	return nil
+}
+
+// flakyTarget fails to create the machine named failMachine while fail is set.
+type flakyTarget struct {
+	*target
+	failMachine string
+	fail        bool
+}
+
+func (t *flakyTarget) CreateMachines(machines []*clusterv1.Machine, ns string) error {
+	for _, m := range machines {
+		if t.fail && m.Name == t.failMachine {
+			return errors.New("connection reset by peer")
+		}
+	}
+	return t.target.CreateMachines(machines, ns)
+}
+
+func newJournalTestSource() *sourcer {
+	return newSourcer().
+		WithCluster("ns1", "cluster1").
+		WithMachineDeployment("ns1", "cluster1", "deployment1").
+		WithMachineSet("ns1", "cluster1", "deployment1", "machineset1").
+		WithMachine("ns1", "cluster1", "machineset1", "machine1").
+		WithMachineSet("ns1", "cluster1", "", "machineset2").
+		WithMachine("ns1", "cluster1", "machineset2", "machine2").
+		WithMachine("ns1", "cluster1", "", "machine3").
+		WithMachineClass("ns1", "machineclass1").
+		WithMachine("ns2", "", "", "machine4")
+}
+
+func newJournalStore(t *testing.T) (JournalStore, func()) {
+	dir, err := ioutil.TempDir("", "pivot-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFileJournalStore(filepath.Join(dir, "journal.json")), func() { os.RemoveAll(dir) }
+}
+
+// counts returns the number of objects of each kind, to compare the content of clusters.
+func counts(clusters map[string][]*clusterv1.Cluster, mds map[string][]*clusterv1.MachineDeployment, mss map[string][]*clusterv1.MachineSet, machines map[string][]*clusterv1.Machine, mcs map[string][]*clusterv1.MachineClass) [5]int {
+	var c [5]int
+	for _, l := range clusters {
+		c[0] += len(l)
+	}
+	for _, l := range mds {
+		c[1] += len(l)
+	}
+	for _, l := range mss {
+		c[2] += len(l)
+	}
+	for _, l := range machines {
+		c[3] += len(l)
+	}
+	for _, l := range mcs {
+		c[4] += len(l)
+	}
+	return c
+}
+
+func sourceCounts(s *sourcer) [5]int {
+	return counts(s.clusters, s.machineDeployments, s.machineSets, s.machines, s.machineClasses)
+}
+
+func targetCounts(t *target) [5]int {
+	return counts(t.clusters, t.machineDeployments, t.machineSets, t.machines, t.machineClasses)
+}
+
+func TestPivotJournalCompleted(t *testing.T) {
+	store, cleanup := newJournalStore(t)
+	defer cleanup()
+	pc := &providerComponents{names: []string{"controller"}}
+
+	if err := PivotWithOptions(newJournalTestSource(), newTarget(), pc.String(), PivotOptions{Journal: store}); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+
+	journal, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !journal.Completed {
+		t.Error("expected the journal to record a completed pivot")
+	}
+	if len(journal.Controllers) != 1 || journal.Controllers[0].Name != "controller" || journal.Controllers[0].Replicas != 1 {
+		t.Errorf("unexpected controllers in journal: %v", journal.Controllers)
+	}
+	if len(journal.Objects) != 9 {
+		t.Errorf("expected 9 objects in journal, got %d", len(journal.Objects))
+	}
+	for _, o := range journal.Objects {
+		if o.State != ObjectMoved {
+			t.Errorf("expected %s %s/%s to be moved, got %s", o.Kind, o.Namespace, o.Name, o.State)
+		}
+	}
+
+	// A completed pivot doesn't prevent a new one.
+	if err := PivotWithOptions(newSourcer(), newTarget(), pc.String(), PivotOptions{Journal: store}); err != nil {
+		t.Errorf("did not expect err but got %v", err)
+	}
+}
+
+func TestPivotResume(t *testing.T) {
+	store, cleanup := newJournalStore(t)
+	defer cleanup()
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newJournalTestSource()
+	expected := sourceCounts(source)
+	target := &flakyTarget{target: newTarget(), failMachine: "machine2", fail: true}
+
+	if err := PivotWithOptions(source, target, pc.String(), PivotOptions{Journal: store}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if source.replicas["controller"] != 0 {
+		t.Errorf("expected the controller to be scaled down, got %d replicas", source.replicas["controller"])
+	}
+
+	err := PivotWithOptions(source, target, pc.String(), PivotOptions{Journal: store})
+	if err == nil || !strings.Contains(err.Error(), "unfinished pivot") {
+		t.Fatalf("expected an error about the unfinished pivot, got %v", err)
+	}
+
+	target.fail = false
+	if err := PivotWithOptions(source, target, pc.String(), PivotOptions{Journal: store, Resume: true}); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	if got := sourceCounts(source); got != [5]int{} {
+		t.Errorf("expected the source cluster to be empty, got %v", got)
+	}
+	if got := targetCounts(target.target); got != expected {
+		t.Errorf("expected %v objects in the target cluster, got %v", expected, got)
+	}
+}
+
+func TestPivotResumeWithoutJournal(t *testing.T) {
+	store, cleanup := newJournalStore(t)
+	defer cleanup()
+
+	if err := PivotWithOptions(newSourcer(), newTarget(), "", PivotOptions{Journal: store, Resume: true}); err == nil {
+		t.Error("expected an error but got nil")
+	}
+}
+
+func TestPivotRollback(t *testing.T) {
+	store, cleanup := newJournalStore(t)
+	defer cleanup()
+	pc := &providerComponents{names: []string{"controller"}}
+	source := newJournalTestSource()
+	expected := sourceCounts(source)
+	target := &flakyTarget{target: newTarget(), failMachine: "machine3", fail: true}
+
+	if err := PivotWithOptions(source, target, pc.String(), PivotOptions{Journal: store}); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+	if got := targetCounts(target.target); got == [5]int{} {
+		t.Fatal("expected objects to be moved to the target cluster before the failure")
+	}
+
+	if err := RollbackPivot(source, target, store); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+	if got := sourceCounts(source); got != expected {
+		t.Errorf("expected %v objects in the source cluster, got %v", expected, got)
+	}
+	if got := targetCounts(target.target); got != [5]int{} {
+		t.Errorf("expected the target cluster to be empty, got %v", got)
+	}
+	if source.replicas["controller"] != 1 {
+		t.Errorf("expected the controller to be scaled back up, got %d replicas", source.replicas["controller"])
+	}
+	if journal, err := store.Load(); err != nil || journal != nil {
+		t.Errorf("expected the journal to be removed, got %v, %v", journal, err)
+	}
+}
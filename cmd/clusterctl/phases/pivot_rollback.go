@@ -0,0 +1,263 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package phases
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+type rollbackSourceClient interface {
+	pauseClient
+	scaleClient
+	CreateClusterObject(*clusterv1.Cluster) error
+	CreateConfigMap(*corev1.ConfigMap) error
+	CreateMachineClass(*clusterv1.MachineClass) error
+	CreateMachineDeployments([]*clusterv1.MachineDeployment, string) error
+	CreateMachines([]*clusterv1.Machine, string) error
+	CreateMachineSets([]*clusterv1.MachineSet, string) error
+	CreateSecret(*corev1.Secret) error
+	EnsureNamespace(string) error
+	GetConfigMap(namespace, name string) (*corev1.ConfigMap, error)
+	GetSecret(namespace, name string) (*corev1.Secret, error)
+}
+
+type rollbackTargetClient interface {
+	scaleClient
+	DeleteConfigMap(namespace, name string) error
+	DeleteMachineClass(namespace, name string) error
+	DeleteSecret(namespace, name string) error
+	ForceDeleteCluster(string, string) error
+	ForceDeleteMachine(string, string) error
+	ForceDeleteMachineDeployment(string, string) error
+	ForceDeleteMachineSet(namespace, name string) error
+	GetConfigMap(namespace, name string) (*corev1.ConfigMap, error)
+	GetSecret(namespace, name string) (*corev1.Secret, error)
+}
+
+// RollbackPivot undoes the unfinished pivot recorded in the journal: the
+// controllers are scaled down in the target cluster, the moved objects are
+// restored in the source cluster and deleted from the target cluster, the
+// objects of the source cluster are unpaused and its controllers are scaled
+// back up. The journal is removed
+// once the rollback has completed.
+func RollbackPivot(source rollbackSourceClient, target rollbackTargetClient, store JournalStore) error {
+	state, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return errors.New("no pivot to roll back was found in the pivot journal")
+	}
+	if state.Completed {
+		return errors.New("the pivot recorded in the journal has completed and can't be rolled back")
+	}
+	j := &journal{store: store, state: state}
+
+	for _, controller := range state.Controllers {
+		klog.V(4).Infof("Scaling down controller %s/%s in the target cluster", controller.Namespace, controller.Name)
+		if err := scaleController(target, controller, 0); err != nil {
+			return errors.Wrapf(err, "failed to scale down %s/%s in the target cluster", controller.Namespace, controller.Name)
+		}
+	}
+
+	// Undo the moves in the reverse order.
+	for i := len(state.Objects) - 1; i >= 0; i-- {
+		o := &state.Objects[i]
+		if o.State == ObjectRolledBack {
+			continue
+		}
+		klog.V(4).Infof("Rolling back %s %s/%s", o.Kind, o.Namespace, o.Name)
+		if err := restoreObject(source, target, o); err != nil {
+			return errors.Wrapf(err, "failed to restore %s %s/%s in the source cluster", o.Kind, o.Namespace, o.Name)
+		}
+		if err := deleteCopiedObject(target, o); err != nil {
+			return errors.Wrapf(err, "failed to delete %s %s/%s from the target cluster", o.Kind, o.Namespace, o.Name)
+		}
+		o.State = ObjectRolledBack
+		if err := j.save(); err != nil {
+			return err
+		}
+	}
+
+	klog.V(4).Info("Unpausing the Cluster API objects of the source cluster")
+	if err := setObjectsPaused(source, false); err != nil {
+		return err
+	}
+
+	for _, controller := range state.Controllers {
+		klog.V(4).Infof("Scaling up controller %s/%s to %d replicas", controller.Namespace, controller.Name, controller.Replicas)
+		if err := scaleController(source, controller, controller.Replicas); err != nil {
+			return errors.Wrapf(err, "failed to scale up %s/%s", controller.Namespace, controller.Name)
+		}
+	}
+
+	klog.Info("Rolled back the pivot")
+	return store.Remove()
+}
+
+// restoreObject creates the object recorded in the journal in the source
+// cluster, unless it is still there. Owner references are dropped, as the
+// restored owners get new UIDs.
+func restoreObject(source rollbackSourceClient, target rollbackTargetClient, o *JournalObject) error {
+	var err error
+	switch o.Kind {
+	case kindCluster:
+		cluster := &clusterv1.Cluster{}
+		if err := json.Unmarshal(o.Object, cluster); err != nil {
+			return err
+		}
+		if err := source.EnsureNamespace(cluster.Namespace); err != nil {
+			return err
+		}
+		err = source.CreateClusterObject(cluster)
+	case kindMachineClass:
+		machineClass := &clusterv1.MachineClass{}
+		if err := json.Unmarshal(o.Object, machineClass); err != nil {
+			return err
+		}
+		err = source.CreateMachineClass(machineClass)
+	case kindMachineDeployment:
+		md := &clusterv1.MachineDeployment{}
+		if err := json.Unmarshal(o.Object, md); err != nil {
+			return err
+		}
+		md.SetOwnerReferences(nil)
+		err = source.CreateMachineDeployments([]*clusterv1.MachineDeployment{md}, md.Namespace)
+	case kindMachineSet:
+		ms := &clusterv1.MachineSet{}
+		if err := json.Unmarshal(o.Object, ms); err != nil {
+			return err
+		}
+		ms.SetOwnerReferences(nil)
+		err = source.CreateMachineSets([]*clusterv1.MachineSet{ms}, ms.Namespace)
+	case kindMachine:
+		m := &clusterv1.Machine{}
+		if err := json.Unmarshal(o.Object, m); err != nil {
+			return err
+		}
+		m.SetOwnerReferences(nil)
+		err = source.CreateMachines([]*clusterv1.Machine{m}, m.Namespace)
+	case kindConfigMap:
+		err = restoreConfigMap(source, target, o.Namespace, o.Name)
+	case kindSecret:
+		err = restoreSecret(source, target, o.Namespace, o.Name)
+	default:
+		return errors.Errorf("unknown kind %q", o.Kind)
+	}
+	if err != nil && !apierrors.IsAlreadyExists(errors.Cause(err)) {
+		return err
+	}
+	return nil
+}
+
+// restoreSecret creates the Secret in the source cluster from its copy in the
+// target cluster when it is no longer in the source cluster, which happens when
+// it was garbage collected along with the owners moved by the pivot. The journal
+// only records its metadata.
+func restoreSecret(source rollbackSourceClient, target rollbackTargetClient, namespace, name string) error {
+	if _, err := source.GetSecret(namespace, name); !apierrors.IsNotFound(errors.Cause(err)) {
+		return err
+	}
+	copied, err := target.GetSecret(namespace, name)
+	if apierrors.IsNotFound(errors.Cause(err)) {
+		klog.Warningf("Secret %s/%s is missing from both clusters, it can't be restored", namespace, name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: restoredMeta(copied.ObjectMeta),
+		Type:       copied.Type,
+		Data:       copied.Data,
+	}
+	if err := source.EnsureNamespace(namespace); err != nil {
+		return err
+	}
+	return source.CreateSecret(secret)
+}
+
+// restoreConfigMap creates the ConfigMap in the source cluster from its copy
+// in the target cluster when it is no longer in the source cluster.
+func restoreConfigMap(source rollbackSourceClient, target rollbackTargetClient, namespace, name string) error {
+	if _, err := source.GetConfigMap(namespace, name); !apierrors.IsNotFound(errors.Cause(err)) {
+		return err
+	}
+	copied, err := target.GetConfigMap(namespace, name)
+	if apierrors.IsNotFound(errors.Cause(err)) {
+		klog.Warningf("ConfigMap %s/%s is missing from both clusters, it can't be restored", namespace, name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: restoredMeta(copied.ObjectMeta),
+		Data:       copied.Data,
+		BinaryData: copied.BinaryData,
+	}
+	if err := source.EnsureNamespace(namespace); err != nil {
+		return err
+	}
+	return source.CreateConfigMap(configMap)
+}
+
+// restoredMeta returns the metadata to create an object in the source cluster
+// with, from the metadata of its copy in the target cluster.
+func restoredMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:        meta.Name,
+		Namespace:   meta.Namespace,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+}
+
+// deleteCopiedObject deletes the object recorded in the journal from the
+// target cluster, if it was created there.
+func deleteCopiedObject(target rollbackTargetClient, o *JournalObject) error {
+	var err error
+	switch o.Kind {
+	case kindCluster:
+		err = target.ForceDeleteCluster(o.Namespace, o.Name)
+	case kindMachineClass:
+		err = target.DeleteMachineClass(o.Namespace, o.Name)
+	case kindMachineDeployment:
+		err = target.ForceDeleteMachineDeployment(o.Namespace, o.Name)
+	case kindMachineSet:
+		err = target.ForceDeleteMachineSet(o.Namespace, o.Name)
+	case kindMachine:
+		err = target.ForceDeleteMachine(o.Namespace, o.Name)
+	case kindConfigMap:
+		err = target.DeleteConfigMap(o.Namespace, o.Name)
+	case kindSecret:
+		err = target.DeleteSecret(o.Namespace, o.Name)
+	default:
+		return errors.Errorf("unknown kind %q", o.Kind)
+	}
+	if err != nil && !apierrors.IsNotFound(errors.Cause(err)) {
+		return err
+	}
+	return nil
+}
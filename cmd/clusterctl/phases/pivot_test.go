@@ -18,10 +18,13 @@ package phases
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 )
 
@@ -33,6 +36,10 @@ type sourcer struct {
 	machineSets        map[string][]*clusterv1.MachineSet
 	machines           map[string][]*clusterv1.Machine
 	machineClasses     map[string][]*clusterv1.MachineClass
+	secrets            map[string][]*corev1.Secret
+	configMaps         map[string][]*corev1.ConfigMap
+	// replicas are the last number of replicas the controllers were scaled to, by name
+	replicas map[string]int32
 }
 
 func newSourcer() *sourcer {
@@ -42,13 +49,23 @@ func newSourcer() *sourcer {
 		machineSets:        make(map[string][]*clusterv1.MachineSet),
 		machines:           make(map[string][]*clusterv1.Machine),
 		machineClasses:     make(map[string][]*clusterv1.MachineClass),
+		secrets:            make(map[string][]*corev1.Secret),
+		configMaps:         make(map[string][]*corev1.ConfigMap),
+		replicas:           make(map[string]int32),
 	}
 }
+
+// sourceUID returns the UID of an object in the source cluster.
+func sourceUID(kind, ns, name string) types.UID {
+	return types.UID(fmt.Sprintf("source/%s/%s/%s", kind, ns, name))
+}
+
 func (s *sourcer) WithCluster(ns, name string) *sourcer {
 	s.clusters[ns] = append(s.clusters[ns], &clusterv1.Cluster{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: ns,
+			UID:       sourceUID("Cluster", ns, name),
 		},
 	})
 	return s
@@ -59,6 +76,7 @@ func (s *sourcer) WithMachineDeployment(ns, cluster, name string) *sourcer {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: ns,
+			UID:       sourceUID("MachineDeployment", ns, name),
 		},
 	}
 
@@ -70,6 +88,7 @@ func (s *sourcer) WithMachineDeployment(ns, cluster, name string) *sourcer {
 				APIVersion:         clusterv1.SchemeGroupVersion.Version,
 				Kind:               "Cluster",
 				Name:               cluster,
+				UID:                sourceUID("Cluster", ns, cluster),
 				BlockOwnerDeletion: &blockOwnerDeletion,
 			},
 		}
@@ -83,6 +102,7 @@ func (s *sourcer) WithMachineSet(ns, cluster, md, name string) *sourcer {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: ns,
+			UID:       sourceUID("MachineSet", ns, name),
 		},
 	}
 	if cluster != "" {
@@ -93,6 +113,7 @@ func (s *sourcer) WithMachineSet(ns, cluster, md, name string) *sourcer {
 				APIVersion:         clusterv1.SchemeGroupVersion.Version,
 				Kind:               "Cluster",
 				Name:               cluster,
+				UID:                sourceUID("Cluster", ns, cluster),
 				BlockOwnerDeletion: &blockOwnerDeletion,
 			},
 		}
@@ -104,6 +125,7 @@ func (s *sourcer) WithMachineSet(ns, cluster, md, name string) *sourcer {
 				APIVersion: clusterv1.SchemeGroupVersion.Version,
 				Kind:       "MachineDeployment",
 				Name:       md,
+				UID:        sourceUID("MachineDeployment", ns, md),
 				Controller: &isController,
 			},
 		}
@@ -118,6 +140,7 @@ func (s *sourcer) WithMachine(ns, cluster, ms, name string) *sourcer {
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: ns,
+			UID:       sourceUID("Machine", ns, name),
 		},
 	}
 	if cluster != "" {
@@ -128,6 +151,7 @@ func (s *sourcer) WithMachine(ns, cluster, ms, name string) *sourcer {
 				APIVersion:         clusterv1.SchemeGroupVersion.Version,
 				Kind:               "Cluster",
 				Name:               cluster,
+				UID:                sourceUID("Cluster", ns, cluster),
 				BlockOwnerDeletion: &blockOwnerDeletion,
 			},
 		}
@@ -139,6 +163,7 @@ func (s *sourcer) WithMachine(ns, cluster, ms, name string) *sourcer {
 				APIVersion: clusterv1.SchemeGroupVersion.Version,
 				Kind:       "MachineSet",
 				Name:       ms,
+				UID:        sourceUID("MachineSet", ns, ms),
 				Controller: &isController,
 			},
 		}
@@ -332,7 +357,12 @@ func (s *sourcer) GetMachinesForMachineSet(ms *clusterv1.MachineSet) ([]*cluster
 	return machines, nil
 }
 
-func (s *sourcer) ScaleStatefulSet(string, string, int32) error {
+func (s *sourcer) ScaleDeployment(ns, name string, replicas int32) error {
+	s.replicas[name] = replicas
+	return nil
+}
+func (s *sourcer) ScaleStatefulSet(ns, name string, replicas int32) error {
+	s.replicas[name] = replicas
 	return nil
 }
 func (s *sourcer) WaitForClusterV1alpha1Ready() error {
@@ -345,13 +375,37 @@ type target struct {
 	machineSets        map[string][]*clusterv1.MachineSet
 	machines           map[string][]*clusterv1.Machine
 	machineClasses     map[string][]*clusterv1.MachineClass
+	secrets            map[string][]*corev1.Secret
+	configMaps         map[string][]*corev1.ConfigMap
+	// lastUID numbers the UIDs of the created objects
+	lastUID int
+}
+
+func newTarget() *target {
+	return &target{
+		clusters:           make(map[string][]*clusterv1.Cluster),
+		machineDeployments: make(map[string][]*clusterv1.MachineDeployment),
+		machineSets:        make(map[string][]*clusterv1.MachineSet),
+		machines:           make(map[string][]*clusterv1.Machine),
+		machineClasses:     make(map[string][]*clusterv1.MachineClass),
+		secrets:            make(map[string][]*corev1.Secret),
+		configMaps:         make(map[string][]*corev1.ConfigMap),
+	}
+}
+
+// created returns the metadata of an object created from meta, which like the
+// API server gets a new UID.
+func (t *target) created(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	t.lastUID++
+	meta.UID = types.UID(fmt.Sprintf("target/%d", t.lastUID))
+	return meta
 }
 
 func (t *target) Apply(string) error {
 	return nil
 }
 func (t *target) CreateClusterObject(c *clusterv1.Cluster) error {
-	t.clusters[c.Namespace] = append(t.clusters[c.Namespace], c)
+	t.clusters[c.Namespace] = append(t.clusters[c.Namespace], &clusterv1.Cluster{ObjectMeta: t.created(c.ObjectMeta), Spec: c.Spec})
 	return nil
 }
 func (t *target) CreateMachineClass(mc *clusterv1.MachineClass) error {
@@ -359,15 +413,21 @@ func (t *target) CreateMachineClass(mc *clusterv1.MachineClass) error {
 	return nil
 }
 func (t *target) CreateMachineDeployments(deployments []*clusterv1.MachineDeployment, ns string) error {
-	t.machineDeployments[ns] = append(t.machineDeployments[ns], deployments...)
+	for _, md := range deployments {
+		t.machineDeployments[ns] = append(t.machineDeployments[ns], &clusterv1.MachineDeployment{ObjectMeta: t.created(md.ObjectMeta), Spec: md.Spec})
+	}
 	return nil
 }
 func (t *target) CreateMachines(machines []*clusterv1.Machine, ns string) error {
-	t.machines[ns] = append(t.machines[ns], machines...)
+	for _, m := range machines {
+		t.machines[ns] = append(t.machines[ns], &clusterv1.Machine{ObjectMeta: t.created(m.ObjectMeta), Spec: m.Spec})
+	}
 	return nil
 }
-func (t *target) CreateMachineSets(ms []*clusterv1.MachineSet, ns string) error {
-	t.machineSets[ns] = append(t.machineSets[ns], ms...)
+func (t *target) CreateMachineSets(machineSets []*clusterv1.MachineSet, ns string) error {
+	for _, ms := range machineSets {
+		t.machineSets[ns] = append(t.machineSets[ns], &clusterv1.MachineSet{ObjectMeta: t.created(ms.ObjectMeta), Spec: ms.Spec})
+	}
 	return nil
 }
 
@@ -377,7 +437,7 @@ func (t *target) EnsureNamespace(string) error {
 func (t *target) GetMachineDeployment(ns, name string) (*clusterv1.MachineDeployment, error) {
 	for _, deployment := range t.machineDeployments[ns] {
 		if deployment.Name == name {
-			return deployment, nil
+			return deployment.DeepCopy(), nil
 		}
 	}
 	return nil, fmt.Errorf("no machine deployment found in ns %q with name %q", ns, name)
@@ -385,11 +445,83 @@ func (t *target) GetMachineDeployment(ns, name string) (*clusterv1.MachineDeploy
 func (t *target) GetMachineSet(ns, name string) (*clusterv1.MachineSet, error) {
 	for _, ms := range t.machineSets[ns] {
 		if ms.Name == name {
-			return ms, nil
+			return ms.DeepCopy(), nil
 		}
 	}
 	return nil, fmt.Errorf("no machineset found with name %q in namespace %q", ns, name)
 }
+func (t *target) GetCluster(name, ns string) (*clusterv1.Cluster, error) {
+	for _, c := range t.clusters[ns] {
+		if c.Name == name {
+			return c.DeepCopy(), nil
+		}
+	}
+	return nil, nil
+}
+func (t *target) GetMachine(ns, name string) (*clusterv1.Machine, error) {
+	for _, m := range t.machines[ns] {
+		if m.Name == name {
+			return m.DeepCopy(), nil
+		}
+	}
+	return nil, fmt.Errorf("no machine found in ns %q with name %q", ns, name)
+}
+func (t *target) UpdateClusterObjectStatus(c *clusterv1.Cluster) error {
+	for _, existing := range t.clusters[c.Namespace] {
+		if existing.Name == c.Name {
+			existing.Status = c.Status
+			return nil
+		}
+	}
+	return fmt.Errorf("no cluster found in ns %q with name %q", c.Namespace, c.Name)
+}
+func (t *target) UpdateMachineDeploymentStatus(md *clusterv1.MachineDeployment) error {
+	for _, existing := range t.machineDeployments[md.Namespace] {
+		if existing.Name == md.Name {
+			existing.Status = md.Status
+			return nil
+		}
+	}
+	return fmt.Errorf("no machine deployment found in ns %q with name %q", md.Namespace, md.Name)
+}
+func (t *target) UpdateMachineSet(ms *clusterv1.MachineSet) error {
+	for _, existing := range t.machineSets[ms.Namespace] {
+		if existing.Name == ms.Name {
+			existing.ObjectMeta = ms.ObjectMeta
+			existing.Spec = ms.Spec
+			return nil
+		}
+	}
+	return fmt.Errorf("no machineset found in ns %q with name %q", ms.Namespace, ms.Name)
+}
+func (t *target) UpdateMachineSetStatus(ms *clusterv1.MachineSet) error {
+	for _, existing := range t.machineSets[ms.Namespace] {
+		if existing.Name == ms.Name {
+			existing.Status = ms.Status
+			return nil
+		}
+	}
+	return fmt.Errorf("no machineset found in ns %q with name %q", ms.Namespace, ms.Name)
+}
+func (t *target) UpdateMachine(m *clusterv1.Machine) error {
+	for _, existing := range t.machines[m.Namespace] {
+		if existing.Name == m.Name {
+			existing.ObjectMeta = m.ObjectMeta
+			existing.Spec = m.Spec
+			return nil
+		}
+	}
+	return fmt.Errorf("no machine found in ns %q with name %q", m.Namespace, m.Name)
+}
+func (t *target) UpdateMachineStatus(m *clusterv1.Machine) error {
+	for _, existing := range t.machines[m.Namespace] {
+		if existing.Name == m.Name {
+			existing.Status = m.Status
+			return nil
+		}
+	}
+	return fmt.Errorf("no machine found in ns %q with name %q", m.Namespace, m.Name)
+}
 func (t *target) WaitForClusterV1alpha1Ready() error {
 	return nil
 }
@@ -521,6 +653,62 @@ func TestPivot(t *testing.T) {
 	}
 }
 
+func TestPivotPreservesOwnersAndStatus(t *testing.T) {
+	pc := &providerComponents{names: []string{"controller"}}
+	ns := "ns1"
+	source := newSourcer().
+		WithCluster(ns, "cluster1").
+		WithMachineDeployment(ns, "cluster1", "deployment1").
+		WithMachineSet(ns, "cluster1", "deployment1", "machineset1").
+		WithMachine(ns, "cluster1", "machineset1", "machine1")
+	source.clusters[ns][0].Status.APIEndpoints = []clusterv1.APIEndpoint{{Host: "10.0.0.1", Port: 6443}}
+	source.machineDeployments[ns][0].Status.ObservedGeneration = 3
+	source.machineSets[ns][0].Status.Replicas = 1
+	source.machines[ns][0].Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "node1"}
+	cluster := source.clusters[ns][0]
+	md := source.machineDeployments[ns][0]
+	ms := source.machineSets[ns][0]
+	machine := source.machines[ns][0]
+
+	target := newTarget()
+	if err := Pivot(source, target, pc.String()); err != nil {
+		t.Fatalf("did not expect err but got %v", err)
+	}
+
+	targetCluster := target.clusters[ns][0]
+	targetMD := target.machineDeployments[ns][0]
+	targetMS := target.machineSets[ns][0]
+	targetMachine := target.machines[ns][0]
+
+	owners := []struct {
+		name  string
+		refs  []metav1.OwnerReference
+		owner types.UID
+	}{
+		{"MachineDeployment", targetMD.OwnerReferences, targetCluster.UID},
+		{"MachineSet", targetMS.OwnerReferences, targetMD.UID},
+		{"Machine", targetMachine.OwnerReferences, targetMS.UID},
+	}
+	for _, o := range owners {
+		if len(o.refs) != 1 || o.refs[0].UID != o.owner {
+			t.Errorf("expected the %s to be owned by %q in the target cluster, got %v", o.name, o.owner, o.refs)
+		}
+	}
+
+	if !reflect.DeepEqual(targetCluster.Status, cluster.Status) {
+		t.Errorf("expected Cluster status %v, got %v", cluster.Status, targetCluster.Status)
+	}
+	if !reflect.DeepEqual(targetMD.Status, md.Status) {
+		t.Errorf("expected MachineDeployment status %v, got %v", md.Status, targetMD.Status)
+	}
+	if !reflect.DeepEqual(targetMS.Status, ms.Status) {
+		t.Errorf("expected MachineSet status %v, got %v", ms.Status, targetMS.Status)
+	}
+	if !reflect.DeepEqual(targetMachine.Status, machine.Status) {
+		t.Errorf("expected Machine status %v, got %v", machine.Status, targetMachine.Status)
+	}
+}
+
 // An example of testing a failure scenario
 // Override the function you want to fail with an embedded sourcer struct on a
 // new type:
@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rollout inspects and rolls back the revisions of a MachineDeployment.
+package rollout
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	dutil "sigs.k8s.io/cluster-api/pkg/controller/machinedeployment/util"
+	"sigs.k8s.io/yaml"
+)
+
+type client interface {
+	GetMachineDeployment(namespace, name string) (*clusterv1.MachineDeployment, error)
+	GetMachineSetsForMachineDeployment(*clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error)
+	UpdateMachineDeployment(*clusterv1.MachineDeployment) error
+}
+
+// revision is a MachineSet of a MachineDeployment along with the revision it serves.
+type revision struct {
+	number     int64
+	machineSet *clusterv1.MachineSet
+}
+
+// History writes the revisions of the given MachineDeployment to w, each one followed by the
+// changes of its machine template compared to the previous revision. If rev is not zero, only
+// the machine template of that revision is written.
+func History(c client, w io.Writer, namespace, name string, rev int64) error {
+	md, err := c.GetMachineDeployment(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := getRevisions(c, md)
+	if err != nil {
+		return err
+	}
+
+	if rev != 0 {
+		for _, r := range revisions {
+			if r.number != rev {
+				continue
+			}
+			template, err := templateToYAML(r.machineSet)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "MachineDeployment %s/%s with revision #%d (MachineSet %s)\n", md.Namespace, md.Name, r.number, r.machineSet.Name)
+			fmt.Fprint(w, template)
+			return nil
+		}
+		return errors.Errorf("unable to find revision %d of MachineDeployment %s/%s", rev, md.Namespace, md.Name)
+	}
+
+	fmt.Fprintf(w, "MachineDeployment %s/%s\n", md.Namespace, md.Name)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "REVISION\tMACHINESET\tREPLICAS\tCREATED")
+	for _, r := range revisions {
+		replicas := int32(0)
+		if r.machineSet.Spec.Replicas != nil {
+			replicas = *r.machineSet.Spec.Replicas
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\n", r.number, r.machineSet.Name, replicas, r.machineSet.CreationTimestamp)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for i := 1; i < len(revisions); i++ {
+		previous, err := templateToYAML(revisions[i-1].machineSet)
+		if err != nil {
+			return err
+		}
+		current, err := templateToYAML(revisions[i].machineSet)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\nChanges from revision #%d to revision #%d:\n", revisions[i-1].number, revisions[i].number)
+		fmt.Fprint(w, diff(previous, current))
+	}
+
+	return nil
+}
+
+// Undo rolls the given MachineDeployment back to the revision rev. If rev is zero, the
+// MachineDeployment is rolled back to its previous revision.
+func Undo(c client, namespace, name string, rev int64) error {
+	md, err := c.GetMachineDeployment(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	if rev != 0 {
+		revisions, err := getRevisions(c, md)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, r := range revisions {
+			if r.number == rev {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("unable to find revision %d of MachineDeployment %s/%s", rev, md.Namespace, md.Name)
+		}
+	}
+
+	md.Spec.RollbackTo = &clusterv1.MachineDeploymentRollbackConfig{Revision: rev}
+	if err := c.UpdateMachineDeployment(md); err != nil {
+		return errors.Wrapf(err, "unable to roll back MachineDeployment %s/%s", md.Namespace, md.Name)
+	}
+	return nil
+}
+
+// getRevisions returns the revisions of the given MachineDeployment sorted in ascending order.
+func getRevisions(c client, md *clusterv1.MachineDeployment) ([]revision, error) {
+	machineSets, err := c.GetMachineSetsForMachineDeployment(md)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]revision, 0, len(machineSets))
+	for _, ms := range machineSets {
+		v, err := dutil.Revision(ms)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse revision of MachineSet %s/%s", ms.Namespace, ms.Name)
+		}
+		revisions = append(revisions, revision{number: v, machineSet: ms})
+	}
+
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].number < revisions[j].number })
+	return revisions, nil
+}
+
+// templateToYAML returns the machine template of the given MachineSet as YAML, without the
+// machine-template-hash label which changes for every revision.
+func templateToYAML(ms *clusterv1.MachineSet) (string, error) {
+	template := ms.Spec.Template.DeepCopy()
+	template.Labels = dutil.CloneAndRemoveLabel(template.Labels, dutil.DefaultMachineDeploymentUniqueLabelKey)
+
+	out, err := yaml.Marshal(template)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to marshal machine template of MachineSet %s/%s", ms.Namespace, ms.Name)
+	}
+	return string(out), nil
+}
+
+// diff returns a line based diff between from and to, where removed lines are prefixed with
+// "-", added lines with "+" and unchanged lines with a space.
+func diff(from, to string) string {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(from, to)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	var out strings.Builder
+	for _, d := range diffs {
+		prefix := " "
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			out.WriteString(prefix + line)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	dutil "sigs.k8s.io/cluster-api/pkg/controller/machinedeployment/util"
+)
+
+type testClient struct {
+	machineDeployment *clusterv1.MachineDeployment
+	machineSets       []*clusterv1.MachineSet
+	updated           *clusterv1.MachineDeployment
+}
+
+func (c *testClient) GetMachineDeployment(namespace, name string) (*clusterv1.MachineDeployment, error) {
+	if c.machineDeployment == nil || c.machineDeployment.Namespace != namespace || c.machineDeployment.Name != name {
+		return nil, errors.New("not found")
+	}
+	return c.machineDeployment.DeepCopy(), nil
+}
+
+func (c *testClient) GetMachineSetsForMachineDeployment(*clusterv1.MachineDeployment) ([]*clusterv1.MachineSet, error) {
+	return c.machineSets, nil
+}
+
+func (c *testClient) UpdateMachineDeployment(md *clusterv1.MachineDeployment) error {
+	c.updated = md
+	return nil
+}
+
+func newMachineSet(name, revision, version string) *clusterv1.MachineSet {
+	replicas := int32(1)
+	return &clusterv1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: map[string]string{dutil.RevisionAnnotation: revision},
+		},
+		Spec: clusterv1.MachineSetSpec{
+			Replicas: &replicas,
+			Template: clusterv1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{dutil.DefaultMachineDeploymentUniqueLabelKey: name},
+				},
+				Spec: clusterv1.MachineSpec{
+					Versions: clusterv1.MachineVersionInfo{Kubelet: version},
+				},
+			},
+		},
+	}
+}
+
+func newTestClient() *testClient {
+	return &testClient{
+		machineDeployment: &clusterv1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "md", Namespace: "default"},
+		},
+		machineSets: []*clusterv1.MachineSet{
+			newMachineSet("ms-2", "2", "1.13.0"),
+			newMachineSet("ms-1", "1", "1.12.0"),
+		},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var out bytes.Buffer
+	if err := History(newTestClient(), &out, "default", "md", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := out.String()
+	if first, second := strings.Index(got, "ms-1"), strings.Index(got, "ms-2"); first < 0 || second < first {
+		t.Errorf("expected revisions to be listed in ascending order, got:\n%s", got)
+	}
+	for _, want := range []string{"Changes from revision #1 to revision #2:", "-    kubelet: 1.12.0", "+    kubelet: 1.13.0"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, dutil.DefaultMachineDeploymentUniqueLabelKey) {
+		t.Errorf("expected output not to contain the %q label, got:\n%s", dutil.DefaultMachineDeploymentUniqueLabelKey, got)
+	}
+}
+
+func TestHistoryRevision(t *testing.T) {
+	var out bytes.Buffer
+	if err := History(newTestClient(), &out, "default", "md", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "kubelet: 1.12.0") || strings.Contains(got, "1.13.0") {
+		t.Errorf("expected only the template of revision 1, got:\n%s", got)
+	}
+
+	if err := History(newTestClient(), &out, "default", "md", 3); err == nil {
+		t.Error("expected an error for a missing revision")
+	}
+}
+
+func TestUndo(t *testing.T) {
+	testcases := []struct {
+		name        string
+		revision    int64
+		expectedErr bool
+	}{
+		{name: "previous revision", revision: 0},
+		{name: "existing revision", revision: 1},
+		{name: "missing revision", revision: 3, expectedErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient()
+			err := Undo(c, "default", "md", tc.revision)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if c.updated != nil {
+					t.Error("expected the machine deployment not to be updated")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.updated == nil || c.updated.Spec.RollbackTo == nil {
+				t.Fatal("expected rollbackTo to be set")
+			}
+			if got := c.updated.Spec.RollbackTo.Revision; got != tc.revision {
+				t.Errorf("expected rollback to revision %d, got %d", tc.revision, got)
+			}
+		})
+	}
+}
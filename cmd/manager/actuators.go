@@ -0,0 +1,60 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/pkg/controller/cluster"
+	"sigs.k8s.io/cluster-api/pkg/controller/machine"
+	"sigs.k8s.io/cluster-api/pkg/controller/remote"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// addRemoteActuators adds the machine and cluster controllers to mgr, calling the actuators
+// served out of process at the given targets, by provider name. A controller is only added
+// if actuators are configured for it.
+func addRemoteActuators(mgr manager.Manager, machineTargets, clusterTargets map[string]string) error {
+	if len(machineTargets) > 0 {
+		actuators := map[string]machine.ActuatorV2{}
+		for provider, target := range machineTargets {
+			conn, err := remote.Dial(target)
+			if err != nil {
+				return errors.Wrapf(err, "failed to connect to the machine actuator of provider %q at %q", provider, target)
+			}
+			actuators[provider] = remote.NewMachineActuator(conn)
+		}
+		if err := machine.AddWithActuators(mgr, actuators); err != nil {
+			return err
+		}
+	}
+
+	if len(clusterTargets) > 0 {
+		actuators := map[string]cluster.ActuatorV2{}
+		for provider, target := range clusterTargets {
+			conn, err := remote.Dial(target)
+			if err != nil {
+				return errors.Wrapf(err, "failed to connect to the cluster actuator of provider %q at %q", provider, target)
+			}
+			actuators[provider] = remote.NewClusterActuator(conn)
+		}
+		if err := cluster.AddWithActuators(mgr, actuators); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -17,14 +17,21 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"time"
 
+	"github.com/spf13/pflag"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/klog"
 	"sigs.k8s.io/cluster-api/pkg/apis"
 	"sigs.k8s.io/cluster-api/pkg/controller"
+	controllerconfig "sigs.k8s.io/cluster-api/pkg/controller/config"
+	"sigs.k8s.io/cluster-api/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
@@ -35,14 +42,34 @@ func main() {
 	klog.InitFlags(nil)
 	watchNamespace := flag.String("namespace", "",
 		"Namespace that the controller watches to reconcile cluster-api objects. If unspecified, the controller watches for cluster-api objects across all namespaces.")
+	metricsAddr := flag.String("metrics-addr", ":8080",
+		"The address the metrics endpoint binds to. Set to \"0\" to disable the metrics endpoint.")
+	healthAddr := flag.String("health-addr", ":9440",
+		"The address the /healthz and /readyz probe endpoints bind to.")
+	webhookPort := flag.Int("webhook-port", 0,
+		"Port on which the admission webhooks are served. If unspecified, the webhooks are disabled.")
+	webhookCertDir := flag.String("webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs",
+		"Directory containing the tls.crt and tls.key files used to serve the admission webhooks.")
+
+	machineActuators := pflag.StringToString("machine-actuator", nil,
+		"Address of the machine actuator of a provider served out of process, as provider=unix:///path/to/socket or provider=host:port. "+
+			"It may be repeated to run the machine controller for several providers. The machines which don't specify a provider are handled by the actuator configured with an empty provider name.")
+	clusterActuators := pflag.StringToString("cluster-actuator", nil,
+		"Address of the cluster actuator of a provider served out of process, as provider=unix:///path/to/socket or provider=host:port. "+
+			"It may be repeated to run the cluster controller for several providers. The clusters which don't specify a provider are handled by the actuator configured with an empty provider name.")
+
+	// The controller configuration flags are registered first, so that its --kubeconfig flag
+	// takes precedence over the one registered by controller-runtime.
+	controllerconfig.ControllerConfig.AddFlags(pflag.CommandLine)
+	pflag.CommandLine.AddGoFlagSet(flag.CommandLine)
+	pflag.Parse()
 
-	flag.Parse()
 	if *watchNamespace != "" {
 		log.Printf("Watching cluster-api objects only in namespace %q for reconciliation.", *watchNamespace)
 	}
 	log.Printf("Registering Components.")
 	// Get a config to talk to the apiserver
-	cfg, err := config.GetConfig()
+	cfg, err := getConfig(controllerconfig.ControllerConfig.Kubeconfig)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -50,8 +77,11 @@ func main() {
 	// Create a new Cmd to provide shared dependencies and start components
 	syncPeriod := 10 * time.Minute
 	mgr, err := manager.New(cfg, manager.Options{
-		SyncPeriod: &syncPeriod,
-		Namespace:  *watchNamespace,
+		SyncPeriod:         &syncPeriod,
+		Namespace:          *watchNamespace,
+		MetricsBindAddress: *metricsAddr,
+		Port:               *webhookPort,
+		CertDir:            *webhookCertDir,
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -68,9 +98,64 @@ func main() {
 	if err := controller.AddToManager(mgr); err != nil {
 		log.Fatal(err)
 	}
+	if err := addRemoteActuators(mgr, *machineActuators, *clusterActuators); err != nil {
+		log.Fatal(err)
+	}
 
-	log.Printf("Starting the Cmd.")
+	// Setup all Webhooks
+	if *webhookPort != 0 {
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	stop := signals.SetupSignalHandler()
+	probes := newHealthProbes()
+	go func() {
+		log.Fatal(probes.serve(*healthAddr))
+	}()
+
+	leaderElectionConfig := controllerconfig.GetLeaderElectionConfig()
+	if !leaderElectionConfig.LeaderElect {
+		log.Printf("Starting the Cmd.")
+
+		// Start the Cmd
+		probes.setReady()
+		log.Fatal(mgr.Start(stop))
+	}
 
-	// Start the Cmd
-	log.Fatal(mgr.Start(signals.SetupSignalHandler()))
+	// Only the leader starts the Cmd. The other replicas are alive but not ready, so that
+	// the admission webhooks are only served by the leader.
+	lec, err := leaderElectionConfig.NewLeaderElectionConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stop
+		cancel()
+	}()
+	lec.Callbacks = leaderelection.LeaderCallbacks{
+		OnStartedLeading: func(ctx context.Context) {
+			log.Printf("Starting the Cmd.")
+
+			// Start the Cmd
+			probes.setReady()
+			log.Fatal(mgr.Start(ctx.Done()))
+		},
+		OnStoppedLeading: func() {
+			log.Fatalf("Lost the leader election lock %q.", lec.Name)
+		},
+	}
+	log.Printf("Waiting for the leader election lock %q.", lec.Name)
+	leaderelection.RunOrDie(ctx, *lec)
+}
+
+// getConfig returns the config to talk to the apiserver, loaded from the given kubeconfig if it is
+// set, or else as controller-runtime does.
+func getConfig(kubeconfig string) (*rest.Config, error) {
+	if kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return config.GetConfig()
 }
@@ -0,0 +1,62 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+)
+
+// healthProbes serves the liveness and readiness probes of the manager. The manager is always
+// alive, but only ready once it runs its controllers, which it does only while it holds the leader
+// election lock when leader election is enabled.
+type healthProbes struct {
+	ready int32
+}
+
+func newHealthProbes() *healthProbes {
+	return &healthProbes{}
+}
+
+// setReady marks the manager as ready.
+func (p *healthProbes) setReady() {
+	atomic.StoreInt32(&p.ready, 1)
+}
+
+// checkReady is the healthz.Checker of the readiness probe.
+func (p *healthProbes) checkReady(_ *http.Request) error {
+	if atomic.LoadInt32(&p.ready) == 0 {
+		return errors.New("controllers are not started")
+	}
+	return nil
+}
+
+// handler returns the handler serving the /healthz and /readyz endpoints.
+func (p *healthProbes) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", http.StripPrefix("/healthz", &healthz.Handler{Checks: map[string]healthz.Checker{"ping": healthz.Ping}}))
+	mux.Handle("/readyz", http.StripPrefix("/readyz", &healthz.Handler{Checks: map[string]healthz.Checker{"controllers": p.checkReady}}))
+	return mux
+}
+
+// serve serves the probes on addr until it fails.
+func (p *healthProbes) serve(addr string) error {
+	return http.ListenAndServe(addr, p.handler())
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+
+	"k8s.io/klog"
+	clusterapis "sigs.k8s.io/cluster-api/pkg/apis"
+	capicluster "sigs.k8s.io/cluster-api/pkg/controller/cluster"
+	capimachine "sigs.k8s.io/cluster-api/pkg/controller/machine"
+	"sigs.k8s.io/cluster-api/pkg/controller/node"
+	"sigs.k8s.io/cluster-api/pkg/provider/simulated"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
+)
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Set("logtostderr", "true")
+	readyDelay := flag.Duration("ready-delay", 0, "Time it takes for the node of a new machine to become Ready.")
+	deleteDelay := flag.Duration("delete-delay", 0, "Time it takes to delete a machine.")
+	createFailures := flag.Int("create-failures", 0, "Number of times the creation of each machine fails before succeeding.")
+	linkByProviderID := flag.Bool("link-by-provider-id", false, "Link nodes to their machines by provider ID only, instead of the machine annotation.")
+	apiEndpoint := flag.String("api-endpoint", "127.0.0.1:6443", "Host and port reported as the API endpoint of the clusters.")
+	flag.Parse()
+
+	cfg := config.GetConfigOrDie()
+
+	// Setup a Manager
+	mgr, err := manager.New(cfg, manager.Options{})
+	if err != nil {
+		klog.Fatalf("Failed to set up controller manager: %v", err)
+	}
+
+	if err := clusterapis.AddToScheme(mgr.GetScheme()); err != nil {
+		klog.Fatal(err)
+	}
+
+	provider := simulated.NewProvider(simulated.Options{
+		Client:           mgr.GetClient(),
+		ReadyDelay:       *readyDelay,
+		DeleteDelay:      *deleteDelay,
+		CreateFailures:   *createFailures,
+		LinkByProviderID: *linkByProviderID,
+		APIEndpoint:      *apiEndpoint,
+	})
+
+	if err := capimachine.AddWithActuatorV2(mgr, provider.MachineActuator()); err != nil {
+		klog.Fatal(err)
+	}
+	if err := capicluster.AddWithActuatorV2(mgr, provider.ClusterActuator()); err != nil {
+		klog.Fatal(err)
+	}
+	if err := node.Add(mgr); err != nil {
+		klog.Fatal(err)
+	}
+
+	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+		klog.Fatalf("Failed to run manager: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package actuator
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+)
+
+/// [Machine]
+// Machine controls machines on a specific infrastructure. The context passed
+// to its methods is cancelled once the actuator timeout of the controller has
+// passed, so that hung calls to the infrastructure don't block the controller.
+// The errors returned along with a MachineResult are transient and retried with
+// backoff. All methods should be idempotent unless otherwise specified.
+type Machine interface {
+	// Create the machine.
+	Create(context.Context, *clusterv1.Cluster, *clusterv1.Machine) (MachineResult, error)
+	// Delete the machine. If neither a requeue nor an error is returned, it is assumed that all dependent resources have been cleaned up.
+	Delete(context.Context, *clusterv1.Cluster, *clusterv1.Machine) (MachineResult, error)
+	// Update the machine to the provided definition.
+	Update(context.Context, *clusterv1.Cluster, *clusterv1.Machine) (MachineResult, error)
+	// Checks if the machine currently exists.
+	Exists(context.Context, *clusterv1.Cluster, *clusterv1.Machine) (bool, error)
+}
+
+// MachineResult is the outcome of an operation of a Machine actuator.
+type MachineResult struct {
+	// RequeueAfter, if not zero, requests the machine to be reconciled again
+	// after the given duration, e.g. while the operation is in progress.
+	RequeueAfter time.Duration
+
+	// Error, if set, is a terminal error which requires user intervention. It
+	// is recorded in the ErrorReason and ErrorMessage of the machine, which
+	// isn't reconciled again until it changes.
+	Error *capierrors.MachineError
+
+	// ProviderStatus, if set, replaces the ProviderStatus of the machine.
+	ProviderStatus *runtime.RawExtension
+
+	// Addresses, if set, replaces the Addresses of the machine.
+	Addresses []corev1.NodeAddress
+}
+
+/// [Machine]
@@ -0,0 +1,807 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/actuator/v1alpha1/actuator.proto
+
+package v1alpha1
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// MachineRequest is the request of the operations of the MachineActuator service.
+type MachineRequest struct {
+	// cluster is the cluster.k8s.io/v1alpha1 Cluster of the machine, encoded as
+	// JSON. It is empty for machines which are not linked to a cluster.
+	Cluster []byte `protobuf:"bytes,1,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	// machine is the cluster.k8s.io/v1alpha1 Machine, encoded as JSON.
+	Machine              []byte   `protobuf:"bytes,2,opt,name=machine,proto3" json:"machine,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MachineRequest) Reset()         { *m = MachineRequest{} }
+func (m *MachineRequest) String() string { return proto.CompactTextString(m) }
+func (*MachineRequest) ProtoMessage()    {}
+func (*MachineRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aae82c28f504dca2, []int{0}
+}
+
+func (m *MachineRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MachineRequest.Unmarshal(m, b)
+}
+func (m *MachineRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MachineRequest.Marshal(b, m, deterministic)
+}
+func (m *MachineRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MachineRequest.Merge(m, src)
+}
+func (m *MachineRequest) XXX_Size() int {
+	return xxx_messageInfo_MachineRequest.Size(m)
+}
+func (m *MachineRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MachineRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MachineRequest proto.InternalMessageInfo
+
+func (m *MachineRequest) GetCluster() []byte {
+	if m != nil {
+		return m.Cluster
+	}
+	return nil
+}
+
+func (m *MachineRequest) GetMachine() []byte {
+	if m != nil {
+		return m.Machine
+	}
+	return nil
+}
+
+// MachineResponse is the response of the Create, Update and Delete operations
+// of the MachineActuator service.
+type MachineResponse struct {
+	// requeue_after, if set, requests the machine to be reconciled again after
+	// the given duration.
+	RequeueAfter *duration.Duration `protobuf:"bytes,1,opt,name=requeue_after,json=requeueAfter,proto3" json:"requeue_after,omitempty"`
+	// error, if set, is a terminal error which requires user intervention.
+	Error *TerminalError `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// provider_status, if set, replaces the ProviderStatus of the machine. It is
+	// encoded as JSON.
+	ProviderStatus []byte `protobuf:"bytes,3,opt,name=provider_status,json=providerStatus,proto3" json:"provider_status,omitempty"`
+	// addresses, if set, replaces the Addresses of the machine.
+	Addresses            []*NodeAddress `protobuf:"bytes,4,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *MachineResponse) Reset()         { *m = MachineResponse{} }
+func (m *MachineResponse) String() string { return proto.CompactTextString(m) }
+func (*MachineResponse) ProtoMessage()    {}
+func (*MachineResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aae82c28f504dca2, []int{1}
+}
+
+func (m *MachineResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MachineResponse.Unmarshal(m, b)
+}
+func (m *MachineResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MachineResponse.Marshal(b, m, deterministic)
+}
+func (m *MachineResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MachineResponse.Merge(m, src)
+}
+func (m *MachineResponse) XXX_Size() int {
+	return xxx_messageInfo_MachineResponse.Size(m)
+}
+func (m *MachineResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MachineResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MachineResponse proto.InternalMessageInfo
+
+func (m *MachineResponse) GetRequeueAfter() *duration.Duration {
+	if m != nil {
+		return m.RequeueAfter
+	}
+	return nil
+}
+
+func (m *MachineResponse) GetError() *TerminalError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+func (m *MachineResponse) GetProviderStatus() []byte {
+	if m != nil {
+		return m.ProviderStatus
+	}
+	return nil
+}
+
+func (m *MachineResponse) GetAddresses() []*NodeAddress {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+// NodeAddress is an address of a machine, see the NodeAddress of core/v1.
+type NodeAddress struct {
+	Type                 string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Address              string   `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeAddress) Reset()         { *m = NodeAddress{} }
+func (m *NodeAddress) String() string { return proto.CompactTextString(m) }
+func (*NodeAddress) ProtoMessage()    {}
+func (*NodeAddress) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aae82c28f504dca2, []int{2}
+}
+
+func (m *NodeAddress) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeAddress.Unmarshal(m, b)
+}
+func (m *NodeAddress) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeAddress.Marshal(b, m, deterministic)
+}
+func (m *NodeAddress) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeAddress.Merge(m, src)
+}
+func (m *NodeAddress) XXX_Size() int {
+	return xxx_messageInfo_NodeAddress.Size(m)
+}
+func (m *NodeAddress) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeAddress.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeAddress proto.InternalMessageInfo
+
+func (m *NodeAddress) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *NodeAddress) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+// ExistsResponse is the response of the Exists operation of the MachineActuator
+// service.
+type ExistsResponse struct {
+	Exists               bool     `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExistsResponse) Reset()         { *m = ExistsResponse{} }
+func (m *ExistsResponse) String() string { return proto.CompactTextString(m) }
+func (*ExistsResponse) ProtoMessage()    {}
+func (*ExistsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aae82c28f504dca2, []int{3}
+}
+
+func (m *ExistsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExistsResponse.Unmarshal(m, b)
+}
+func (m *ExistsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExistsResponse.Marshal(b, m, deterministic)
+}
+func (m *ExistsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExistsResponse.Merge(m, src)
+}
+func (m *ExistsResponse) XXX_Size() int {
+	return xxx_messageInfo_ExistsResponse.Size(m)
+}
+func (m *ExistsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExistsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExistsResponse proto.InternalMessageInfo
+
+func (m *ExistsResponse) GetExists() bool {
+	if m != nil {
+		return m.Exists
+	}
+	return false
+}
+
+// ClusterRequest is the request of the operations of the ClusterActuator
+// service.
+type ClusterRequest struct {
+	// cluster is the cluster.k8s.io/v1alpha1 Cluster, encoded as JSON.
+	Cluster              []byte   `protobuf:"bytes,1,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ClusterRequest) Reset()         { *m = ClusterRequest{} }
+func (m *ClusterRequest) String() string { return proto.CompactTextString(m) }
+func (*ClusterRequest) ProtoMessage()    {}
+func (*ClusterRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aae82c28f504dca2, []int{4}
+}
+
+func (m *ClusterRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterRequest.Unmarshal(m, b)
+}
+func (m *ClusterRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterRequest.Marshal(b, m, deterministic)
+}
+func (m *ClusterRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterRequest.Merge(m, src)
+}
+func (m *ClusterRequest) XXX_Size() int {
+	return xxx_messageInfo_ClusterRequest.Size(m)
+}
+func (m *ClusterRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClusterRequest proto.InternalMessageInfo
+
+func (m *ClusterRequest) GetCluster() []byte {
+	if m != nil {
+		return m.Cluster
+	}
+	return nil
+}
+
+// ClusterResponse is the response of the operations of the ClusterActuator
+// service.
+type ClusterResponse struct {
+	// requeue_after, if set, requests the cluster to be reconciled again after
+	// the given duration.
+	RequeueAfter *duration.Duration `protobuf:"bytes,1,opt,name=requeue_after,json=requeueAfter,proto3" json:"requeue_after,omitempty"`
+	// error, if set, is a terminal error which requires user intervention.
+	Error *TerminalError `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// provider_status, if set, replaces the ProviderStatus of the cluster. It is
+	// encoded as JSON.
+	ProviderStatus []byte `protobuf:"bytes,3,opt,name=provider_status,json=providerStatus,proto3" json:"provider_status,omitempty"`
+	// api_endpoints, if set, replaces the APIEndpoints of the cluster.
+	ApiEndpoints         []*APIEndpoint `protobuf:"bytes,4,rep,name=api_endpoints,json=apiEndpoints,proto3" json:"api_endpoints,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *ClusterResponse) Reset()         { *m = ClusterResponse{} }
+func (m *ClusterResponse) String() string { return proto.CompactTextString(m) }
+func (*ClusterResponse) ProtoMessage()    {}
+func (*ClusterResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aae82c28f504dca2, []int{5}
+}
+
+func (m *ClusterResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ClusterResponse.Unmarshal(m, b)
+}
+func (m *ClusterResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ClusterResponse.Marshal(b, m, deterministic)
+}
+func (m *ClusterResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ClusterResponse.Merge(m, src)
+}
+func (m *ClusterResponse) XXX_Size() int {
+	return xxx_messageInfo_ClusterResponse.Size(m)
+}
+func (m *ClusterResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ClusterResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ClusterResponse proto.InternalMessageInfo
+
+func (m *ClusterResponse) GetRequeueAfter() *duration.Duration {
+	if m != nil {
+		return m.RequeueAfter
+	}
+	return nil
+}
+
+func (m *ClusterResponse) GetError() *TerminalError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+func (m *ClusterResponse) GetProviderStatus() []byte {
+	if m != nil {
+		return m.ProviderStatus
+	}
+	return nil
+}
+
+func (m *ClusterResponse) GetApiEndpoints() []*APIEndpoint {
+	if m != nil {
+		return m.ApiEndpoints
+	}
+	return nil
+}
+
+// APIEndpoint is an endpoint of the API server of a cluster.
+type APIEndpoint struct {
+	Host                 string   `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	Port                 int32    `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *APIEndpoint) Reset()         { *m = APIEndpoint{} }
+func (m *APIEndpoint) String() string { return proto.CompactTextString(m) }
+func (*APIEndpoint) ProtoMessage()    {}
+func (*APIEndpoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aae82c28f504dca2, []int{6}
+}
+
+func (m *APIEndpoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_APIEndpoint.Unmarshal(m, b)
+}
+func (m *APIEndpoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_APIEndpoint.Marshal(b, m, deterministic)
+}
+func (m *APIEndpoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_APIEndpoint.Merge(m, src)
+}
+func (m *APIEndpoint) XXX_Size() int {
+	return xxx_messageInfo_APIEndpoint.Size(m)
+}
+func (m *APIEndpoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_APIEndpoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_APIEndpoint proto.InternalMessageInfo
+
+func (m *APIEndpoint) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *APIEndpoint) GetPort() int32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+// TerminalError is a terminal error returned by an actuator. Its reason is one
+// of the MachineStatusError or ClusterStatusError values of cluster.k8s.io.
+type TerminalError struct {
+	Reason               string   `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TerminalError) Reset()         { *m = TerminalError{} }
+func (m *TerminalError) String() string { return proto.CompactTextString(m) }
+func (*TerminalError) ProtoMessage()    {}
+func (*TerminalError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aae82c28f504dca2, []int{7}
+}
+
+func (m *TerminalError) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TerminalError.Unmarshal(m, b)
+}
+func (m *TerminalError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TerminalError.Marshal(b, m, deterministic)
+}
+func (m *TerminalError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TerminalError.Merge(m, src)
+}
+func (m *TerminalError) XXX_Size() int {
+	return xxx_messageInfo_TerminalError.Size(m)
+}
+func (m *TerminalError) XXX_DiscardUnknown() {
+	xxx_messageInfo_TerminalError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TerminalError proto.InternalMessageInfo
+
+func (m *TerminalError) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *TerminalError) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*MachineRequest)(nil), "clusterapi.actuator.v1alpha1.MachineRequest")
+	proto.RegisterType((*MachineResponse)(nil), "clusterapi.actuator.v1alpha1.MachineResponse")
+	proto.RegisterType((*NodeAddress)(nil), "clusterapi.actuator.v1alpha1.NodeAddress")
+	proto.RegisterType((*ExistsResponse)(nil), "clusterapi.actuator.v1alpha1.ExistsResponse")
+	proto.RegisterType((*ClusterRequest)(nil), "clusterapi.actuator.v1alpha1.ClusterRequest")
+	proto.RegisterType((*ClusterResponse)(nil), "clusterapi.actuator.v1alpha1.ClusterResponse")
+	proto.RegisterType((*APIEndpoint)(nil), "clusterapi.actuator.v1alpha1.APIEndpoint")
+	proto.RegisterType((*TerminalError)(nil), "clusterapi.actuator.v1alpha1.TerminalError")
+}
+
+func init() {
+	proto.RegisterFile("pkg/actuator/v1alpha1/actuator.proto", fileDescriptor_aae82c28f504dca2)
+}
+
+var fileDescriptor_aae82c28f504dca2 = []byte{
+	// 518 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x54, 0xdd, 0x6a, 0x13, 0x41,
+	0x14, 0x6e, 0x92, 0x36, 0x36, 0x27, 0x7f, 0xb0, 0x17, 0x12, 0x83, 0x48, 0x59, 0x04, 0xe3, 0x4f,
+	0x37, 0x34, 0xe2, 0x95, 0x20, 0xac, 0x4d, 0x10, 0x2f, 0x2c, 0x32, 0xea, 0x8d, 0x37, 0x61, 0x9a,
+	0x3d, 0xd9, 0x8c, 0x6e, 0x77, 0xc6, 0x99, 0xd9, 0xa2, 0x2f, 0xe0, 0x9d, 0x6f, 0xe3, 0x33, 0xf9,
+	0x1c, 0x32, 0xb3, 0x33, 0x6b, 0x73, 0x93, 0xa4, 0x88, 0x42, 0xef, 0xf6, 0x9c, 0xf3, 0x9d, 0x9f,
+	0x6f, 0x3e, 0xbe, 0x85, 0xfb, 0xe2, 0x73, 0x3a, 0xa6, 0x0b, 0x5d, 0x50, 0xcd, 0xe5, 0xf8, 0xf2,
+	0x84, 0x66, 0x62, 0x45, 0x4f, 0xaa, 0x4c, 0x24, 0x24, 0xd7, 0x3c, 0xb8, 0xbb, 0xc8, 0x0a, 0xa5,
+	0x51, 0x52, 0xc1, 0xa2, 0xaa, 0xe4, 0xc1, 0xc3, 0x7b, 0x29, 0xe7, 0x69, 0x86, 0x63, 0x8b, 0x3d,
+	0x2f, 0x96, 0xe3, 0xa4, 0x90, 0x54, 0x33, 0x9e, 0x97, 0xdd, 0xe1, 0x14, 0x7a, 0x6f, 0xe8, 0x62,
+	0xc5, 0x72, 0x24, 0xf8, 0xa5, 0x40, 0xa5, 0x83, 0x01, 0xdc, 0x72, 0x13, 0x07, 0xb5, 0xa3, 0xda,
+	0xa8, 0x43, 0x7c, 0x68, 0x2a, 0x17, 0x25, 0x76, 0x50, 0x2f, 0x2b, 0x2e, 0x0c, 0xbf, 0xd7, 0xa1,
+	0x5f, 0x8d, 0x51, 0x82, 0xe7, 0x0a, 0x83, 0x17, 0xd0, 0x95, 0x66, 0x64, 0x81, 0x73, 0xba, 0xf4,
+	0xd3, 0xda, 0x93, 0x3b, 0x51, 0x79, 0x51, 0xe4, 0x2f, 0x8a, 0xa6, 0xee, 0x22, 0xd2, 0x71, 0xf8,
+	0xd8, 0xc0, 0x83, 0x18, 0x0e, 0x50, 0x4a, 0x2e, 0xed, 0xae, 0xf6, 0xe4, 0x71, 0xb4, 0x89, 0x67,
+	0xf4, 0x1e, 0xe5, 0x05, 0xcb, 0x69, 0x36, 0x33, 0x2d, 0xa4, 0xec, 0x0c, 0x1e, 0x40, 0x5f, 0x48,
+	0x7e, 0xc9, 0x12, 0x94, 0x73, 0xa5, 0xa9, 0x2e, 0xd4, 0xa0, 0x61, 0x0f, 0xef, 0xf9, 0xf4, 0x3b,
+	0x9b, 0x0d, 0x5e, 0x41, 0x8b, 0x26, 0x89, 0x44, 0xa5, 0x50, 0x0d, 0xf6, 0x8f, 0x1a, 0xa3, 0xf6,
+	0xe4, 0xe1, 0xe6, 0x7d, 0x67, 0x3c, 0xc1, 0xb8, 0x6c, 0x21, 0x7f, 0x7a, 0xc3, 0xe7, 0xd0, 0xbe,
+	0x52, 0x09, 0x02, 0xd8, 0xd7, 0xdf, 0x04, 0x5a, 0xea, 0x2d, 0x62, 0xbf, 0xcd, 0x2b, 0x3a, 0xbc,
+	0x65, 0xd6, 0x22, 0x3e, 0x0c, 0x47, 0xd0, 0x9b, 0x7d, 0x65, 0x4a, 0xab, 0xea, 0x0d, 0x6f, 0x43,
+	0x13, 0x6d, 0xc6, 0x4e, 0x38, 0x24, 0x2e, 0x0a, 0x1f, 0x41, 0xef, 0xb4, 0xbc, 0x6e, 0xab, 0x6a,
+	0xe1, 0x8f, 0x3a, 0xf4, 0x2b, 0xf0, 0x0d, 0xd4, 0xe6, 0x0c, 0xba, 0x54, 0xb0, 0x39, 0xe6, 0x89,
+	0xe0, 0x2c, 0xd7, 0x3b, 0xea, 0x13, 0xbf, 0x7d, 0x3d, 0x73, 0x1d, 0xa4, 0x43, 0x05, 0xf3, 0x81,
+	0x0a, 0x9f, 0x41, 0xfb, 0x4a, 0xd1, 0x48, 0xb4, 0xe2, 0x4a, 0x7b, 0x89, 0xcc, 0xb7, 0xc9, 0x09,
+	0x2e, 0xb5, 0x65, 0x77, 0x40, 0xec, 0x77, 0x18, 0x43, 0x77, 0x8d, 0x87, 0xd1, 0x46, 0x22, 0x55,
+	0x3c, 0x77, 0xad, 0x2e, 0xb2, 0x2e, 0x41, 0xa5, 0x68, 0x8a, 0x5e, 0x5f, 0x17, 0x4e, 0x7e, 0x36,
+	0x2a, 0x97, 0xc4, 0xee, 0xe0, 0x20, 0x85, 0xe6, 0xa9, 0x44, 0xaa, 0x31, 0x78, 0xb2, 0x99, 0xd0,
+	0xba, 0x4b, 0x87, 0xc7, 0x3b, 0xa2, 0x4b, 0xc1, 0xc3, 0x3d, 0xb3, 0xe8, 0x83, 0x48, 0xfe, 0xcf,
+	0xa2, 0x29, 0x66, 0xf8, 0xef, 0x17, 0x2d, 0xa1, 0x59, 0xda, 0xe5, 0x9a, 0x8b, 0xb6, 0xa0, 0xd7,
+	0x2d, 0x18, 0xee, 0x4d, 0x7e, 0xd5, 0x2a, 0x03, 0x55, 0xb2, 0x7d, 0x82, 0x16, 0xc1, 0x05, 0xcf,
+	0x17, 0x2c, 0xdb, 0xca, 0x73, 0xdd, 0xa9, 0xc3, 0xe3, 0x1d, 0xd1, 0xd7, 0x7f, 0xd0, 0xbf, 0x5c,
+	0xf4, 0x12, 0x3e, 0x1e, 0xfa, 0xea, 0x79, 0xd3, 0xfe, 0x02, 0x9e, 0xfe, 0x1e, 0x00, 0xb9, 0x3c,
+	0x8a, 0xbc, 0x84, 0x06, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// MachineActuatorClient is the client API for MachineActuator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MachineActuatorClient interface {
+	// Create the machine.
+	Create(ctx context.Context, in *MachineRequest, opts ...grpc.CallOption) (*MachineResponse, error)
+	// Update the machine to the provided definition.
+	Update(ctx context.Context, in *MachineRequest, opts ...grpc.CallOption) (*MachineResponse, error)
+	// Delete the machine.
+	Delete(ctx context.Context, in *MachineRequest, opts ...grpc.CallOption) (*MachineResponse, error)
+	// Exists checks if the machine currently exists.
+	Exists(ctx context.Context, in *MachineRequest, opts ...grpc.CallOption) (*ExistsResponse, error)
+}
+
+type machineActuatorClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMachineActuatorClient(cc *grpc.ClientConn) MachineActuatorClient {
+	return &machineActuatorClient{cc}
+}
+
+func (c *machineActuatorClient) Create(ctx context.Context, in *MachineRequest, opts ...grpc.CallOption) (*MachineResponse, error) {
+	out := new(MachineResponse)
+	err := c.cc.Invoke(ctx, "/clusterapi.actuator.v1alpha1.MachineActuator/Create", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineActuatorClient) Update(ctx context.Context, in *MachineRequest, opts ...grpc.CallOption) (*MachineResponse, error) {
+	out := new(MachineResponse)
+	err := c.cc.Invoke(ctx, "/clusterapi.actuator.v1alpha1.MachineActuator/Update", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineActuatorClient) Delete(ctx context.Context, in *MachineRequest, opts ...grpc.CallOption) (*MachineResponse, error) {
+	out := new(MachineResponse)
+	err := c.cc.Invoke(ctx, "/clusterapi.actuator.v1alpha1.MachineActuator/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *machineActuatorClient) Exists(ctx context.Context, in *MachineRequest, opts ...grpc.CallOption) (*ExistsResponse, error) {
+	out := new(ExistsResponse)
+	err := c.cc.Invoke(ctx, "/clusterapi.actuator.v1alpha1.MachineActuator/Exists", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MachineActuatorServer is the server API for MachineActuator service.
+type MachineActuatorServer interface {
+	// Create the machine.
+	Create(context.Context, *MachineRequest) (*MachineResponse, error)
+	// Update the machine to the provided definition.
+	Update(context.Context, *MachineRequest) (*MachineResponse, error)
+	// Delete the machine.
+	Delete(context.Context, *MachineRequest) (*MachineResponse, error)
+	// Exists checks if the machine currently exists.
+	Exists(context.Context, *MachineRequest) (*ExistsResponse, error)
+}
+
+// UnimplementedMachineActuatorServer can be embedded to have forward compatible implementations.
+type UnimplementedMachineActuatorServer struct {
+}
+
+func (*UnimplementedMachineActuatorServer) Create(ctx context.Context, req *MachineRequest) (*MachineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (*UnimplementedMachineActuatorServer) Update(ctx context.Context, req *MachineRequest) (*MachineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (*UnimplementedMachineActuatorServer) Delete(ctx context.Context, req *MachineRequest) (*MachineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (*UnimplementedMachineActuatorServer) Exists(ctx context.Context, req *MachineRequest) (*ExistsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Exists not implemented")
+}
+
+func RegisterMachineActuatorServer(s *grpc.Server, srv MachineActuatorServer) {
+	s.RegisterService(&_MachineActuator_serviceDesc, srv)
+}
+
+func _MachineActuator_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MachineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineActuatorServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterapi.actuator.v1alpha1.MachineActuator/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineActuatorServer).Create(ctx, req.(*MachineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineActuator_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MachineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineActuatorServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterapi.actuator.v1alpha1.MachineActuator/Update",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineActuatorServer).Update(ctx, req.(*MachineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineActuator_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MachineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineActuatorServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterapi.actuator.v1alpha1.MachineActuator/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineActuatorServer).Delete(ctx, req.(*MachineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MachineActuator_Exists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MachineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MachineActuatorServer).Exists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterapi.actuator.v1alpha1.MachineActuator/Exists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MachineActuatorServer).Exists(ctx, req.(*MachineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _MachineActuator_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "clusterapi.actuator.v1alpha1.MachineActuator",
+	HandlerType: (*MachineActuatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    _MachineActuator_Create_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _MachineActuator_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _MachineActuator_Delete_Handler,
+		},
+		{
+			MethodName: "Exists",
+			Handler:    _MachineActuator_Exists_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/actuator/v1alpha1/actuator.proto",
+}
+
+// ClusterActuatorClient is the client API for ClusterActuator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ClusterActuatorClient interface {
+	// Reconcile creates or applies updates to the cluster.
+	Reconcile(ctx context.Context, in *ClusterRequest, opts ...grpc.CallOption) (*ClusterResponse, error)
+	// Delete the cluster.
+	Delete(ctx context.Context, in *ClusterRequest, opts ...grpc.CallOption) (*ClusterResponse, error)
+}
+
+type clusterActuatorClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewClusterActuatorClient(cc *grpc.ClientConn) ClusterActuatorClient {
+	return &clusterActuatorClient{cc}
+}
+
+func (c *clusterActuatorClient) Reconcile(ctx context.Context, in *ClusterRequest, opts ...grpc.CallOption) (*ClusterResponse, error) {
+	out := new(ClusterResponse)
+	err := c.cc.Invoke(ctx, "/clusterapi.actuator.v1alpha1.ClusterActuator/Reconcile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterActuatorClient) Delete(ctx context.Context, in *ClusterRequest, opts ...grpc.CallOption) (*ClusterResponse, error) {
+	out := new(ClusterResponse)
+	err := c.cc.Invoke(ctx, "/clusterapi.actuator.v1alpha1.ClusterActuator/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ClusterActuatorServer is the server API for ClusterActuator service.
+type ClusterActuatorServer interface {
+	// Reconcile creates or applies updates to the cluster.
+	Reconcile(context.Context, *ClusterRequest) (*ClusterResponse, error)
+	// Delete the cluster.
+	Delete(context.Context, *ClusterRequest) (*ClusterResponse, error)
+}
+
+// UnimplementedClusterActuatorServer can be embedded to have forward compatible implementations.
+type UnimplementedClusterActuatorServer struct {
+}
+
+func (*UnimplementedClusterActuatorServer) Reconcile(ctx context.Context, req *ClusterRequest) (*ClusterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reconcile not implemented")
+}
+func (*UnimplementedClusterActuatorServer) Delete(ctx context.Context, req *ClusterRequest) (*ClusterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+
+func RegisterClusterActuatorServer(s *grpc.Server, srv ClusterActuatorServer) {
+	s.RegisterService(&_ClusterActuator_serviceDesc, srv)
+}
+
+func _ClusterActuator_Reconcile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterActuatorServer).Reconcile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterapi.actuator.v1alpha1.ClusterActuator/Reconcile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterActuatorServer).Reconcile(ctx, req.(*ClusterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClusterActuator_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterActuatorServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/clusterapi.actuator.v1alpha1.ClusterActuator/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterActuatorServer).Delete(ctx, req.(*ClusterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ClusterActuator_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "clusterapi.actuator.v1alpha1.ClusterActuator",
+	HandlerType: (*ClusterActuatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Reconcile",
+			Handler:    _ClusterActuator_Reconcile_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _ClusterActuator_Delete_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/actuator/v1alpha1/actuator.proto",
+}
@@ -70,10 +70,10 @@ const (
 	// as a new node within the expected timeframe after instance
 	// creation at the provider succeeded
 	//
-	// Example use case: A controller that deletes Machines which do
-	// not result in a Node joining the cluster within a given timeout
-	// and that are managed by a MachineSet
-	JoinClusterTimeoutMachineError = "JoinClusterTimeoutError"
+	// Example: the machine controller reports this error for Machines
+	// which are not linked to a Node within the timeout set by their
+	// "cluster.k8s.io/node-startup-timeout" annotation.
+	JoinClusterTimeoutMachineError MachineStatusError = "JoinClusterTimeoutError"
 )
 
 type ClusterStatusError string
@@ -118,4 +118,45 @@ const (
 	// Replace the old MachineSet by new one using rolling update
 	// i.e. gradually scale down the old MachineSet and scale up the new one.
 	RollingUpdateMachineDeploymentStrategyType MachineDeploymentStrategyType = "RollingUpdate"
+
+	// Kill all existing machines before creating new ones, i.e. scale down
+	// all old MachineSets to zero and wait for their Machines to be deleted
+	// before scaling up the new one.
+	RecreateMachineDeploymentStrategyType MachineDeploymentStrategyType = "Recreate"
+)
+
+type MachineDeploymentConditionType string
+
+const (
+	// Available means the deployment is available, ie. at least the minimum available
+	// replicas required are up and running for at least minReadySeconds.
+	MachineDeploymentAvailable MachineDeploymentConditionType = "Available"
+
+	// Progressing means the deployment is progressing. Progress for a deployment is
+	// considered when a new machine set is created or adopted, and when new machines scale
+	// up or old machines scale down. Progress is not estimated for paused deployments or
+	// when progressDeadlineSeconds is not specified.
+	MachineDeploymentProgressing MachineDeploymentConditionType = "Progressing"
+
+	// ReplicaFailure is added in a deployment when one of its machine sets fails to be
+	// created or when one of its machine sets reports an error.
+	MachineDeploymentReplicaFailure MachineDeploymentConditionType = "ReplicaFailure"
+)
+
+type ClusterConditionType string
+
+const (
+	// InfrastructureReady means the cluster actuator reconciled the cluster's
+	// infrastructure successfully.
+	ClusterInfrastructureReady ClusterConditionType = "InfrastructureReady"
+
+	// ControlPlaneReady means at least one of the control plane machines of the
+	// cluster has been linked to a node.
+	ClusterControlPlaneReady ClusterConditionType = "ControlPlaneReady"
+
+	// APIEndpointAvailable means the cluster reports at least one API endpoint.
+	ClusterAPIEndpointAvailable ClusterConditionType = "APIEndpointAvailable"
+
+	// Deleting means the cluster is being deleted.
+	ClusterDeleting ClusterConditionType = "Deleting"
 )
@@ -0,0 +1,55 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DefaultServiceDomain is the service domain used for clusters that don't specify one.
+const DefaultServiceDomain = "cluster.local"
+
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-k8s-io-v1alpha1-cluster,mutating=true,failurePolicy=fail,groups=cluster.k8s.io,resources=clusters,versions=v1alpha1,name=default.cluster.cluster.k8s.io
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-k8s-io-v1alpha1-cluster,mutating=false,failurePolicy=fail,groups=cluster.k8s.io,resources=clusters,versions=v1alpha1,name=validation.cluster.cluster.k8s.io
+
+// Default sets default Cluster field values.
+func (o *Cluster) Default() {
+	if o.Spec.ClusterNetwork.ServiceDomain == "" {
+		o.Spec.ClusterNetwork.ServiceDomain = DefaultServiceDomain
+	}
+}
+
+// ValidateCreate implements webhook.Validator.
+func (o *Cluster) ValidateCreate() error {
+	return toInvalidError("Cluster", o.Name, o.Validate())
+}
+
+// ValidateUpdate implements webhook.Validator. The cluster network can't be changed
+// once the cluster has been created.
+func (o *Cluster) ValidateUpdate(old runtime.Object) error {
+	oldCluster := old.(*Cluster)
+	errs := o.Validate()
+	errs = append(errs, apivalidation.ValidateImmutableField(o.Spec.ClusterNetwork, oldCluster.Spec.ClusterNetwork, field.NewPath("spec", "clusterNetwork"))...)
+	return toInvalidError("Cluster", o.Name, errs)
+}
+
+// ValidateDelete implements webhook.Validator.
+func (o *Cluster) ValidateDelete() error {
+	return nil
+}
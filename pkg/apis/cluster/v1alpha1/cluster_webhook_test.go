@@ -0,0 +1,55 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+)
+
+func newWebhookTestCluster() *Cluster {
+	c := &Cluster{}
+	c.Spec.ClusterNetwork.Pods.CIDRBlocks = []string{"192.168.0.0/16"}
+	c.Spec.ClusterNetwork.Services.CIDRBlocks = []string{"10.96.0.0/12"}
+	c.Default()
+	return c
+}
+
+func TestClusterDefault(t *testing.T) {
+	c := newWebhookTestCluster()
+	if c.Spec.ClusterNetwork.ServiceDomain != DefaultServiceDomain {
+		t.Errorf("expected service domain %q, got %q", DefaultServiceDomain, c.Spec.ClusterNetwork.ServiceDomain)
+	}
+}
+
+func TestClusterValidateCreate(t *testing.T) {
+	if err := newWebhookTestCluster().ValidateCreate(); err != nil {
+		t.Errorf("expected cluster to be valid, got %v", err)
+	}
+	if err := (&Cluster{}).ValidateCreate(); err == nil {
+		t.Error("expected an error for a cluster without a cluster network")
+	}
+}
+
+func TestClusterValidateUpdate(t *testing.T) {
+	oldCluster := newWebhookTestCluster()
+
+	changed := oldCluster.DeepCopy()
+	changed.Spec.ClusterNetwork.Pods.CIDRBlocks = []string{"10.244.0.0/16"}
+	if err := changed.ValidateUpdate(oldCluster); err == nil {
+		t.Error("expected an error when changing the cluster network")
+	}
+}
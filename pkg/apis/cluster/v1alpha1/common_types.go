@@ -21,6 +21,11 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// PausedAnnotation is set on clusters, machine deployments, machine sets and machines to stop the controllers from
+// reconciling them, for example while clusterctl moves them to another management cluster. The node controller
+// doesn't update the status of paused machines either. Its value is ignored by the controllers.
+const PausedAnnotation = "cluster.k8s.io/paused"
+
 // ProviderSpec defines the configuration to use during node creation.
 type ProviderSpec struct {
 
@@ -0,0 +1,112 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+// MachinePhase is a string representation of a Machine Phase.
+//
+// This type is a high-level indicator of the status of the Machine as it is provisioned,
+// from the API user’s perspective.
+//
+// The value should not be interpreted by any software components as a reliable indication
+// of the actual state of the Machine, and controllers should not use the Machine Phase field
+// value when making decisions about what action to take.
+//
+// Controllers should always look at the actual state of the Machine’s fields to make those decisions.
+type MachinePhase string
+
+var (
+	// MachinePhasePending is the first state a Machine is assigned by
+	// the Machine controller after being created.
+	MachinePhasePending = MachinePhase("Pending")
+
+	// MachinePhaseProvisioning is the state when the actuator has been asked
+	// to create the Machine's infrastructure, which doesn't exist yet.
+	MachinePhaseProvisioning = MachinePhase("Provisioning")
+
+	// MachinePhaseProvisioned is the state when the Machine's infrastructure
+	// exists, but it hasn't been linked to a Node yet or the Node isn't Ready.
+	MachinePhaseProvisioned = MachinePhase("Provisioned")
+
+	// MachinePhaseRunning is the Machine state when it has
+	// become a Kubernetes Node in a Ready state.
+	MachinePhaseRunning = MachinePhase("Running")
+
+	// MachinePhaseDeleting is the Machine state when a delete
+	// request has been sent to the API Server,
+	// but its infrastructure has not yet been fully deleted.
+	MachinePhaseDeleting = MachinePhase("Deleting")
+
+	// MachinePhaseFailed is the Machine state when the system
+	// might require user intervention, see Status.ErrorReason
+	// and Status.ErrorMessage.
+	MachinePhaseFailed = MachinePhase("Failed")
+)
+
+// MachineOperationType is the type of an operation performed on a Machine by the
+// Machine controller, as recorded in Status.LastOperation.
+type MachineOperationType string
+
+const (
+	// MachineOperationCreate is the operation creating the Machine's infrastructure.
+	MachineOperationCreate MachineOperationType = "Create"
+
+	// MachineOperationUpdate is the operation updating the Machine's infrastructure.
+	MachineOperationUpdate MachineOperationType = "Update"
+
+	// MachineOperationDelete is the operation deleting the Machine's infrastructure.
+	MachineOperationDelete MachineOperationType = "Delete"
+)
+
+// MachineOperationState is the state of the last operation performed on a Machine.
+type MachineOperationState string
+
+const (
+	// MachineOperationProcessing means that the operation is still in progress.
+	MachineOperationProcessing MachineOperationState = "Processing"
+
+	// MachineOperationSuccessful means that the operation completed successfully.
+	MachineOperationSuccessful MachineOperationState = "Successful"
+
+	// MachineOperationFailed means that the operation returned an error.
+	MachineOperationFailed MachineOperationState = "Failed"
+)
+
+// SetTypedPhase sets the Phase field to the string representation of MachinePhase.
+func (m *MachineStatus) SetTypedPhase(p MachinePhase) {
+	phase := string(p)
+	m.Phase = &phase
+}
+
+// GetTypedPhase attempts to parse the Phase field and return
+// the typed MachinePhase representation as described in `machine_phase_types.go`.
+func (m *MachineStatus) GetTypedPhase() MachinePhase {
+	if m.Phase == nil {
+		return ""
+	}
+	switch phase := MachinePhase(*m.Phase); phase {
+	case
+		MachinePhasePending,
+		MachinePhaseProvisioning,
+		MachinePhaseProvisioned,
+		MachinePhaseRunning,
+		MachinePhaseDeleting,
+		MachinePhaseFailed:
+		return phase
+	default:
+		return ""
+	}
+}
@@ -29,6 +29,23 @@ const (
 
 	// MachineClusterLabelName is the label set on machines linked to a cluster.
 	MachineClusterLabelName = "cluster.k8s.io/cluster-name"
+
+	// ProviderLabelName is set on clusters and machines to select the actuator reconciling them, when the
+	// controllers of several providers run in the same manager. The provider of the MachineClass of a
+	// machine is used if it is not set.
+	ProviderLabelName = "cluster.k8s.io/provider"
+
+	// ExcludeNodeDrainingAnnotation is set on machines whose node must not be drained before the machine is deleted.
+	ExcludeNodeDrainingAnnotation = "cluster.k8s.io/exclude-node-draining"
+
+	// NodeDrainTimeoutAnnotation is set on machines to limit how long the machine controller tries to drain the node
+	// before the machine is deleted, for example "10m". The node is drained without a time limit if it is not set.
+	NodeDrainTimeoutAnnotation = "cluster.k8s.io/drain-timeout"
+
+	// NodeStartupTimeoutAnnotation is set on machines to limit how long the machine controller waits for the machine
+	// to be linked to a node after it was created, for example "20m". Once it elapses, the machine status reports a
+	// JoinClusterTimeoutError so that the machine is replaced. Machines wait for their node forever if it is not set.
+	NodeStartupTimeoutAnnotation = "cluster.k8s.io/node-startup-timeout"
 )
 
 // +genclient
@@ -39,7 +56,7 @@ const (
 // +k8s:openapi-gen=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="ProviderID",type="string",JSONPath=".spec.providerID",description="Provider ID"
-// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Machine status such as Terminating/Pending/Running/Failed etc"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Machine status such as Pending/Provisioning/Running/Deleting/Failed etc"
 // +kubebuilder:printcolumn:name="NodeName",type="string",JSONPath=".status.nodeRef.name",description="Node name associated with this machine",priority=1
 type Machine struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -198,7 +215,7 @@ type MachineStatus struct {
 	LastOperation *LastOperation `json:"lastOperation,omitempty"`
 
 	// Phase represents the current phase of machine actuation.
-	// E.g. Pending, Running, Terminating, Failed etc.
+	// One of Pending, Provisioning, Provisioned, Running, Deleting or Failed.
 	// +optional
 	Phase *string `json:"phase,omitempty"`
 }
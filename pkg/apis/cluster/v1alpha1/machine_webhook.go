@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-k8s-io-v1alpha1-machine,mutating=false,failurePolicy=fail,groups=cluster.k8s.io,resources=machines,versions=v1alpha1,name=validation.machine.cluster.k8s.io
+
+// ValidateCreate implements webhook.Validator.
+func (m *Machine) ValidateCreate() error {
+	return toInvalidError("Machine", m.Name, m.validate())
+}
+
+// ValidateUpdate implements webhook.Validator. The provider ID and the cluster a
+// Machine belongs to can't be changed once they have been set.
+func (m *Machine) ValidateUpdate(old runtime.Object) error {
+	oldMachine := old.(*Machine)
+	errs := m.validate()
+
+	if oldMachine.Spec.ProviderID != nil {
+		errs = append(errs, apivalidation.ValidateImmutableField(m.Spec.ProviderID, oldMachine.Spec.ProviderID, field.NewPath("spec", "providerID"))...)
+	}
+	if oldCluster, ok := oldMachine.Labels[MachineClusterLabelName]; ok {
+		errs = append(errs, apivalidation.ValidateImmutableField(m.Labels[MachineClusterLabelName], oldCluster, field.NewPath("metadata", "labels").Key(MachineClusterLabelName))...)
+	}
+
+	return toInvalidError("Machine", m.Name, errs)
+}
+
+// ValidateDelete implements webhook.Validator.
+func (m *Machine) ValidateDelete() error {
+	return nil
+}
+
+func (m *Machine) validate() field.ErrorList {
+	return validateProviderSpec(&m.Spec.ProviderSpec, field.NewPath("spec", "providerSpec"))
+}
+
+// validateProviderSpec checks that no more than one source is set for the provider configuration.
+func validateProviderSpec(spec *ProviderSpec, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+	if spec.Value != nil && spec.ValueFrom != nil {
+		errs = append(errs, field.Forbidden(fldPath.Child("valueFrom"), "cannot be set when value is set"))
+	}
+	return errs
+}
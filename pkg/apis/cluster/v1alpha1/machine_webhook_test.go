@@ -0,0 +1,78 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMachineValidateCreate(t *testing.T) {
+	m := &Machine{}
+	m.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: []byte("{}")}
+	if err := m.ValidateCreate(); err != nil {
+		t.Errorf("expected machine to be valid, got %v", err)
+	}
+
+	m.Spec.ProviderSpec.ValueFrom = &ProviderSpecSource{MachineClass: &MachineClassRef{}}
+	if err := m.ValidateCreate(); err == nil {
+		t.Error("expected an error for a machine with both value and valueFrom set")
+	}
+}
+
+func TestMachineValidateUpdate(t *testing.T) {
+	providerID := "aws:///us-east-1a/i-1234"
+	otherProviderID := "aws:///us-east-1a/i-5678"
+
+	testcases := []struct {
+		name        string
+		oldID       *string
+		newID       *string
+		oldCluster  string
+		newCluster  string
+		expectedErr bool
+	}{
+		{name: "set provider id", newID: &providerID},
+		{name: "unchanged provider id", oldID: &providerID, newID: &providerID},
+		{name: "changed provider id", oldID: &providerID, newID: &otherProviderID, expectedErr: true},
+		{name: "removed provider id", oldID: &providerID, expectedErr: true},
+		{name: "set cluster", newCluster: "foo"},
+		{name: "unchanged cluster", oldCluster: "foo", newCluster: "foo"},
+		{name: "changed cluster", oldCluster: "foo", newCluster: "bar", expectedErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			oldMachine := &Machine{}
+			oldMachine.Spec.ProviderID = tc.oldID
+			if tc.oldCluster != "" {
+				oldMachine.Labels = map[string]string{MachineClusterLabelName: tc.oldCluster}
+			}
+			newMachine := &Machine{}
+			newMachine.Spec.ProviderID = tc.newID
+			if tc.newCluster != "" {
+				newMachine.Labels = map[string]string{MachineClusterLabelName: tc.newCluster}
+			}
+
+			err := newMachine.ValidateUpdate(oldMachine)
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
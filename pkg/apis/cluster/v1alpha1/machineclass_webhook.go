@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-k8s-io-v1alpha1-machineclass,mutating=false,failurePolicy=fail,groups=cluster.k8s.io,resources=machineclasses,versions=v1alpha1,name=validation.machineclass.cluster.k8s.io
+
+// ValidateCreate implements webhook.Validator.
+func (m *MachineClass) ValidateCreate() error {
+	return toInvalidError("MachineClass", m.Name, m.validate())
+}
+
+// ValidateUpdate implements webhook.Validator.
+func (m *MachineClass) ValidateUpdate(old runtime.Object) error {
+	return toInvalidError("MachineClass", m.Name, m.validate())
+}
+
+// ValidateDelete implements webhook.Validator.
+func (m *MachineClass) ValidateDelete() error {
+	return nil
+}
+
+func (m *MachineClass) validate() field.ErrorList {
+	errs := field.ErrorList{}
+	if len(m.ProviderSpec.Raw) == 0 && m.ProviderSpec.Object == nil {
+		errs = append(errs, field.Required(field.NewPath("providerSpec"), "provider configuration is required"))
+	}
+	return errs
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-k8s-io-v1alpha1-machinedeployment,mutating=true,failurePolicy=fail,groups=cluster.k8s.io,resources=machinedeployments,versions=v1alpha1,name=default.machinedeployment.cluster.k8s.io
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-k8s-io-v1alpha1-machinedeployment,mutating=false,failurePolicy=fail,groups=cluster.k8s.io,resources=machinedeployments,versions=v1alpha1,name=validation.machinedeployment.cluster.k8s.io
+
+// Default implements webhook.Defaulter.
+func (m *MachineDeployment) Default() {
+	PopulateDefaultsMachineDeployment(m)
+}
+
+// ValidateCreate implements webhook.Validator.
+func (m *MachineDeployment) ValidateCreate() error {
+	return toInvalidError("MachineDeployment", m.Name, m.Validate())
+}
+
+// ValidateUpdate implements webhook.Validator. The selector can't be changed once
+// the MachineDeployment has been created, as it would orphan its MachineSets.
+func (m *MachineDeployment) ValidateUpdate(old runtime.Object) error {
+	oldMachineDeployment := old.(*MachineDeployment)
+	errs := m.Validate()
+	errs = append(errs, apivalidation.ValidateImmutableField(m.Spec.Selector, oldMachineDeployment.Spec.Selector, field.NewPath("spec", "selector"))...)
+	return toInvalidError("MachineDeployment", m.Name, errs)
+}
+
+// ValidateDelete implements webhook.Validator.
+func (m *MachineDeployment) ValidateDelete() error {
+	return nil
+}
+
+// Validate checks the MachineDeployment spec for errors.
+func (m *MachineDeployment) Validate() field.ErrorList {
+	errs := field.ErrorList{}
+	fldPath := field.NewPath("spec")
+
+	// validate spec.selector and spec.template.labels
+	errs = append(errs, metav1validation.ValidateLabelSelector(&m.Spec.Selector, fldPath.Child("selector"))...)
+	if len(m.Spec.Selector.MatchLabels)+len(m.Spec.Selector.MatchExpressions) == 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("selector"), m.Spec.Selector, "empty selector is not valid for MachineDeployment."))
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&m.Spec.Selector)
+	if err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("selector"), m.Spec.Selector, "invalid label selector."))
+	} else if !selector.Matches(labels.Set(m.Spec.Template.Labels)) {
+		errs = append(errs, field.Invalid(fldPath.Child("template", "metadata", "labels"), m.Spec.Template.Labels, "`selector` does not match template `labels`"))
+	}
+
+	if m.Spec.Replicas != nil && *m.Spec.Replicas < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("replicas"), *m.Spec.Replicas, "must be greater than or equal to 0"))
+	}
+	if m.Spec.MinReadySeconds != nil && *m.Spec.MinReadySeconds < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("minReadySeconds"), *m.Spec.MinReadySeconds, "must be greater than or equal to 0"))
+	}
+	if m.Spec.RevisionHistoryLimit != nil && *m.Spec.RevisionHistoryLimit < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("revisionHistoryLimit"), *m.Spec.RevisionHistoryLimit, "must be greater than or equal to 0"))
+	}
+	if m.Spec.ProgressDeadlineSeconds != nil {
+		if *m.Spec.ProgressDeadlineSeconds <= 0 {
+			errs = append(errs, field.Invalid(fldPath.Child("progressDeadlineSeconds"), *m.Spec.ProgressDeadlineSeconds, "must be greater than 0"))
+		} else if m.Spec.MinReadySeconds != nil && *m.Spec.ProgressDeadlineSeconds <= *m.Spec.MinReadySeconds {
+			errs = append(errs, field.Invalid(fldPath.Child("progressDeadlineSeconds"), *m.Spec.ProgressDeadlineSeconds, "must be greater than minReadySeconds"))
+		}
+	}
+	if m.Spec.RollbackTo != nil && m.Spec.RollbackTo.Revision < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("rollbackTo", "revision"), m.Spec.RollbackTo.Revision, "must be greater than or equal to 0"))
+	}
+
+	if m.Spec.Strategy != nil {
+		errs = append(errs, validateMachineDeploymentStrategy(m.Spec.Strategy, fldPath.Child("strategy"))...)
+	}
+
+	errs = append(errs, validateProviderSpec(&m.Spec.Template.Spec.ProviderSpec, fldPath.Child("template", "spec", "providerSpec"))...)
+
+	return errs
+}
+
+func validateMachineDeploymentStrategy(strategy *MachineDeploymentStrategy, fldPath *field.Path) field.ErrorList {
+	errs := field.ErrorList{}
+
+	switch strategy.Type {
+	case "", common.RollingUpdateMachineDeploymentStrategyType:
+	case common.RecreateMachineDeploymentStrategyType:
+		if strategy.RollingUpdate != nil {
+			errs = append(errs, field.Forbidden(fldPath.Child("rollingUpdate"), "may not be specified when strategy `type` is 'Recreate'"))
+		}
+		return errs
+	default:
+		validValues := []string{string(common.RollingUpdateMachineDeploymentStrategyType), string(common.RecreateMachineDeploymentStrategyType)}
+		return append(errs, field.NotSupported(fldPath.Child("type"), strategy.Type, validValues))
+	}
+
+	if strategy.RollingUpdate == nil {
+		return errs
+	}
+
+	fldPath = fldPath.Child("rollingUpdate")
+	maxSurge, err := intOrPercentValue(strategy.RollingUpdate.MaxSurge)
+	if err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("maxSurge"), strategy.RollingUpdate.MaxSurge.String(), "must be an integer or a percentage"))
+	}
+	maxUnavailable, err := intOrPercentValue(strategy.RollingUpdate.MaxUnavailable)
+	if err != nil {
+		errs = append(errs, field.Invalid(fldPath.Child("maxUnavailable"), strategy.RollingUpdate.MaxUnavailable.String(), "must be an integer or a percentage"))
+	}
+	if maxSurge < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("maxSurge"), strategy.RollingUpdate.MaxSurge.String(), "must be greater than or equal to 0"))
+	}
+	if maxUnavailable < 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("maxUnavailable"), strategy.RollingUpdate.MaxUnavailable.String(), "must be greater than or equal to 0"))
+	}
+	if strategy.RollingUpdate.MaxSurge != nil && strategy.RollingUpdate.MaxUnavailable != nil && maxSurge == 0 && maxUnavailable == 0 {
+		errs = append(errs, field.Invalid(fldPath.Child("maxUnavailable"), strategy.RollingUpdate.MaxUnavailable.String(), "may not be 0 when `maxSurge` is 0"))
+	}
+
+	return errs
+}
+
+// intOrPercentValue returns the value of an int or percentage field, scaled against
+// 100 so that percentages can be compared with 0 too.
+func intOrPercentValue(v *intstr.IntOrString) (int, error) {
+	if v == nil {
+		return 0, nil
+	}
+	return intstr.GetValueFromIntOrPercent(v, 100, true)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+)
+
+func newWebhookTestMachineDeployment() *MachineDeployment {
+	md := &MachineDeployment{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	md.Spec.Selector.MatchLabels = map[string]string{"foo": "bar"}
+	md.Spec.Template.Labels = map[string]string{"foo": "bar"}
+	md.Default()
+	return md
+}
+
+func TestMachineDeploymentDefault(t *testing.T) {
+	md := newWebhookTestMachineDeployment()
+
+	if md.Namespace != metav1.NamespaceDefault {
+		t.Errorf("expected namespace %q, got %q", metav1.NamespaceDefault, md.Namespace)
+	}
+	if md.Spec.Strategy == nil || md.Spec.Strategy.Type != common.RollingUpdateMachineDeploymentStrategyType {
+		t.Errorf("expected strategy %q, got %+v", common.RollingUpdateMachineDeploymentStrategyType, md.Spec.Strategy)
+	}
+	if md.Spec.Strategy.RollingUpdate == nil || md.Spec.Strategy.RollingUpdate.MaxSurge == nil || md.Spec.Strategy.RollingUpdate.MaxUnavailable == nil {
+		t.Errorf("expected rolling update parameters to be defaulted, got %+v", md.Spec.Strategy.RollingUpdate)
+	}
+}
+
+func TestMachineDeploymentValidateCreate(t *testing.T) {
+	zero := intstr.FromInt(0)
+	negative := int32(-1)
+
+	testcases := []struct {
+		name        string
+		mutate      func(md *MachineDeployment)
+		expectedErr bool
+	}{
+		{
+			name:   "defaulted",
+			mutate: func(md *MachineDeployment) {},
+		},
+		{
+			name:        "selector not matching template labels",
+			mutate:      func(md *MachineDeployment) { md.Spec.Template.Labels = map[string]string{"foo": "baz"} },
+			expectedErr: true,
+		},
+		{
+			name:        "negative replicas",
+			mutate:      func(md *MachineDeployment) { md.Spec.Replicas = &negative },
+			expectedErr: true,
+		},
+		{
+			name:        "unknown strategy",
+			mutate:      func(md *MachineDeployment) { md.Spec.Strategy.Type = "Unknown" },
+			expectedErr: true,
+		},
+		{
+			name:        "rolling update parameters with recreate strategy",
+			mutate:      func(md *MachineDeployment) { md.Spec.Strategy.Type = common.RecreateMachineDeploymentStrategyType },
+			expectedErr: true,
+		},
+		{
+			name: "zero maxSurge and maxUnavailable",
+			mutate: func(md *MachineDeployment) {
+				md.Spec.Strategy.RollingUpdate.MaxSurge = &zero
+				md.Spec.Strategy.RollingUpdate.MaxUnavailable = &zero
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid maxUnavailable",
+			mutate: func(md *MachineDeployment) {
+				invalid := intstr.FromString("foo")
+				md.Spec.Strategy.RollingUpdate.MaxUnavailable = &invalid
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			md := newWebhookTestMachineDeployment()
+			tc.mutate(md)
+			err := md.ValidateCreate()
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMachineDeploymentValidateUpdate(t *testing.T) {
+	oldMachineDeployment := newWebhookTestMachineDeployment()
+
+	changed := oldMachineDeployment.DeepCopy()
+	changed.Spec.Selector.MatchLabels = map[string]string{}
+	changed.Spec.Selector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "foo", Operator: metav1.LabelSelectorOpExists}}
+	if err := changed.ValidateUpdate(oldMachineDeployment); err == nil {
+		t.Error("expected an error when changing the selector")
+	}
+}
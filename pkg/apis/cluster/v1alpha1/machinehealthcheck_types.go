@@ -0,0 +1,144 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+/// [MachineHealthCheck]
+// MachineHealthCheck is the Schema for the machinehealthchecks API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="MaxUnhealthy",type="string",JSONPath=".spec.maxUnhealthy",description="Maximum number of unhealthy machines allowed"
+// +kubebuilder:printcolumn:name="ExpectedMachines",type="integer",JSONPath=".status.expectedMachines",description="Number of machines currently monitored"
+// +kubebuilder:printcolumn:name="CurrentHealthy",type="integer",JSONPath=".status.currentHealthy",description="Current observed healthy machines"
+type MachineHealthCheck struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Specification of machine health check policy
+	Spec MachineHealthCheckSpec `json:"spec,omitempty"`
+
+	// Most recently observed status of MachineHealthCheck resource
+	Status MachineHealthCheckStatus `json:"status,omitempty"`
+}
+
+/// [MachineHealthCheck]
+
+/// [MachineHealthCheckSpec]
+// MachineHealthCheckSpec defines the desired state of MachineHealthCheck
+type MachineHealthCheckSpec struct {
+	// Label selector to match machines whose health will be exercised.
+	// Only machines owned by a MachineSet are remediated.
+	Selector metav1.LabelSelector `json:"selector"`
+
+	// UnhealthyConditions contains a list of the conditions that determine
+	// whether a node is considered unhealthy. The conditions are combined in a
+	// logical OR, i.e. if any of the conditions is met, the node is unhealthy.
+	UnhealthyConditions []UnhealthyCondition `json:"unhealthyConditions"`
+
+	// Any further remediation is only allowed if at most "MaxUnhealthy" machines selected by
+	// "selector" are not healthy. Can be an absolute number (ex: 5) or a percentage of the
+	// selected machines (ex: 10%). Defaults to 100%.
+	// +optional
+	MaxUnhealthy *intstr.IntOrString `json:"maxUnhealthy,omitempty"`
+}
+
+/// [MachineHealthCheckSpec]
+
+/// [UnhealthyCondition]
+// UnhealthyCondition represents a Node condition type and value with a timeout
+// specified as a duration. When the named condition has been in the given
+// status for at least the timeout value, a node is considered unhealthy.
+type UnhealthyCondition struct {
+	// Type of the node condition, e.g. "Ready".
+	Type corev1.NodeConditionType `json:"type"`
+
+	// Status of the node condition, e.g. "Unknown".
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Timeout for which the condition must have been in the given status, e.g. "5m".
+	Timeout metav1.Duration `json:"timeout"`
+}
+
+/// [UnhealthyCondition]
+
+/// [MachineHealthCheckStatus]
+// MachineHealthCheckStatus defines the observed state of MachineHealthCheck
+type MachineHealthCheckStatus struct {
+	// Total number of machines counted by this machine health check.
+	// +optional
+	ExpectedMachines int32 `json:"expectedMachines,omitempty"`
+
+	// Total number of healthy machines counted by this machine health check.
+	// +optional
+	CurrentHealthy int32 `json:"currentHealthy,omitempty"`
+
+	// ObservedGeneration reflects the generation of the most recently observed MachineHealthCheck.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+/// [MachineHealthCheckStatus]
+
+func (m *MachineHealthCheck) Validate() field.ErrorList {
+	errors := field.ErrorList{}
+
+	fldPath := field.NewPath("spec")
+	errors = append(errors, metav1validation.ValidateLabelSelector(&m.Spec.Selector, fldPath.Child("selector"))...)
+	if len(m.Spec.Selector.MatchLabels)+len(m.Spec.Selector.MatchExpressions) == 0 {
+		errors = append(errors, field.Invalid(fldPath.Child("selector"), m.Spec.Selector, "empty selector is not valid for MachineHealthCheck."))
+	}
+
+	if len(m.Spec.UnhealthyConditions) == 0 {
+		errors = append(errors, field.Required(fldPath.Child("unhealthyConditions"), "at least one unhealthy condition is required."))
+	}
+	for i, c := range m.Spec.UnhealthyConditions {
+		if c.Timeout.Duration < 0 {
+			errors = append(errors, field.Invalid(fldPath.Child("unhealthyConditions").Index(i).Child("timeout"), c.Timeout.Duration.String(), "must be greater than or equal to 0."))
+		}
+	}
+
+	if m.Spec.MaxUnhealthy != nil {
+		if _, err := intstr.GetValueFromIntOrPercent(m.Spec.MaxUnhealthy, 0, false); err != nil {
+			errors = append(errors, field.Invalid(fldPath.Child("maxUnhealthy"), m.Spec.MaxUnhealthy.String(), "must be an integer or a percentage."))
+		}
+	}
+
+	return errors
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MachineHealthCheckList contains a list of MachineHealthCheck
+type MachineHealthCheckList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MachineHealthCheck `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MachineHealthCheck{}, &MachineHealthCheckList{})
+}
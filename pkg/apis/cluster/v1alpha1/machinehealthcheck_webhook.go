@@ -0,0 +1,36 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import "k8s.io/apimachinery/pkg/runtime"
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-k8s-io-v1alpha1-machinehealthcheck,mutating=false,failurePolicy=fail,groups=cluster.k8s.io,resources=machinehealthchecks,versions=v1alpha1,name=validation.machinehealthcheck.cluster.k8s.io
+
+// ValidateCreate implements webhook.Validator.
+func (m *MachineHealthCheck) ValidateCreate() error {
+	return toInvalidError("MachineHealthCheck", m.Name, m.Validate())
+}
+
+// ValidateUpdate implements webhook.Validator.
+func (m *MachineHealthCheck) ValidateUpdate(old runtime.Object) error {
+	return toInvalidError("MachineHealthCheck", m.Name, m.Validate())
+}
+
+// ValidateDelete implements webhook.Validator.
+func (m *MachineHealthCheck) ValidateDelete() error {
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// +kubebuilder:webhook:verbs=create;update,path=/mutate-cluster-k8s-io-v1alpha1-machineset,mutating=true,failurePolicy=fail,groups=cluster.k8s.io,resources=machinesets,versions=v1alpha1,name=default.machineset.cluster.k8s.io
+// +kubebuilder:webhook:verbs=create;update,path=/validate-cluster-k8s-io-v1alpha1-machineset,mutating=false,failurePolicy=fail,groups=cluster.k8s.io,resources=machinesets,versions=v1alpha1,name=validation.machineset.cluster.k8s.io
+
+// ValidateCreate implements webhook.Validator.
+func (m *MachineSet) ValidateCreate() error {
+	return toInvalidError("MachineSet", m.Name, m.validate())
+}
+
+// ValidateUpdate implements webhook.Validator. The selector can't be changed once
+// the MachineSet has been created, as it would orphan the Machines it selects.
+func (m *MachineSet) ValidateUpdate(old runtime.Object) error {
+	oldMachineSet := old.(*MachineSet)
+	errs := m.validate()
+	errs = append(errs, apivalidation.ValidateImmutableField(m.Spec.Selector, oldMachineSet.Spec.Selector, field.NewPath("spec", "selector"))...)
+	return toInvalidError("MachineSet", m.Name, errs)
+}
+
+// ValidateDelete implements webhook.Validator.
+func (m *MachineSet) ValidateDelete() error {
+	return nil
+}
+
+func (m *MachineSet) validate() field.ErrorList {
+	errs := m.Validate()
+	if m.Spec.Replicas != nil && *m.Spec.Replicas < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "replicas"), *m.Spec.Replicas, "must be greater than or equal to 0"))
+	}
+	errs = append(errs, validateProviderSpec(&m.Spec.Template.Spec.ProviderSpec, field.NewPath("spec", "template", "spec", "providerSpec"))...)
+	return errs
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newWebhookTestMachineSet(selector, templateLabels map[string]string) *MachineSet {
+	ms := &MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	ms.Spec.Selector.MatchLabels = selector
+	ms.Spec.Template.Labels = templateLabels
+	return ms
+}
+
+func TestMachineSetValidateCreate(t *testing.T) {
+	testcases := []struct {
+		name        string
+		machineSet  *MachineSet
+		expectedErr bool
+	}{
+		{
+			name:       "matching selector",
+			machineSet: newWebhookTestMachineSet(map[string]string{"foo": "bar"}, map[string]string{"foo": "bar"}),
+		},
+		{
+			name:        "empty selector",
+			machineSet:  newWebhookTestMachineSet(nil, map[string]string{"foo": "bar"}),
+			expectedErr: true,
+		},
+		{
+			name:        "selector not matching template labels",
+			machineSet:  newWebhookTestMachineSet(map[string]string{"foo": "bar"}, map[string]string{"foo": "baz"}),
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.machineSet.ValidateCreate()
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMachineSetValidateUpdate(t *testing.T) {
+	oldMachineSet := newWebhookTestMachineSet(map[string]string{"foo": "bar"}, map[string]string{"foo": "bar", "baz": "qux"})
+
+	unchanged := oldMachineSet.DeepCopy()
+	unchanged.Spec.Replicas = new(int32)
+	if err := unchanged.ValidateUpdate(oldMachineSet); err != nil {
+		t.Errorf("expected update to be valid, got %v", err)
+	}
+
+	changed := oldMachineSet.DeepCopy()
+	changed.Spec.Selector.MatchLabels = map[string]string{"baz": "qux"}
+	if err := changed.ValidateUpdate(oldMachineSet); err == nil {
+		t.Error("expected an error when changing the selector")
+	}
+}
@@ -30,6 +30,7 @@ type ClusterV1alpha1Interface interface {
 	MachinesGetter
 	MachineClassesGetter
 	MachineDeploymentsGetter
+	MachineHealthChecksGetter
 	MachineSetsGetter
 }
 
@@ -54,6 +55,10 @@ func (c *ClusterV1alpha1Client) MachineDeployments(namespace string) MachineDepl
 	return newMachineDeployments(c, namespace)
 }
 
+func (c *ClusterV1alpha1Client) MachineHealthChecks(namespace string) MachineHealthCheckInterface {
+	return newMachineHealthChecks(c, namespace)
+}
+
 func (c *ClusterV1alpha1Client) MachineSets(namespace string) MachineSetInterface {
 	return newMachineSets(c, namespace)
 }
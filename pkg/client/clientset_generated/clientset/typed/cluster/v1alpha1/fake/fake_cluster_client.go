@@ -44,6 +44,10 @@ func (c *FakeClusterV1alpha1) MachineDeployments(namespace string) v1alpha1.Mach
 	return &FakeMachineDeployments{c, namespace}
 }
 
+func (c *FakeClusterV1alpha1) MachineHealthChecks(namespace string) v1alpha1.MachineHealthCheckInterface {
+	return &FakeMachineHealthChecks{c, namespace}
+}
+
 func (c *FakeClusterV1alpha1) MachineSets(namespace string) v1alpha1.MachineSetInterface {
 	return &FakeMachineSets{c, namespace}
 }
@@ -26,4 +26,6 @@ type MachineClassExpansion interface{}
 
 type MachineDeploymentExpansion interface{}
 
+type MachineHealthCheckExpansion interface{}
+
 type MachineSetExpansion interface{}
@@ -32,6 +32,8 @@ type Interface interface {
 	MachineClasses() MachineClassInformer
 	// MachineDeployments returns a MachineDeploymentInformer.
 	MachineDeployments() MachineDeploymentInformer
+	// MachineHealthChecks returns a MachineHealthCheckInformer.
+	MachineHealthChecks() MachineHealthCheckInformer
 	// MachineSets returns a MachineSetInformer.
 	MachineSets() MachineSetInformer
 }
@@ -67,6 +69,11 @@ func (v *version) MachineDeployments() MachineDeploymentInformer {
 	return &machineDeploymentInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// MachineHealthChecks returns a MachineHealthCheckInformer.
+func (v *version) MachineHealthChecks() MachineHealthCheckInformer {
+	return &machineHealthCheckInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // MachineSets returns a MachineSetInformer.
 func (v *version) MachineSets() MachineSetInformer {
 	return &machineSetInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -50,6 +50,14 @@ type MachineDeploymentListerExpansion interface{}
 // MachineDeploymentNamespaceLister.
 type MachineDeploymentNamespaceListerExpansion interface{}
 
+// MachineHealthCheckListerExpansion allows custom methods to be added to
+// MachineHealthCheckLister.
+type MachineHealthCheckListerExpansion interface{}
+
+// MachineHealthCheckNamespaceListerExpansion allows custom methods to be added to
+// MachineHealthCheckNamespaceLister.
+type MachineHealthCheckNamespaceListerExpansion interface{}
+
 // MachineSetListerExpansion allows custom methods to be added to
 // MachineSetLister.
 type MachineSetListerExpansion interface{}
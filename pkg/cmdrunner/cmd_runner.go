@@ -17,7 +17,10 @@ limitations under the License.
 package cmdrunner
 
 import (
+	"bytes"
+	"context"
 	"os/exec"
+	"strings"
 )
 
 // Runner has one method that executes a command and returns stdout and stderr.
@@ -25,6 +28,23 @@ type Runner interface {
 	CombinedOutput(cmd string, args ...string) (output string, err error)
 }
 
+// InputRunner executes a command with the given input on stdin and returns
+// stdout and stderr separately. The command is killed when ctx is done.
+type InputRunner interface {
+	Output(ctx context.Context, input string, cmd string, args ...string) (stdout, stderr string, err error)
+}
+
+// ExitCode returns the exit code of a command that ran and exited with a
+// non-zero status, as reported by the error returned by a runner. It returns
+// false if err does not carry an exit code.
+func ExitCode(err error) (int, bool) {
+	exitErr, ok := err.(interface{ ExitCode() int })
+	if !ok || exitErr.ExitCode() < 0 {
+		return 0, false
+	}
+	return exitErr.ExitCode(), true
+}
+
 type realRunner struct {
 }
 
@@ -37,3 +57,13 @@ func (runner *realRunner) CombinedOutput(cmd string, args ...string) (string, er
 	output, err := exec.Command(cmd, args...).CombinedOutput()
 	return string(output), err
 }
+
+func (runner *realRunner) Output(ctx context.Context, input string, cmd string, args ...string) (string, string, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, cmd, args...)
+	c.Stdin = strings.NewReader(input)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	err := c.Run()
+	return stdout.String(), stderr.String(), err
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package cmdrunner
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -74,6 +75,34 @@ func TestCombinedOutputShouldIncludeStdOutAndErr(t *testing.T) {
 	}
 }
 
+func TestOutputShouldSeparateStdOutAndErr(t *testing.T) {
+	skipIfCommandNotPresent(t, "sh")
+	runner := New()
+	stdout, stderr, err := runner.Output(context.Background(), "input", "sh", "-c", "cat && (>&2 echo \"stderr\")")
+	if err != nil {
+		t.Errorf("invalid error: expected 'nil', got '%v'", err)
+	}
+	if stdout != "input" {
+		t.Errorf("invalid stdout: expected 'input', got '%v'", stdout)
+	}
+	if stderr != "stderr\n" {
+		t.Errorf("invalid stderr: expected 'stderr\\n', got '%v'", stderr)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	skipIfCommandNotPresent(t, "sh")
+	runner := New()
+	_, _, err := runner.Output(context.Background(), "", "sh", "-c", "exit 75")
+	if code, ok := ExitCode(err); !ok || code != 75 {
+		t.Errorf("invalid exit code: expected '75', got '%v' (%v)", code, ok)
+	}
+	_, _, err = runner.Output(context.Background(), "", "asdf")
+	if code, ok := ExitCode(err); ok {
+		t.Errorf("unexpected exit code '%v' for a command that did not run", code)
+	}
+}
+
 func skipIfCommandNotPresent(t *testing.T, cmd string) {
 	runner := New()
 	_, err := runner.CombinedOutput("ls")
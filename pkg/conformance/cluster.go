@@ -0,0 +1,144 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/controller/cluster"
+)
+
+// ClusterFixture supplies the clusters passed to the cluster actuator under
+// test.
+type ClusterFixture struct {
+	Options
+
+	// NewCluster returns a cluster which hasn't been reconciled by the
+	// actuator yet. It must return a different cluster on each call, and
+	// create it in the fake API server the actuator uses, if any.
+	NewCluster func() *clusterv1.Cluster
+}
+
+// NewClusterV1Adapter returns an ActuatorV2 calling the given Actuator, like
+// cluster.NewV1Adapter(), which additionally reports a RequeueAfterError
+// with a non-positive RequeueAfter as an error.
+func NewClusterV1Adapter(actuator cluster.Actuator) cluster.ActuatorV2 {
+	return cluster.NewV1Adapter(&checkedClusterActuator{actuator: actuator})
+}
+
+type checkedClusterActuator struct {
+	actuator cluster.Actuator
+}
+
+func (a *checkedClusterActuator) Reconcile(c *clusterv1.Cluster) error {
+	return checkRequeueAfterError(a.actuator.Reconcile(c))
+}
+
+func (a *checkedClusterActuator) Delete(c *clusterv1.Cluster) error {
+	return checkRequeueAfterError(a.actuator.Delete(c))
+}
+
+// CheckClusterActuator returns the guarantees of the cluster actuator contract
+// violated by the given actuator.
+func CheckClusterActuator(ctx context.Context, actuator cluster.ActuatorV2, fixture ClusterFixture) []Violation {
+	return check(clusterGuarantees(ctx, actuator, fixture))
+}
+
+// TestClusterActuator checks each guarantee of the cluster actuator contract
+// in a subtest.
+func TestClusterActuator(t *testing.T, actuator cluster.ActuatorV2, fixture ClusterFixture) {
+	run(t, clusterGuarantees(context.Background(), actuator, fixture))
+}
+
+func clusterGuarantees(ctx context.Context, actuator cluster.ActuatorV2, fixture ClusterFixture) []guarantee {
+	s := &clusterSuite{ctx: ctx, actuator: actuator, fixture: fixture}
+	return []guarantee{
+		{name: "ReconcileIsIdempotent", check: s.reconcileIsIdempotent},
+		{name: "DeleteAfterReconcile", check: s.deleteAfterReconcile},
+		{name: "DeleteToleratesMissingCluster", check: s.deleteToleratesMissingCluster},
+	}
+}
+
+type clusterSuite struct {
+	ctx      context.Context
+	actuator cluster.ActuatorV2
+	fixture  ClusterFixture
+}
+
+func (s *clusterSuite) reconcileIsIdempotent() error {
+	c := s.fixture.NewCluster()
+	defer s.delete(c) // nolint
+
+	for i := 0; i < 2; i++ {
+		if err := s.reconcile(c); err != nil {
+			return errors.Wrapf(err, "call %d", i+1)
+		}
+	}
+	return nil
+}
+
+func (s *clusterSuite) deleteAfterReconcile() error {
+	c := s.fixture.NewCluster()
+	if err := s.reconcile(c); err != nil {
+		return err
+	}
+	return s.delete(c)
+}
+
+func (s *clusterSuite) deleteToleratesMissingCluster() error {
+	c := s.fixture.NewCluster()
+	if err := s.delete(c); err != nil {
+		return errors.Wrap(err, "cluster which wasn't reconciled")
+	}
+
+	c = s.fixture.NewCluster()
+	if err := s.reconcile(c); err != nil {
+		return err
+	}
+	if err := s.delete(c); err != nil {
+		return err
+	}
+	if err := s.delete(c); err != nil {
+		return errors.Wrap(err, "cluster which was already deleted")
+	}
+	return nil
+}
+
+func (s *clusterSuite) reconcile(c *clusterv1.Cluster) error {
+	return s.fixture.eventually("Reconcile", func() (time.Duration, error, error) {
+		result, err := s.actuator.Reconcile(s.ctx, c)
+		return result.RequeueAfter, clusterTerminalError(result), err
+	})
+}
+
+func (s *clusterSuite) delete(c *clusterv1.Cluster) error {
+	return s.fixture.eventually("Delete", func() (time.Duration, error, error) {
+		result, err := s.actuator.Delete(s.ctx, c)
+		return result.RequeueAfter, clusterTerminalError(result), err
+	})
+}
+
+func clusterTerminalError(result cluster.Result) error {
+	if result.Error == nil {
+		return nil
+	}
+	return result.Error
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+)
+
+// DefaultMaxRequeues is used when Options.MaxRequeues is not set.
+const DefaultMaxRequeues = 10
+
+// Options configures how operations requesting a requeue are retried.
+type Options struct {
+	// MaxRequeues is the number of requeues after which an operation is
+	// considered stuck.
+	MaxRequeues int
+	// Advance is called with the requested duration before retrying an
+	// operation. It defaults to time.Sleep and may advance a fake clock
+	// instead.
+	Advance func(time.Duration)
+}
+
+// Violation reports a guarantee violated by an actuator.
+type Violation struct {
+	Guarantee string
+	Err       error
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %v", v.Guarantee, v.Err)
+}
+
+// guarantee is a part of the contract of an actuator, checked by a function
+// returning an error if it is violated.
+type guarantee struct {
+	name  string
+	check func() error
+}
+
+// check returns the violations of the given guarantees.
+func check(guarantees []guarantee) []Violation {
+	var violations []Violation
+	for _, g := range guarantees {
+		if err := g.check(); err != nil {
+			violations = append(violations, Violation{Guarantee: g.name, Err: err})
+		}
+	}
+	return violations
+}
+
+// run checks each of the given guarantees in a subtest named after it.
+func run(t *testing.T, guarantees []guarantee) {
+	for _, g := range guarantees {
+		g := g
+		t.Run(g.name, func(t *testing.T) {
+			if err := g.check(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// eventually calls the operation until it neither fails nor requests a
+// requeue, and returns an error if it doesn't within MaxRequeues.
+func (o Options) eventually(operation string, call func() (requeueAfter time.Duration, terminal error, err error)) error {
+	maxRequeues := o.MaxRequeues
+	if maxRequeues <= 0 {
+		maxRequeues = DefaultMaxRequeues
+	}
+	advance := o.Advance
+	if advance == nil {
+		advance = time.Sleep
+	}
+
+	for requeues := 0; ; requeues++ {
+		requeueAfter, terminal, err := call()
+		switch {
+		case err != nil:
+			return errors.Wrapf(err, "%s failed", operation)
+		case terminal != nil:
+			return errors.Errorf("%s returned a terminal error: %v", operation, terminal)
+		case requeueAfter < 0:
+			return errors.Errorf("%s requested a requeue after a negative duration %s", operation, requeueAfter)
+		case requeueAfter == 0:
+			return nil
+		}
+		if requeues >= maxRequeues {
+			return errors.Errorf("%s did not complete after %d requeues", operation, requeues)
+		}
+		advance(requeueAfter)
+	}
+}
+
+// checkRequeueAfterError returns an error if err is a RequeueAfterError
+// which doesn't request a requeue, since the controllers would then consider
+// the operation successful.
+func checkRequeueAfterError(err error) error {
+	if requeueErr, ok := errors.Cause(err).(*controllerError.RequeueAfterError); ok && requeueErr.RequeueAfter <= 0 {
+		return errors.Errorf("a RequeueAfterError must have a positive RequeueAfter, got %s", requeueErr.RequeueAfter)
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+)
+
+// fakeMachineActuator keeps instances in a map, with knobs breaking the
+// contract.
+type fakeMachineActuator struct {
+	instances map[string]int
+	// pending is the number of requeues left before creations complete.
+	pending map[string]int
+
+	duplicateOnCreate  bool
+	failMissingDelete  bool
+	zeroRequeue        bool
+	requeuesBeforeDone int
+}
+
+func newFakeMachineActuator() *fakeMachineActuator {
+	return &fakeMachineActuator{instances: map[string]int{}, pending: map[string]int{}}
+}
+
+func (a *fakeMachineActuator) Create(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	if _, ok := a.pending[machine.Name]; !ok {
+		a.pending[machine.Name] = a.requeuesBeforeDone
+	}
+	if a.pending[machine.Name] > 0 {
+		a.pending[machine.Name]--
+		if a.zeroRequeue {
+			return &controllerError.RequeueAfterError{}
+		}
+		return &controllerError.RequeueAfterError{RequeueAfter: 1}
+	}
+	if _, ok := a.instances[machine.Name]; !ok || a.duplicateOnCreate {
+		a.instances[machine.Name]++
+	}
+	return nil
+}
+
+func (a *fakeMachineActuator) Delete(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	if _, ok := a.instances[machine.Name]; !ok && a.failMissingDelete {
+		return errors.Errorf("instance of machine %q not found", machine.Name)
+	}
+	delete(a.instances, machine.Name)
+	delete(a.pending, machine.Name)
+	return nil
+}
+
+func (a *fakeMachineActuator) Update(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	return nil
+}
+
+func (a *fakeMachineActuator) Exists(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (bool, error) {
+	_, ok := a.instances[machine.Name]
+	return ok, nil
+}
+
+func (a *fakeMachineActuator) fixture() MachineFixture {
+	i := 0
+	return MachineFixture{
+		NewMachine: func() (*clusterv1.Cluster, *clusterv1.Machine) {
+			i++
+			return nil, &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("machine-%d", i)}}
+		},
+		CountInstances: func() (int, error) {
+			count := 0
+			for _, instances := range a.instances {
+				count += instances
+			}
+			return count, nil
+		},
+	}
+}
+
+func TestConformingMachineActuator(t *testing.T) {
+	actuator := newFakeMachineActuator()
+	actuator.requeuesBeforeDone = 2
+	TestMachineActuator(t, NewMachineV1Adapter(actuator), actuator.fixture())
+}
+
+func TestMachineActuatorViolations(t *testing.T) {
+	testCases := []struct {
+		name     string
+		setup    func(*fakeMachineActuator)
+		options  Options
+		expected []string
+	}{
+		{
+			name:     "duplicate instances",
+			setup:    func(a *fakeMachineActuator) { a.duplicateOnCreate = true },
+			expected: []string{"CreateIsIdempotent"},
+		},
+		{
+			name:     "delete fails for missing instances",
+			setup:    func(a *fakeMachineActuator) { a.failMissingDelete = true },
+			expected: []string{"DeleteToleratesMissingInstance"},
+		},
+		{
+			name: "requeue without delay",
+			setup: func(a *fakeMachineActuator) {
+				a.requeuesBeforeDone = 1
+				a.zeroRequeue = true
+			},
+			expected: []string{"ExistsAfterCreate", "CreateIsIdempotent", "UpdateWithoutChangesIsNoop", "NotExistsAfterDelete", "DeleteToleratesMissingInstance"},
+		},
+		{
+			name:     "never done",
+			setup:    func(a *fakeMachineActuator) { a.requeuesBeforeDone = 3 },
+			options:  Options{MaxRequeues: 2},
+			expected: []string{"ExistsAfterCreate", "CreateIsIdempotent", "UpdateWithoutChangesIsNoop", "NotExistsAfterDelete", "DeleteToleratesMissingInstance"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actuator := newFakeMachineActuator()
+			tc.setup(actuator)
+			fixture := actuator.fixture()
+			fixture.Options = tc.options
+
+			violations := CheckMachineActuator(context.Background(), NewMachineV1Adapter(actuator), fixture)
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.Guarantee)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+				t.Errorf("expected violations of %v, got %v", tc.expected, violations)
+			}
+		})
+	}
+}
+
+// fakeClusterActuator fails to delete clusters it doesn't know about.
+type fakeClusterActuator struct {
+	clusters map[string]bool
+}
+
+func (a *fakeClusterActuator) Reconcile(cluster *clusterv1.Cluster) error {
+	a.clusters[cluster.Name] = true
+	return nil
+}
+
+func (a *fakeClusterActuator) Delete(cluster *clusterv1.Cluster) error {
+	if !a.clusters[cluster.Name] {
+		return errors.Errorf("cluster %q not found", cluster.Name)
+	}
+	delete(a.clusters, cluster.Name)
+	return nil
+}
+
+func TestClusterActuatorViolations(t *testing.T) {
+	i := 0
+	fixture := ClusterFixture{
+		NewCluster: func() *clusterv1.Cluster {
+			i++
+			return &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("cluster-%d", i)}}
+		},
+	}
+	actuator := NewClusterV1Adapter(&fakeClusterActuator{clusters: map[string]bool{}})
+
+	violations := CheckClusterActuator(context.Background(), actuator, fixture)
+	if len(violations) != 1 || violations[0].Guarantee != "DeleteToleratesMissingCluster" {
+		t.Errorf("expected a violation of DeleteToleratesMissingCluster, got %v", violations)
+	}
+}
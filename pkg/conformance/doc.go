@@ -0,0 +1,32 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance drives machine and cluster actuators through the
+// contract expected by the Cluster API controllers, so that providers don't
+// need to write their own tests for it.
+//
+// Providers run it from their tests against a fake backend:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.TestMachineActuator(t, conformance.NewMachineV1Adapter(newActuator(fakeCloud)), conformance.MachineFixture{
+//			NewMachine:     newMachine,
+//			CountInstances: fakeCloud.countInstances,
+//		})
+//	}
+//
+// Every guarantee is run as a subtest named after it, so that the failures
+// show which guarantees are violated.
+package conformance
@@ -0,0 +1,273 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/controller/machine"
+)
+
+// MachineFixture supplies the machines passed to the machine actuator under
+// test and inspects its fake backend.
+type MachineFixture struct {
+	Options
+
+	// NewMachine returns a machine which hasn't been created by the actuator
+	// yet, and its cluster, which may be nil. It must return a different
+	// machine on each call, and create it in the fake API server the
+	// actuator uses, if any.
+	NewMachine func() (*clusterv1.Cluster, *clusterv1.Machine)
+
+	// CountInstances, if set, returns the number of instances in the fake
+	// backend, to check that no instances are duplicated or leaked.
+	CountInstances func() (int, error)
+}
+
+// NewMachineV1Adapter returns an ActuatorV2 calling the given Actuator, like
+// machine.NewV1Adapter(), which additionally reports a RequeueAfterError
+// with a non-positive RequeueAfter as an error.
+func NewMachineV1Adapter(actuator machine.Actuator) machine.ActuatorV2 {
+	return machine.NewV1Adapter(&checkedMachineActuator{Actuator: actuator})
+}
+
+type checkedMachineActuator struct {
+	machine.Actuator
+}
+
+func (a *checkedMachineActuator) Create(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	return checkRequeueAfterError(a.Actuator.Create(ctx, cluster, m))
+}
+
+func (a *checkedMachineActuator) Update(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	return checkRequeueAfterError(a.Actuator.Update(ctx, cluster, m))
+}
+
+func (a *checkedMachineActuator) Delete(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	return checkRequeueAfterError(a.Actuator.Delete(ctx, cluster, m))
+}
+
+// CheckMachineActuator returns the guarantees of the machine actuator contract
+// violated by the given actuator.
+func CheckMachineActuator(ctx context.Context, actuator machine.ActuatorV2, fixture MachineFixture) []Violation {
+	return check(machineGuarantees(ctx, actuator, fixture))
+}
+
+// TestMachineActuator checks each guarantee of the machine actuator contract
+// in a subtest.
+func TestMachineActuator(t *testing.T, actuator machine.ActuatorV2, fixture MachineFixture) {
+	run(t, machineGuarantees(context.Background(), actuator, fixture))
+}
+
+func machineGuarantees(ctx context.Context, actuator machine.ActuatorV2, fixture MachineFixture) []guarantee {
+	s := &machineSuite{ctx: ctx, actuator: actuator, fixture: fixture}
+	return []guarantee{
+		{name: "ExistsFalseBeforeCreate", check: s.existsFalseBeforeCreate},
+		{name: "ExistsAfterCreate", check: s.existsAfterCreate},
+		{name: "CreateIsIdempotent", check: s.createIsIdempotent},
+		{name: "UpdateWithoutChangesIsNoop", check: s.updateWithoutChangesIsNoop},
+		{name: "NotExistsAfterDelete", check: s.notExistsAfterDelete},
+		{name: "DeleteToleratesMissingInstance", check: s.deleteToleratesMissingInstance},
+	}
+}
+
+type machineSuite struct {
+	ctx      context.Context
+	actuator machine.ActuatorV2
+	fixture  MachineFixture
+}
+
+func (s *machineSuite) existsFalseBeforeCreate() error {
+	cluster, m := s.fixture.NewMachine()
+	exists, err := s.exists(cluster, m)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("Exists returned true for a machine which wasn't created")
+	}
+	return nil
+}
+
+func (s *machineSuite) existsAfterCreate() error {
+	cluster, m := s.fixture.NewMachine()
+	defer s.cleanup(cluster, m)
+
+	if err := s.create(cluster, m); err != nil {
+		return err
+	}
+	return s.expectExists(cluster, m, true, "after Create")
+}
+
+func (s *machineSuite) createIsIdempotent() error {
+	cluster, m := s.fixture.NewMachine()
+	defer s.cleanup(cluster, m)
+
+	before, err := s.countInstances()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.create(cluster, m); err != nil {
+			return errors.Wrapf(err, "call %d", i+1)
+		}
+	}
+	if err := s.expectInstances(before+1, "after calling Create twice"); err != nil {
+		return err
+	}
+	return s.expectExists(cluster, m, true, "after calling Create twice")
+}
+
+func (s *machineSuite) updateWithoutChangesIsNoop() error {
+	cluster, m := s.fixture.NewMachine()
+	defer s.cleanup(cluster, m)
+
+	if err := s.create(cluster, m); err != nil {
+		return err
+	}
+	before, err := s.countInstances()
+	if err != nil {
+		return err
+	}
+	spec := m.Spec.DeepCopy()
+	if err := s.update(cluster, m); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(spec, &m.Spec) {
+		return errors.Errorf("Update changed the spec of the machine from %+v to %+v", spec, m.Spec)
+	}
+	if err := s.expectInstances(before, "after Update"); err != nil {
+		return err
+	}
+	return s.expectExists(cluster, m, true, "after Update")
+}
+
+func (s *machineSuite) notExistsAfterDelete() error {
+	cluster, m := s.fixture.NewMachine()
+
+	before, err := s.countInstances()
+	if err != nil {
+		return err
+	}
+	if err := s.create(cluster, m); err != nil {
+		return err
+	}
+	if err := s.delete(cluster, m); err != nil {
+		return err
+	}
+	if err := s.expectInstances(before, "after Delete"); err != nil {
+		return err
+	}
+	return s.expectExists(cluster, m, false, "after Delete")
+}
+
+func (s *machineSuite) deleteToleratesMissingInstance() error {
+	cluster, m := s.fixture.NewMachine()
+	if err := s.delete(cluster, m); err != nil {
+		return errors.Wrap(err, "machine which wasn't created")
+	}
+
+	cluster, m = s.fixture.NewMachine()
+	if err := s.create(cluster, m); err != nil {
+		return err
+	}
+	if err := s.delete(cluster, m); err != nil {
+		return err
+	}
+	if err := s.delete(cluster, m); err != nil {
+		return errors.Wrap(err, "machine which was already deleted")
+	}
+	return nil
+}
+
+func (s *machineSuite) create(cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	return s.fixture.eventually("Create", func() (time.Duration, error, error) {
+		result, err := s.actuator.Create(s.ctx, cluster, m)
+		return result.RequeueAfter, machineTerminalError(result), err
+	})
+}
+
+func (s *machineSuite) update(cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	return s.fixture.eventually("Update", func() (time.Duration, error, error) {
+		result, err := s.actuator.Update(s.ctx, cluster, m)
+		return result.RequeueAfter, machineTerminalError(result), err
+	})
+}
+
+func (s *machineSuite) delete(cluster *clusterv1.Cluster, m *clusterv1.Machine) error {
+	return s.fixture.eventually("Delete", func() (time.Duration, error, error) {
+		result, err := s.actuator.Delete(s.ctx, cluster, m)
+		return result.RequeueAfter, machineTerminalError(result), err
+	})
+}
+
+func (s *machineSuite) exists(cluster *clusterv1.Cluster, m *clusterv1.Machine) (bool, error) {
+	exists, err := s.actuator.Exists(s.ctx, cluster, m)
+	return exists, errors.Wrap(err, "Exists failed")
+}
+
+func (s *machineSuite) expectExists(cluster *clusterv1.Cluster, m *clusterv1.Machine, expected bool, when string) error {
+	exists, err := s.exists(cluster, m)
+	if err != nil {
+		return err
+	}
+	if exists != expected {
+		return errors.Errorf("Exists returned %t %s", exists, when)
+	}
+	return nil
+}
+
+// countInstances returns 0 if the fixture can't count the instances.
+func (s *machineSuite) countInstances() (int, error) {
+	if s.fixture.CountInstances == nil {
+		return 0, nil
+	}
+	count, err := s.fixture.CountInstances()
+	return count, errors.Wrap(err, "failed to count the instances")
+}
+
+func (s *machineSuite) expectInstances(expected int, when string) error {
+	if s.fixture.CountInstances == nil {
+		return nil
+	}
+	count, err := s.countInstances()
+	if err != nil {
+		return err
+	}
+	if count != expected {
+		return errors.Errorf("expected %d instances %s, found %d", expected, when, count)
+	}
+	return nil
+}
+
+// cleanup deletes the machine, ignoring errors which are reported by the
+// guarantees about Delete.
+func (s *machineSuite) cleanup(cluster *clusterv1.Cluster, m *clusterv1.Machine) {
+	s.delete(cluster, m) // nolint
+}
+
+func machineTerminalError(result machine.Result) error {
+	if result.Error == nil {
+		return nil
+	}
+	return result.Error
+}
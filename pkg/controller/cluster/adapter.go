@@ -0,0 +1,59 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+)
+
+// NewV1Adapter returns an ActuatorV2 calling the given Actuator, which ignores
+// the context. The errors it returns are converted into a Result: a
+// RequeueAfterError requests a requeue, a ClusterError is terminal, and other
+// errors are returned as they are.
+func NewV1Adapter(actuator Actuator) ActuatorV2 {
+	return &v1Adapter{actuator: actuator}
+}
+
+type v1Adapter struct {
+	actuator Actuator
+}
+
+func (a *v1Adapter) Reconcile(_ context.Context, cluster *clusterv1.Cluster) (Result, error) {
+	return resultFromError(a.actuator.Reconcile(cluster))
+}
+
+func (a *v1Adapter) Delete(_ context.Context, cluster *clusterv1.Cluster) (Result, error) {
+	return resultFromError(a.actuator.Delete(cluster))
+}
+
+// resultFromError converts an error returned by an Actuator into a Result.
+func resultFromError(err error) (Result, error) {
+	if err == nil {
+		return Result{}, nil
+	}
+	if requeueErr, ok := errors.Cause(err).(*controllerError.RequeueAfterError); ok {
+		return Result{RequeueAfter: requeueErr.RequeueAfter}, nil
+	}
+	if clusterErr, ok := clusterError(err); ok {
+		return Result{Error: clusterErr}, nil
+	}
+	return Result{}, err
+}
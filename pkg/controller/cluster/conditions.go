@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons of the cluster conditions set by the cluster controller.
+const (
+	reconcileSucceededReason        = "ReconcileSucceeded"
+	reconcileInProgressReason       = "ReconcileInProgress"
+	reconcileFailedReason           = "ReconcileFailed"
+	apiEndpointsReportedReason      = "APIEndpointsReported"
+	noAPIEndpointsReason            = "NoAPIEndpoints"
+	noControlPlaneMachinesReason    = "NoControlPlaneMachines"
+	controlPlaneNodeLinkedReason    = "ControlPlaneNodeLinked"
+	waitingForControlPlaneReason    = "WaitingForControlPlaneNodes"
+	controlPlaneMachineFailedReason = "ControlPlaneMachineFailed"
+	deletionRequestedReason         = "DeletionRequested"
+)
+
+// newClusterCondition creates a new cluster condition.
+func newClusterCondition(condType common.ClusterConditionType, status corev1.ConditionStatus, reason, message string) clusterv1.ClusterCondition {
+	now := metav1.Now()
+	return clusterv1.ClusterCondition{
+		Type:               condType,
+		Status:             status,
+		LastUpdateTime:     now,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// getClusterCondition returns the condition with the provided type.
+func getClusterCondition(status clusterv1.ClusterStatus, condType common.ClusterConditionType) *clusterv1.ClusterCondition {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == condType {
+			return &status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// setClusterCondition updates the cluster status to include the provided condition. If the condition that
+// we are about to add already exists and has the same status and reason then we are not going to update.
+func setClusterCondition(status *clusterv1.ClusterStatus, condition clusterv1.ClusterCondition) {
+	currentCond := getClusterCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason {
+		return
+	}
+	// Do not update lastTransitionTime if the status of the condition doesn't change.
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+
+	var newConditions []clusterv1.ClusterCondition
+	for _, c := range status.Conditions {
+		if c.Type != condition.Type {
+			newConditions = append(newConditions, c)
+		}
+	}
+	status.Conditions = append(newConditions, condition)
+}
+
+// infrastructureReadyCondition converts the result of the actuator's Reconcile into
+// the InfrastructureReady condition.
+func infrastructureReadyCondition(result Result, err error) clusterv1.ClusterCondition {
+	switch {
+	case err != nil:
+		return newClusterCondition(common.ClusterInfrastructureReady, corev1.ConditionFalse, reconcileFailedReason, err.Error())
+	case result.Error != nil:
+		return newClusterCondition(common.ClusterInfrastructureReady, corev1.ConditionFalse, reconcileFailedReason, result.Error.Error())
+	case result.RequeueAfter > 0:
+		return newClusterCondition(common.ClusterInfrastructureReady, corev1.ConditionFalse, reconcileInProgressReason,
+			fmt.Sprintf("requeue in: %s", result.RequeueAfter))
+	}
+	return newClusterCondition(common.ClusterInfrastructureReady, corev1.ConditionTrue, reconcileSucceededReason, "")
+}
+
+// apiEndpointAvailableCondition returns the APIEndpointAvailable condition of the cluster.
+func apiEndpointAvailableCondition(cluster *clusterv1.Cluster) clusterv1.ClusterCondition {
+	if len(cluster.Status.APIEndpoints) == 0 {
+		return newClusterCondition(common.ClusterAPIEndpointAvailable, corev1.ConditionFalse, noAPIEndpointsReason, "")
+	}
+	endpoint := cluster.Status.APIEndpoints[0]
+	return newClusterCondition(common.ClusterAPIEndpointAvailable, corev1.ConditionTrue, apiEndpointsReportedReason,
+		fmt.Sprintf("API server available at %s:%d", endpoint.Host, endpoint.Port))
+}
+
+// controlPlaneReadyCondition returns the ControlPlaneReady condition of the cluster, given
+// the machines linked to it. The control plane is ready once one of its machines is linked
+// to a node, it is unknown for clusters without control plane machines, whose control plane
+// may not be managed by cluster-api.
+func controlPlaneReadyCondition(machines []*clusterv1.Machine) clusterv1.ClusterCondition {
+	controlPlane := util.GetControlPlaneMachines(machines)
+	if len(controlPlane) == 0 {
+		return newClusterCondition(common.ClusterControlPlaneReady, corev1.ConditionUnknown, noControlPlaneMachinesReason, "")
+	}
+
+	var ready, failed int
+	for _, m := range controlPlane {
+		switch {
+		case m.Status.ErrorReason != nil || m.Status.ErrorMessage != nil:
+			failed++
+		case m.Status.NodeRef != nil && m.DeletionTimestamp.IsZero():
+			ready++
+		}
+	}
+
+	message := fmt.Sprintf("%d of %d control plane machines are linked to a node", ready, len(controlPlane))
+	switch {
+	case ready > 0:
+		return newClusterCondition(common.ClusterControlPlaneReady, corev1.ConditionTrue, controlPlaneNodeLinkedReason, message)
+	case failed > 0:
+		return newClusterCondition(common.ClusterControlPlaneReady, corev1.ConditionFalse, controlPlaneMachineFailedReason,
+			fmt.Sprintf("%d of %d control plane machines failed", failed, len(controlPlane)))
+	default:
+		return newClusterCondition(common.ClusterControlPlaneReady, corev1.ConditionFalse, waitingForControlPlaneReason, message)
+	}
+}
+
+// getClusterMachines returns the machines linked to the cluster by their cluster name label.
+func (r *ReconcileCluster) getClusterMachines(ctx context.Context, cluster *clusterv1.Cluster) ([]*clusterv1.Machine, error) {
+	machineList := &clusterv1.MachineList{}
+	if err := r.List(ctx, machineList, client.InNamespace(cluster.Namespace), client.MatchingLabels{clusterv1.MachineClusterLabelName: cluster.Name}); err != nil {
+		return nil, err
+	}
+
+	machines := make([]*clusterv1.Machine, 0, len(machineList.Items))
+	for i := range machineList.Items {
+		machines = append(machines, &machineList.Items[i])
+	}
+	return machines, nil
+}
+
+// patchStatus patches the status of the cluster if it changed from base. A merge patch
+// is used as actuators may have updated the cluster during the reconciliation.
+func (r *ReconcileCluster) patchStatus(ctx context.Context, cluster, base *clusterv1.Cluster) error {
+	if equality.Semantic.DeepEqual(cluster.Status, base.Status) {
+		return nil
+	}
+	return r.Status().Patch(ctx, cluster, client.MergeFrom(base))
+}
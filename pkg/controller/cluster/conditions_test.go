@@ -0,0 +1,304 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type errorActuator struct {
+	TestActuator
+	err error
+}
+
+func (a *errorActuator) Reconcile(*v1alpha1.Cluster) error {
+	return a.err
+}
+
+func newTestCluster(deleted bool) *v1alpha1.Cluster {
+	c := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "foo",
+			Namespace:  "default",
+			Generation: 2,
+			Finalizers: []string{v1alpha1.ClusterFinalizer},
+		},
+	}
+	if deleted {
+		now := metav1.Now()
+		c.DeletionTimestamp = &now
+	}
+	return c
+}
+
+func newControlPlaneMachine(name string, linked bool) *v1alpha1.Machine {
+	m := &v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{v1alpha1.MachineClusterLabelName: "foo"},
+		},
+		Spec: v1alpha1.MachineSpec{Versions: v1alpha1.MachineVersionInfo{ControlPlane: "1.15.0"}},
+	}
+	if linked {
+		m.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: name}
+	}
+	return m
+}
+
+func TestReconcileConditions(t *testing.T) {
+	testcases := []struct {
+		name        string
+		actuatorErr error
+		expectErr   bool
+		endpoints   []v1alpha1.APIEndpoint
+		machines    []runtime.Object
+		expected    map[common.ClusterConditionType]corev1.ConditionStatus
+	}{
+		{
+			name:      "ready cluster",
+			endpoints: []v1alpha1.APIEndpoint{{Host: "10.0.0.1", Port: 6443}},
+			machines:  []runtime.Object{newControlPlaneMachine("cp-0", true), newControlPlaneMachine("cp-1", false)},
+			expected: map[common.ClusterConditionType]corev1.ConditionStatus{
+				common.ClusterInfrastructureReady:  corev1.ConditionTrue,
+				common.ClusterAPIEndpointAvailable: corev1.ConditionTrue,
+				common.ClusterControlPlaneReady:    corev1.ConditionTrue,
+				common.ClusterDeleting:             corev1.ConditionFalse,
+			},
+		},
+		{
+			name:        "provisioning cluster",
+			actuatorErr: &controllerError.RequeueAfterError{RequeueAfter: time.Minute},
+			machines:    []runtime.Object{newControlPlaneMachine("cp-0", false)},
+			expected: map[common.ClusterConditionType]corev1.ConditionStatus{
+				common.ClusterInfrastructureReady:  corev1.ConditionFalse,
+				common.ClusterAPIEndpointAvailable: corev1.ConditionFalse,
+				common.ClusterControlPlaneReady:    corev1.ConditionFalse,
+				common.ClusterDeleting:             corev1.ConditionFalse,
+			},
+		},
+		{
+			name:        "failed cluster without control plane machines",
+			actuatorErr: errors.New("boom"),
+			expectErr:   true,
+			expected: map[common.ClusterConditionType]corev1.ConditionStatus{
+				common.ClusterInfrastructureReady:  corev1.ConditionFalse,
+				common.ClusterAPIEndpointAvailable: corev1.ConditionFalse,
+				common.ClusterControlPlaneReady:    corev1.ConditionUnknown,
+				common.ClusterDeleting:             corev1.ConditionFalse,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := newTestCluster(false)
+			cluster.Status.APIEndpoints = tc.endpoints
+			r := &ReconcileCluster{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, append(tc.machines, cluster)...),
+				scheme:   scheme.Scheme,
+				actuator: NewV1Adapter(&errorActuator{err: tc.actuatorErr}),
+			}
+
+			_, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "foo"}})
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := &v1alpha1.Cluster{}
+			if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo"}, got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Status.ObservedGeneration != 2 {
+				t.Errorf("expected observed generation 2, got %d", got.Status.ObservedGeneration)
+			}
+			for condType, status := range tc.expected {
+				cond := getClusterCondition(got.Status, condType)
+				if cond == nil {
+					t.Errorf("expected condition %q to be set", condType)
+					continue
+				}
+				if cond.Status != status {
+					t.Errorf("expected condition %q to be %q, got %q", condType, status, cond.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileDeletingCondition(t *testing.T) {
+	a := newTestActuator()
+	r := &ReconcileCluster{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, newTestCluster(true)),
+		scheme:   scheme.Scheme,
+		actuator: NewV1Adapter(a),
+	}
+
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "foo"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := &v1alpha1.Cluster{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo"}, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cond := getClusterCondition(got.Status, common.ClusterDeleting)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected condition %q to be true, got %+v", common.ClusterDeleting, cond)
+	}
+	if a.DeleteCallCount != 1 {
+		t.Errorf("expected actuator Delete to be called once, got %d", a.DeleteCallCount)
+	}
+}
+
+func TestSetClusterCondition(t *testing.T) {
+	status := &v1alpha1.ClusterStatus{}
+	first := newClusterCondition(common.ClusterInfrastructureReady, corev1.ConditionFalse, reconcileInProgressReason, "")
+	first.LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	setClusterCondition(status, first)
+
+	setClusterCondition(status, newClusterCondition(common.ClusterInfrastructureReady, corev1.ConditionFalse, reconcileFailedReason, "boom"))
+	cond := getClusterCondition(*status, common.ClusterInfrastructureReady)
+	if cond.Reason != reconcileFailedReason || !cond.LastTransitionTime.Equal(&first.LastTransitionTime) {
+		t.Errorf("expected reason to change and transition time to be kept, got %+v", cond)
+	}
+
+	setClusterCondition(status, newClusterCondition(common.ClusterInfrastructureReady, corev1.ConditionTrue, reconcileSucceededReason, ""))
+	cond = getClusterCondition(*status, common.ClusterInfrastructureReady)
+	if cond.Status != corev1.ConditionTrue || cond.LastTransitionTime.Equal(&first.LastTransitionTime) {
+		t.Errorf("expected status and transition time to change, got %+v", cond)
+	}
+	if len(status.Conditions) != 1 {
+		t.Errorf("expected a single condition, got %d", len(status.Conditions))
+	}
+}
+
+func TestMachineToCluster(t *testing.T) {
+	requests := machineToCluster(handler.MapObject{Object: newControlPlaneMachine("cp-0", false)})
+	if len(requests) != 1 || requests[0].Name != "foo" {
+		t.Errorf("expected a request for cluster %q, got %v", "foo", requests)
+	}
+
+	worker := newControlPlaneMachine("worker", false)
+	worker.Spec.Versions.ControlPlane = ""
+	if requests := machineToCluster(handler.MapObject{Object: worker}); len(requests) != 0 {
+		t.Errorf("expected no requests for a worker machine, got %v", requests)
+	}
+}
+
+func TestReconcileErrorStatus(t *testing.T) {
+	r := &ReconcileCluster{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, newTestCluster(false)),
+		scheme:   scheme.Scheme,
+		actuator: NewV1Adapter(&errorActuator{err: pkgerrors.Wrap(capierrors.InvalidClusterConfiguration("invalid region %q", "moon"), "reconcile failed")}),
+	}
+
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "foo"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Requeue || result.RequeueAfter != 0 {
+		t.Errorf("expected no requeue, got %+v", result)
+	}
+
+	got := &v1alpha1.Cluster{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo"}, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Status.ErrorReason != common.InvalidConfigurationClusterError || got.Status.ErrorMessage != `invalid region "moon"` {
+		t.Errorf("expected the cluster error to be recorded, got %q: %q", got.Status.ErrorReason, got.Status.ErrorMessage)
+	}
+}
+
+func TestSetErrorStatus(t *testing.T) {
+	status := &v1alpha1.ClusterStatus{}
+	setErrorStatus(status, Result{Error: capierrors.CreateCluster("no quota")}, nil)
+	if status.ErrorReason != common.CreateClusterError || status.ErrorMessage != "no quota" {
+		t.Errorf("expected the cluster error to be recorded, got %q: %q", status.ErrorReason, status.ErrorMessage)
+	}
+
+	setErrorStatus(status, Result{}, errors.New("boom"))
+	if status.ErrorReason != common.CreateClusterError {
+		t.Errorf("expected a transient error to keep the cluster error, got %q", status.ErrorReason)
+	}
+
+	setErrorStatus(status, Result{}, nil)
+	if status.ErrorReason != "" || status.ErrorMessage != "" {
+		t.Errorf("expected a success to clear the cluster error, got %q: %q", status.ErrorReason, status.ErrorMessage)
+	}
+}
+
+type resultActuator struct {
+	result Result
+}
+
+func (a *resultActuator) Reconcile(context.Context, *v1alpha1.Cluster) (Result, error) {
+	return a.result, nil
+}
+
+func (a *resultActuator) Delete(context.Context, *v1alpha1.Cluster) (Result, error) {
+	return a.result, nil
+}
+
+func TestReconcileActuatorV2Result(t *testing.T) {
+	endpoints := []v1alpha1.APIEndpoint{{Host: "10.0.0.1", Port: 6443}}
+	r := &ReconcileCluster{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, newTestCluster(false)),
+		scheme:   scheme.Scheme,
+		actuator: &resultActuator{result: Result{RequeueAfter: time.Minute, APIEndpoints: endpoints}},
+	}
+
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "foo"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != time.Minute {
+		t.Errorf("expected a requeue after %s, got %+v", time.Minute, result)
+	}
+
+	got := &v1alpha1.Cluster{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "foo"}, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Status.APIEndpoints, endpoints) {
+		t.Errorf("expected API endpoints %v, got %v", endpoints, got.Status.APIEndpoints)
+	}
+	if cond := getClusterCondition(got.Status, common.ClusterAPIEndpointAvailable); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("expected condition %q to be true, got %+v", common.ClusterAPIEndpointAvailable, cond)
+	}
+	if cond := getClusterCondition(got.Status, common.ClusterInfrastructureReady); cond == nil || cond.Reason != reconcileInProgressReason {
+		t.Errorf("expected condition %q to be in progress, got %+v", common.ClusterInfrastructureReady, cond)
+	}
+}
@@ -19,12 +19,17 @@ package cluster
 import (
 	"context"
 
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
 	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
-	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+	"sigs.k8s.io/cluster-api/pkg/controller/config"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
 	"sigs.k8s.io/cluster-api/pkg/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -37,18 +42,34 @@ import (
 var DefaultActuator Actuator
 
 func AddWithActuator(mgr manager.Manager, actuator Actuator) error {
+	return add(mgr, newReconciler(mgr, NewV1Adapter(actuator)))
+}
+
+// AddWithActuatorV2 adds a cluster controller calling the given ActuatorV2 to mgr.
+func AddWithActuatorV2(mgr manager.Manager, actuator ActuatorV2) error {
 	return add(mgr, newReconciler(mgr, actuator))
 }
 
+// AddWithActuators adds a cluster controller to mgr, which reconciles the clusters of the
+// given providers with their actuator and ignores the other clusters. The provider of a
+// cluster is read from its cluster.k8s.io/provider label. The actuator registered for the
+// empty provider name handles the clusters which don't specify a provider.
+func AddWithActuators(mgr manager.Manager, actuators map[string]ActuatorV2) error {
+	mux := &multiplexer{actuators: actuators}
+	r := newReconciler(mgr, mux)
+	r.handles = mux.handles
+	return add(mgr, r)
+}
+
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager, actuator Actuator) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, actuator ActuatorV2) *ReconcileCluster {
 	return &ReconcileCluster{Client: mgr.GetClient(), scheme: mgr.GetScheme(), actuator: actuator}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("cluster-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("cluster-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: config.ControllerConfig.WorkerCount})
 	if err != nil {
 		return err
 	}
@@ -59,19 +80,45 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to control plane Machines, to keep the ControlPlaneReady condition up to date
+	err = c.Watch(
+		&source.Kind{Type: &clusterv1alpha1.Machine{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(machineToCluster)},
+	)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// machineToCluster maps control plane machines to the cluster they belong to.
+func machineToCluster(o handler.MapObject) []reconcile.Request {
+	m, ok := o.Object.(*clusterv1alpha1.Machine)
+	if !ok || !util.IsControlPlaneMachine(m) {
+		return nil
+	}
+	name, ok := m.Labels[clusterv1alpha1.MachineClusterLabelName]
+	if !ok || name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: m.Namespace, Name: name}}}
+}
+
 var _ reconcile.Reconciler = &ReconcileCluster{}
 
 // ReconcileCluster reconciles a Cluster object
 type ReconcileCluster struct {
 	client.Client
 	scheme   *runtime.Scheme
-	actuator Actuator
+	actuator ActuatorV2
+
+	// handles returns whether the actuator handles the cluster, it handles all clusters if nil.
+	handles func(*clusterv1alpha1.Cluster) bool
 }
 
-// +kubebuilder:rbac:groups=cluster.k8s.io,resources=clusters,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.k8s.io,resources=clusters;clusters/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cluster.k8s.io,resources=machines,verbs=get;list;watch
 func (r *ReconcileCluster) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	cluster := &clusterv1alpha1.Cluster{}
 	err := r.Get(context.Background(), request.NamespacedName, cluster)
@@ -85,6 +132,16 @@ func (r *ReconcileCluster) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
+	if r.handles != nil && !r.handles(cluster) {
+		klog.V(4).Infof("Cluster %q belongs to provider %q, which is not handled by this controller", cluster.Name, clusterProvider(cluster))
+		return reconcile.Result{}, nil
+	}
+
+	if util.IsPaused(cluster) {
+		klog.V(4).Infof("Cluster %q is paused, not reconciling it", cluster.Name)
+		return reconcile.Result{}, nil
+	}
+
 	name := cluster.Name
 	klog.Infof("Running reconcile Cluster for %s\n", name)
 
@@ -109,11 +166,36 @@ func (r *ReconcileCluster) Reconcile(request reconcile.Request) (reconcile.Resul
 			return reconcile.Result{}, nil
 		}
 
+		base := cluster.DeepCopy()
+		cluster.Status.ObservedGeneration = cluster.Generation
+		setClusterCondition(&cluster.Status, newClusterCondition(common.ClusterDeleting, corev1.ConditionTrue, deletionRequestedReason, ""))
+		if err := r.patchStatus(context.Background(), cluster, base); err != nil {
+			klog.Errorf("Error updating status of cluster object %v; %v", name, err)
+			return reconcile.Result{}, err
+		}
+
 		klog.Infof("reconciling cluster object %v triggers delete.", name)
-		if err := r.actuator.Delete(cluster); err != nil {
+		actuatorCtx, cancel := config.ControllerConfig.ActuatorContext(context.Background())
+		result, err := r.actuator.Delete(actuatorCtx, cluster)
+		cancel()
+		if err != nil {
 			klog.Errorf("Error deleting cluster object %v; %v", name, err)
 			return reconcile.Result{}, err
 		}
+		if result.Error != nil {
+			base := cluster.DeepCopy()
+			setErrorStatus(&cluster.Status, result, nil)
+			if statusErr := r.patchStatus(context.Background(), cluster, base); statusErr != nil {
+				klog.Errorf("Error updating status of cluster object %v; %v", name, statusErr)
+				return reconcile.Result{}, statusErr
+			}
+			klog.Errorf("Error deleting cluster object %v, not retrying until the cluster changes; %v", name, result.Error)
+			return reconcile.Result{}, nil
+		}
+		if result.RequeueAfter > 0 {
+			klog.Infof("Deleting cluster object %v is in progress, requeuing in %s", name, result.RequeueAfter)
+			return reconcile.Result{RequeueAfter: result.RequeueAfter}, nil
+		}
 		// Remove finalizer on successful deletion.
 		klog.Infof("cluster object %v deletion successful, removing finalizer.", name)
 		cluster.ObjectMeta.Finalizers = util.Filter(cluster.ObjectMeta.Finalizers, clusterv1.ClusterFinalizer)
@@ -125,14 +207,75 @@ func (r *ReconcileCluster) Reconcile(request reconcile.Request) (reconcile.Resul
 	}
 
 	klog.Infof("reconciling cluster object %v triggers idempotent reconcile.", name)
-	err = r.actuator.Reconcile(cluster)
+	actuatorCtx, cancel := config.ControllerConfig.ActuatorContext(context.Background())
+	result, err := r.actuator.Reconcile(actuatorCtx, cluster)
+	cancel()
+	if statusErr := r.updateStatus(context.Background(), cluster, result, err); statusErr != nil {
+		klog.Errorf("Error updating status of cluster object %v; %v", name, statusErr)
+		return reconcile.Result{}, statusErr
+	}
 	if err != nil {
-		if requeueErr, ok := err.(*controllerError.RequeueAfterError); ok {
-			klog.Infof("Actuator returned requeue after error: %v", requeueErr)
-			return reconcile.Result{Requeue: true, RequeueAfter: requeueErr.RequeueAfter}, nil
-		}
 		klog.Errorf("Error reconciling cluster object %v; %v", name, err)
 		return reconcile.Result{}, err
 	}
+	if result.Error != nil {
+		klog.Errorf("Error reconciling cluster object %v, not retrying until the cluster changes; %v", name, result.Error)
+		return reconcile.Result{}, nil
+	}
+	if result.RequeueAfter > 0 {
+		klog.Infof("Reconciling cluster object %v is in progress, requeuing in %s", name, result.RequeueAfter)
+		return reconcile.Result{RequeueAfter: result.RequeueAfter}, nil
+	}
 	return reconcile.Result{}, nil
 }
+
+// updateStatus sets the observed generation, the provider status, the error and the conditions
+// of the cluster from the result of the actuator's Reconcile and the state of its control plane
+// machines.
+func (r *ReconcileCluster) updateStatus(ctx context.Context, cluster *clusterv1alpha1.Cluster, result Result, reconcileErr error) error {
+	machines, err := r.getClusterMachines(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	base := cluster.DeepCopy()
+	cluster.Status.ObservedGeneration = cluster.Generation
+	setProviderStatus(&cluster.Status, result)
+	setErrorStatus(&cluster.Status, result, reconcileErr)
+	setClusterCondition(&cluster.Status, infrastructureReadyCondition(result, reconcileErr))
+	setClusterCondition(&cluster.Status, apiEndpointAvailableCondition(cluster))
+	setClusterCondition(&cluster.Status, controlPlaneReadyCondition(machines))
+	setClusterCondition(&cluster.Status, newClusterCondition(common.ClusterDeleting, corev1.ConditionFalse, "", ""))
+	return r.patchStatus(ctx, cluster, base)
+}
+
+// clusterError returns the ClusterError reported by an Actuator, if any.
+func clusterError(err error) (*capierrors.ClusterError, bool) {
+	clusterErr, ok := pkgerrors.Cause(err).(*capierrors.ClusterError)
+	return clusterErr, ok && clusterErr != nil
+}
+
+// setErrorStatus records the terminal error returned by the actuator in the ErrorReason and
+// ErrorMessage of the cluster, or clears them once the actuator succeeds. Transient errors
+// leave them untouched.
+func setErrorStatus(status *clusterv1alpha1.ClusterStatus, result Result, err error) {
+	switch {
+	case result.Error != nil:
+		status.ErrorReason = result.Error.Reason
+		status.ErrorMessage = result.Error.Message
+	case err == nil:
+		status.ErrorReason = ""
+		status.ErrorMessage = ""
+	}
+}
+
+// setProviderStatus records the provider status and API endpoints returned by the actuator
+// in the status of the cluster.
+func setProviderStatus(status *clusterv1alpha1.ClusterStatus, result Result) {
+	if result.ProviderStatus != nil {
+		status.ProviderStatus = result.ProviderStatus
+	}
+	if result.APIEndpoints != nil {
+		status.APIEndpoints = result.APIEndpoints
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcilePaused(t *testing.T) {
+	cluster := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "paused",
+			Namespace:   "default",
+			Finalizers:  []string{v1alpha1.ClusterFinalizer},
+			Annotations: map[string]string{v1alpha1.PausedAnnotation: "true"},
+		},
+	}
+	act := newTestActuator()
+	r := &ReconcileCluster{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, cluster),
+		scheme:   scheme.Scheme,
+		actuator: NewV1Adapter(act),
+	}
+
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "paused"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c := &v1alpha1.Cluster{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "paused"}, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if act.ReconcileCallCount != 0 || len(c.Status.Conditions) != 0 {
+		t.Errorf("expected the paused cluster to be left untouched, got %+v", c)
+	}
+}
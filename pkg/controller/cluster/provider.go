@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// clusterProvider returns the name of the provider of the cluster, from its provider label.
+func clusterProvider(cluster *clusterv1.Cluster) string {
+	return cluster.Labels[clusterv1.ProviderLabelName]
+}
+
+// multiplexer is an ActuatorV2 routing each cluster to the actuator of its
+// provider. The actuator registered for the empty provider name handles the
+// clusters which don't specify a provider.
+type multiplexer struct {
+	actuators map[string]ActuatorV2
+}
+
+var _ ActuatorV2 = &multiplexer{}
+
+func (a *multiplexer) Reconcile(ctx context.Context, cluster *clusterv1.Cluster) (Result, error) {
+	actuator, err := a.actuatorFor(cluster)
+	if err != nil {
+		return Result{}, err
+	}
+	return actuator.Reconcile(ctx, cluster)
+}
+
+func (a *multiplexer) Delete(ctx context.Context, cluster *clusterv1.Cluster) (Result, error) {
+	actuator, err := a.actuatorFor(cluster)
+	if err != nil {
+		return Result{}, err
+	}
+	return actuator.Delete(ctx, cluster)
+}
+
+func (a *multiplexer) handles(cluster *clusterv1.Cluster) bool {
+	_, ok := a.actuators[clusterProvider(cluster)]
+	return ok
+}
+
+func (a *multiplexer) actuatorFor(cluster *clusterv1.Cluster) (ActuatorV2, error) {
+	provider := clusterProvider(cluster)
+	actuator, ok := a.actuators[provider]
+	if !ok {
+		return nil, errors.Errorf("no actuator registered for provider %q of cluster %q", provider, cluster.Name)
+	}
+	return actuator, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newProviderCluster(name, provider string) *v1alpha1.Cluster {
+	c := &v1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Finalizers: []string{v1alpha1.ClusterFinalizer}},
+	}
+	if provider != "" {
+		c.Labels = map[string]string{v1alpha1.ProviderLabelName: provider}
+	}
+	return c
+}
+
+func TestReconcileRoutesByProvider(t *testing.T) {
+	vsphere, fallback := newTestActuator(), newTestActuator()
+	mux := &multiplexer{actuators: map[string]ActuatorV2{
+		"vsphere": NewV1Adapter(vsphere),
+		"":        NewV1Adapter(fallback),
+	}}
+	unhandled := newProviderCluster("aws", "aws")
+	unhandled.Finalizers = nil
+	r := &ReconcileCluster{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, newProviderCluster("vsphere", "vsphere"), newProviderCluster("default", ""), unhandled),
+		scheme:   scheme.Scheme,
+		actuator: mux,
+		handles:  mux.handles,
+	}
+
+	for _, name := range []string{"vsphere", "default", "aws"} {
+		if _, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: name}}); err != nil {
+			t.Fatalf("unexpected error reconciling cluster %q: %v", name, err)
+		}
+	}
+
+	if vsphere.ReconcileCallCount != 1 || fallback.ReconcileCallCount != 1 {
+		t.Errorf("expected each actuator to reconcile one cluster, got %d and %d", vsphere.ReconcileCallCount, fallback.ReconcileCallCount)
+	}
+
+	c := &v1alpha1.Cluster{}
+	if err := r.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "aws"}, c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.Finalizers) != 0 || len(c.Status.Conditions) != 0 {
+		t.Errorf("expected the cluster of an unhandled provider to be left untouched, got %+v", c)
+	}
+
+	if _, err := mux.Reconcile(context.TODO(), unhandled); err == nil {
+		t.Error("expected an error for a cluster of an unhandled provider")
+	}
+}
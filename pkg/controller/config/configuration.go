@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"context"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -52,11 +53,18 @@ type LeaderElectionConfiguration struct {
 	// ResourceLock indicates the resource object type that will be used to lock
 	// during leader election cycles.
 	ResourceLock string
+	// ResourceName indicates the name of resource object that will be used to lock
+	// during leader election cycles.
+	ResourceName string
+	// ResourceNamespace indicates the namespace of resource object that will be used to lock
+	// during leader election cycles. It defaults to the namespace the component runs in.
+	ResourceNamespace string
 }
 
 type Configuration struct {
 	Kubeconfig           string
 	WorkerCount          int
+	ActuatorTimeout      time.Duration
 	leaderElectionConfig *LeaderElectionConfiguration
 }
 
@@ -67,16 +75,22 @@ const (
 	DefaultRenewDeadline = 10 * time.Second
 	// DefaultRetryPeriod is the default retry period for leader election
 	DefaultRetryPeriod = 2 * time.Second
+	// DefaultResourceName is the default name of the leader election lock
+	DefaultResourceName = "cluster-api-controller-manager"
+	// DefaultActuatorTimeout is the default deadline of the calls to the actuators
+	DefaultActuatorTimeout = 10 * time.Minute
 )
 
 var ControllerConfig = Configuration{
-	WorkerCount: 5, // Default 5 worker.
+	WorkerCount:     5, // Default 5 worker.
+	ActuatorTimeout: DefaultActuatorTimeout,
 	leaderElectionConfig: &LeaderElectionConfiguration{
 		LeaderElect:   false,
 		LeaseDuration: metav1.Duration{Duration: DefaultLeaseDuration},
 		RenewDeadline: metav1.Duration{Duration: DefaultRenewDeadline},
 		RetryPeriod:   metav1.Duration{Duration: DefaultRetryPeriod},
-		ResourceLock:  resourcelock.EndpointsResourceLock,
+		ResourceLock:  resourcelock.LeasesResourceLock,
+		ResourceName:  DefaultResourceName,
 	},
 }
 
@@ -87,10 +101,20 @@ func GetLeaderElectionConfig() *LeaderElectionConfiguration {
 func (c *Configuration) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&c.Kubeconfig, "kubeconfig", c.Kubeconfig, "Path to kubeconfig file with authorization and control plane location information.")
 	fs.IntVar(&c.WorkerCount, "workers", c.WorkerCount, "The number of workers for controller.")
+	fs.DurationVar(&c.ActuatorTimeout, "actuator-timeout", c.ActuatorTimeout, "The deadline of each call to the actuators, after which their context is cancelled. Zero means no deadline.")
 
 	AddLeaderElectionFlags(c.leaderElectionConfig, fs)
 }
 
+// ActuatorContext returns a copy of ctx which is cancelled once the actuator timeout
+// has passed, to be passed to the actuators.
+func (c *Configuration) ActuatorContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.ActuatorTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.ActuatorTimeout)
+}
+
 func AddLeaderElectionFlags(l *LeaderElectionConfiguration, fs *pflag.FlagSet) {
 	fs.BoolVar(&l.LeaderElect, "leader-elect", l.LeaderElect, ""+
 		"Start a leader election client and gain leadership before "+
@@ -111,5 +135,11 @@ func AddLeaderElectionFlags(l *LeaderElectionConfiguration, fs *pflag.FlagSet) {
 		"of a leadership. This is only applicable if leader election is enabled.")
 	fs.StringVar(&l.ResourceLock, "leader-elect-resource-lock", l.ResourceLock, ""+
 		"The type of resource object that is used for locking during "+
-		"leader election. Supported options are `endpoints` (default) and `configmap`.")
+		"leader election. Supported options are `endpoints`, `configmaps` and `leases` (default).")
+	fs.StringVar(&l.ResourceName, "leader-elect-resource-name", l.ResourceName, ""+
+		"The name of resource object that is used for locking during "+
+		"leader election.")
+	fs.StringVar(&l.ResourceNamespace, "leader-elect-resource-namespace", l.ResourceNamespace, ""+
+		"The namespace of resource object that is used for locking during "+
+		"leader election. Defaults to the namespace the controller runs in.")
 }
@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// inClusterNamespacePath is the file containing the namespace of the pod the component runs in.
+	inClusterNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+	// defaultResourceNamespace is the namespace of the leader election lock when running out of cluster.
+	defaultResourceNamespace = "kube-system"
+)
+
+// NewLeaderElectionConfig returns the client-go leader election configuration described by l, using
+// a lock of type l.ResourceLock held under a unique identity derived from the host name. The callbacks
+// are left to the caller.
+func (l *LeaderElectionConfiguration) NewLeaderElectionConfig(cfg *rest.Config) (*leaderelection.LeaderElectionConfig, error) {
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create leader election client")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get hostname")
+	}
+	id := hostname + "_" + string(uuid.NewUUID())
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: l.ResourceName})
+
+	lock, err := resourcelock.New(l.ResourceLock,
+		l.resourceNamespace(),
+		l.ResourceName,
+		client.CoreV1(),
+		client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity:      id,
+			EventRecorder: recorder,
+		})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create leader election lock")
+	}
+
+	return &leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   l.LeaseDuration.Duration,
+		RenewDeadline:   l.RenewDeadline.Duration,
+		RetryPeriod:     l.RetryPeriod.Duration,
+		ReleaseOnCancel: true,
+		Name:            l.ResourceName,
+	}, nil
+}
+
+// resourceNamespace returns the namespace of the leader election lock, which defaults to the
+// namespace the component runs in.
+func (l *LeaderElectionConfiguration) resourceNamespace() string {
+	if l.ResourceNamespace != "" {
+		return l.ResourceNamespace
+	}
+	if data, err := ioutil.ReadFile(inClusterNamespacePath); err == nil {
+		if ns := strings.TrimSpace(string(data)); ns != "" {
+			return ns
+		}
+	}
+	return defaultResourceNamespace
+}
@@ -0,0 +1,27 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import "sigs.k8s.io/cluster-api/pkg/actuator"
+
+// ActuatorV2 controls machines on a specific infrastructure, returning a Result. It
+// is defined in package actuator, which providers can import without depending on
+// controller-runtime.
+type ActuatorV2 = actuator.Machine
+
+// Result is the outcome of an operation of an ActuatorV2.
+type Result = actuator.MachineResult
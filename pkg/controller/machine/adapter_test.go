@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+)
+
+func TestResultFromError(t *testing.T) {
+	transientErr := errors.New("boom")
+
+	testcases := []struct {
+		name                 string
+		err                  error
+		expectedRequeueAfter time.Duration
+		expectedReason       common.MachineStatusError
+		expectedErr          error
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:                 "requeue",
+			err:                  &controllerError.RequeueAfterError{RequeueAfter: time.Minute},
+			expectedRequeueAfter: time.Minute,
+		},
+		{
+			name:                 "wrapped requeue",
+			err:                  errors.Wrap(&controllerError.RequeueAfterError{RequeueAfter: time.Minute}, "waiting"),
+			expectedRequeueAfter: time.Minute,
+		},
+		{
+			name:           "wrapped machine error",
+			err:            errors.Wrap(capierrors.CreateMachine("no quota"), "create failed"),
+			expectedReason: common.CreateMachineError,
+		},
+		{
+			name:        "transient error",
+			err:         transientErr,
+			expectedErr: transientErr,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := resultFromError(tc.err)
+			if err != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if result.RequeueAfter != tc.expectedRequeueAfter {
+				t.Errorf("expected requeue after %s, got %s", tc.expectedRequeueAfter, result.RequeueAfter)
+			}
+			if tc.expectedReason == "" && result.Error != nil {
+				t.Errorf("expected no terminal error, got %v", result.Error)
+			}
+			if tc.expectedReason != "" && (result.Error == nil || result.Error.Reason != tc.expectedReason) {
+				t.Errorf("expected a terminal error with reason %q, got %v", tc.expectedReason, result.Error)
+			}
+		})
+	}
+}
@@ -24,9 +24,10 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
-	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+	"sigs.k8s.io/cluster-api/pkg/controller/config"
 	"sigs.k8s.io/cluster-api/pkg/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -43,16 +44,34 @@ const (
 var DefaultActuator Actuator
 
 func AddWithActuator(mgr manager.Manager, actuator Actuator) error {
+	return add(mgr, newReconciler(mgr, NewV1Adapter(actuator)))
+}
+
+// AddWithActuatorV2 adds a machine controller calling the given ActuatorV2 to mgr.
+func AddWithActuatorV2(mgr manager.Manager, actuator ActuatorV2) error {
 	return add(mgr, newReconciler(mgr, actuator))
 }
 
+// AddWithActuators adds a machine controller to mgr, which reconciles the machines of
+// the given providers with their actuator and ignores the other machines. The provider
+// of a machine is read from its cluster.k8s.io/provider label, or else from the
+// reference to its MachineClass. The actuator registered for the empty provider name
+// handles the machines which don't specify a provider.
+func AddWithActuators(mgr manager.Manager, actuators map[string]ActuatorV2) error {
+	mux := &multiplexer{actuators: actuators}
+	r := newReconciler(mgr, mux)
+	r.handles = mux.handles
+	return add(mgr, r)
+}
+
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager, actuator Actuator) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, actuator ActuatorV2) *ReconcileMachine {
 	r := &ReconcileMachine{
-		Client:   mgr.GetClient(),
-		scheme:   mgr.GetScheme(),
-		nodeName: os.Getenv(NodeNameEnvVar),
-		actuator: actuator,
+		Client:     mgr.GetClient(),
+		kubeClient: kubernetes.NewForConfigOrDie(mgr.GetConfig()),
+		scheme:     mgr.GetScheme(),
+		nodeName:   os.Getenv(NodeNameEnvVar),
+		actuator:   &instrumentedActuator{actuator: actuator},
 	}
 
 	if r.nodeName == "" {
@@ -65,7 +84,7 @@ func newReconciler(mgr manager.Manager, actuator Actuator) reconcile.Reconciler
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("machine-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("machine-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: config.ControllerConfig.WorkerCount})
 	if err != nil {
 		return err
 	}
@@ -82,7 +101,13 @@ type ReconcileMachine struct {
 	client.Client
 	scheme *runtime.Scheme
 
-	actuator Actuator
+	// kubeClient is used to list the pods of a node and evict them while draining it.
+	kubeClient kubernetes.Interface
+
+	actuator ActuatorV2
+
+	// handles returns whether the actuator handles the machine, it handles all machines if nil.
+	handles func(*clusterv1.Machine) bool
 
 	// nodeName is the name of the node on which the machine controller is running, if not present, it is loaded from NODE_NAME.
 	nodeName string
@@ -91,6 +116,9 @@ type ReconcileMachine struct {
 // Reconcile reads that state of the cluster for a Machine object and makes changes based on the state read
 // and what is in the Machine.Spec
 // +kubebuilder:rbac:groups=cluster.k8s.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
 func (r *ReconcileMachine) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	// TODO(mvladev): Can context be passed from Kubebuilder?
 	ctx := context.TODO()
@@ -108,6 +136,16 @@ func (r *ReconcileMachine) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, err
 	}
 
+	if r.handles != nil && !r.handles(m) {
+		klog.V(4).Infof("Machine %q belongs to provider %q, which is not handled by this controller", m.Name, machineProvider(m))
+		return reconcile.Result{}, nil
+	}
+
+	if util.IsPaused(m) {
+		klog.V(4).Infof("Machine %q is paused, not reconciling it", m.Name)
+		return reconcile.Result{}, nil
+	}
+
 	// Implement controller logic here
 	name := m.Name
 	klog.Infof("Reconciling Machine %q", name)
@@ -150,6 +188,13 @@ func (r *ReconcileMachine) Reconcile(request reconcile.Request) (reconcile.Resul
 				return reconcile.Result{}, err
 			}
 
+			if m.Status.Phase == nil {
+				if err := r.updateStatus(ctx, m, clusterv1.MachinePhasePending, "", Result{}, nil); err != nil {
+					klog.Errorf("Failed to update status of machine %q: %v", name, err)
+					return reconcile.Result{}, err
+				}
+			}
+
 			// Since adding the finalizer updates the object return to avoid later update issues
 			return reconcile.Result{}, nil
 		}
@@ -167,16 +212,43 @@ func (r *ReconcileMachine) Reconcile(request reconcile.Request) (reconcile.Resul
 			return reconcile.Result{}, nil
 		}
 
-		klog.Infof("Reconciling machine %q triggers delete", name)
-		if err := r.actuator.Delete(ctx, cluster, m); err != nil {
-			if requeueErr, ok := err.(*controllerError.RequeueAfterError); ok {
-				klog.Infof("Actuator returned requeue-after error: %v", requeueErr)
-				return reconcile.Result{Requeue: true, RequeueAfter: requeueErr.RequeueAfter}, nil
+		if shouldDrainNode(m) {
+			result, err := resultFromError(r.drainNode(ctx, m.Status.NodeRef.Name))
+			if err != nil || result.RequeueAfter > 0 {
+				if statusErr := r.updateStatus(ctx, m, clusterv1.MachinePhaseDeleting, clusterv1.MachineOperationDelete, result, err); statusErr != nil {
+					klog.Errorf("Failed to update status of machine %q: %v", name, statusErr)
+				}
+
+				if err != nil {
+					klog.Errorf("Failed to drain node %q for machine %q: %v", m.Status.NodeRef.Name, name, err)
+					return reconcile.Result{}, err
+				}
+
+				klog.Infof("Draining node %q for machine %q is not finished, requeuing in %s", m.Status.NodeRef.Name, name, result.RequeueAfter)
+				return reconcile.Result{RequeueAfter: result.RequeueAfter}, nil
 			}
+		}
 
+		klog.Infof("Reconciling machine %q triggers delete", name)
+		actuatorCtx, cancel := config.ControllerConfig.ActuatorContext(ctx)
+		result, deleteErr := r.actuator.Delete(actuatorCtx, cluster, m)
+		cancel()
+		if err := r.updateStatus(ctx, m, clusterv1.MachinePhaseDeleting, clusterv1.MachineOperationDelete, result, deleteErr); err != nil {
+			klog.Errorf("Failed to update status of machine %q: %v", name, err)
+			return reconcile.Result{}, err
+		}
+		if err := deleteErr; err != nil {
 			klog.Errorf("Failed to delete machine %q: %v", name, err)
 			return reconcile.Result{}, err
 		}
+		if result.Error != nil {
+			klog.Errorf("Failed to delete machine %q, not retrying until the machine changes: %v", name, result.Error)
+			return reconcile.Result{}, nil
+		}
+		if result.RequeueAfter > 0 {
+			klog.Infof("Deleting machine %q is in progress, requeuing in %s", name, result.RequeueAfter)
+			return reconcile.Result{RequeueAfter: result.RequeueAfter}, nil
+		}
 
 		if m.Status.NodeRef != nil {
 			klog.Infof("Deleting node %q for machine %q", m.Status.NodeRef.Name, m.Name)
@@ -197,7 +269,15 @@ func (r *ReconcileMachine) Reconcile(request reconcile.Request) (reconcile.Resul
 		return reconcile.Result{}, nil
 	}
 
-	exist, err := r.actuator.Exists(ctx, cluster, m)
+	startupRequeueAfter, err := r.checkNodeStartup(ctx, m)
+	if err != nil {
+		klog.Errorf("Failed to update status of machine %q: %v", name, err)
+		return reconcile.Result{}, err
+	}
+
+	actuatorCtx, cancel := config.ControllerConfig.ActuatorContext(ctx)
+	exist, err := r.actuator.Exists(actuatorCtx, cluster, m)
+	cancel()
 	if err != nil {
 		klog.Errorf("Failed to check if machine %q exists: %v", name, err)
 		return reconcile.Result{}, err
@@ -205,32 +285,56 @@ func (r *ReconcileMachine) Reconcile(request reconcile.Request) (reconcile.Resul
 
 	if exist {
 		klog.Infof("Reconciling machine %q triggers idempotent update", name)
-		if err := r.actuator.Update(ctx, cluster, m); err != nil {
-			if requeueErr, ok := err.(*controllerError.RequeueAfterError); ok {
-				klog.Infof("Actuator returned requeue-after error: %v", requeueErr)
-				return reconcile.Result{Requeue: true, RequeueAfter: requeueErr.RequeueAfter}, nil
-			}
-
+		actuatorCtx, cancel := config.ControllerConfig.ActuatorContext(ctx)
+		result, updateErr := r.actuator.Update(actuatorCtx, cluster, m)
+		cancel()
+		if err := r.updateStatus(ctx, m, clusterv1.MachinePhaseProvisioned, clusterv1.MachineOperationUpdate, result, updateErr); err != nil {
+			klog.Errorf("Failed to update status of machine %q: %v", name, err)
+			return reconcile.Result{}, err
+		}
+		if err := updateErr; err != nil {
 			klog.Errorf(`Error updating machine "%s/%s": %v`, m.Namespace, name, err)
 			return reconcile.Result{}, err
 		}
+		if result.Error != nil {
+			klog.Errorf("Failed to update machine %q, not retrying until the machine changes: %v", name, result.Error)
+			return reconcile.Result{}, nil
+		}
+		if result.RequeueAfter > 0 {
+			klog.Infof("Updating machine %q is in progress, requeuing in %s", name, result.RequeueAfter)
+			return reconcile.Result{RequeueAfter: result.RequeueAfter}, nil
+		}
 
-		return reconcile.Result{}, nil
+		return reconcile.Result{RequeueAfter: startupRequeueAfter}, nil
 	}
 
 	// Machine resource created. Machine does not yet exist.
 	klog.Infof("Reconciling machine object %v triggers idempotent create.", m.ObjectMeta.Name)
-	if err := r.actuator.Create(ctx, cluster, m); err != nil {
-		if requeueErr, ok := err.(*controllerError.RequeueAfterError); ok {
-			klog.Infof("Actuator returned requeue-after error: %v", requeueErr)
-			return reconcile.Result{Requeue: true, RequeueAfter: requeueErr.RequeueAfter}, nil
-		}
-
+	actuatorCtx, cancel = config.ControllerConfig.ActuatorContext(ctx)
+	result, createErr := r.actuator.Create(actuatorCtx, cluster, m)
+	cancel()
+	phase := clusterv1.MachinePhaseProvisioned
+	if createErr != nil || result.Error != nil || result.RequeueAfter > 0 {
+		phase = clusterv1.MachinePhaseProvisioning
+	}
+	if err := r.updateStatus(ctx, m, phase, clusterv1.MachineOperationCreate, result, createErr); err != nil {
+		klog.Errorf("Failed to update status of machine %q: %v", name, err)
+		return reconcile.Result{}, err
+	}
+	if err := createErr; err != nil {
 		klog.Warningf("Failed to create machine %q: %v", name, err)
 		return reconcile.Result{}, err
 	}
+	if result.Error != nil {
+		klog.Errorf("Failed to create machine %q, not retrying until the machine changes: %v", name, result.Error)
+		return reconcile.Result{}, nil
+	}
+	if result.RequeueAfter > 0 {
+		klog.Infof("Creating machine %q is in progress, requeuing in %s", name, result.RequeueAfter)
+		return reconcile.Result{RequeueAfter: result.RequeueAfter}, nil
+	}
 
-	return reconcile.Result{}, nil
+	return reconcile.Result{RequeueAfter: startupRequeueAfter}, nil
 }
 
 func (r *ReconcileMachine) getCluster(ctx context.Context, machine *clusterv1.Machine) (*clusterv1.Cluster, error) {
@@ -17,6 +17,7 @@ limitations under the License.
 package machine
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -24,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -157,7 +159,7 @@ func TestReconcileRequest(t *testing.T) {
 		r := &ReconcileMachine{
 			Client:   fake.NewFakeClient(&clusterList, &machine1, &machine2, &machine3),
 			scheme:   scheme.Scheme,
-			actuator: act,
+			actuator: NewV1Adapter(act),
 		}
 
 		result, err := r.Reconcile(tc.request)
@@ -191,3 +193,31 @@ func TestReconcileRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestReconcilePaused(t *testing.T) {
+	machine := &v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "paused",
+			Namespace:   "default",
+			Annotations: map[string]string{v1alpha1.PausedAnnotation: "true"},
+		},
+	}
+	act := newTestActuator()
+	r := &ReconcileMachine{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+		scheme:   scheme.Scheme,
+		actuator: NewV1Adapter(act),
+	}
+
+	if _, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "paused"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := &v1alpha1.Machine{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "paused"}, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Finalizers) != 0 || m.Status.Phase != nil || act.CreateCallCount != 0 {
+		t.Errorf("expected the paused machine to be left untouched, got %+v", m)
+	}
+}
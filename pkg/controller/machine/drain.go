@@ -0,0 +1,185 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+	"sigs.k8s.io/cluster-api/pkg/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DrainRetryInterval is how long to wait before checking again whether a node being drained has no pods left.
+const DrainRetryInterval = 20 * time.Second
+
+// shouldDrainNode returns whether the node linked to the given machine must be drained before the machine is deleted.
+func shouldDrainNode(machine *clusterv1.Machine) bool {
+	if machine.Status.NodeRef == nil {
+		return false
+	}
+
+	if _, exclude := machine.Annotations[clusterv1.ExcludeNodeDrainingAnnotation]; exclude {
+		klog.Infof("Skipping drain of node %q for machine %q as it has the %q annotation", machine.Status.NodeRef.Name, machine.Name, clusterv1.ExcludeNodeDrainingAnnotation)
+		return false
+	}
+
+	if drainTimedOut(machine) {
+		klog.Warningf("Draining node %q for machine %q exceeded the timeout, proceeding with the deletion", machine.Status.NodeRef.Name, machine.Name)
+		return false
+	}
+
+	return true
+}
+
+// drainTimedOut returns whether the time set by the drain timeout annotation of the given machine has passed
+// since the machine was marked for deletion.
+func drainTimedOut(machine *clusterv1.Machine) bool {
+	value, ok := machine.Annotations[clusterv1.NodeDrainTimeoutAnnotation]
+	if !ok || machine.DeletionTimestamp == nil {
+		return false
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		klog.Warningf("Ignoring invalid %q annotation %q on machine %q: %v", clusterv1.NodeDrainTimeoutAnnotation, value, machine.Name, err)
+		return false
+	}
+
+	return time.Since(machine.DeletionTimestamp.Time) > timeout
+}
+
+// drainNode cordons the node with the given name and evicts all of its pods, except the ones managed by a
+// DaemonSet and mirror pods. Evictions honour the PodDisruptionBudgets of the pods, so a RequeueAfterError
+// is returned until every pod has been evicted and is gone. The kubelet of a node which is not Ready can't
+// confirm that its pods terminated, so evicted pods past their grace period are not waited for.
+func (r *ReconcileMachine) drainNode(ctx context.Context, name string) error {
+	node := &corev1.Node{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(2).Infof("Node %q not found, skipping drain", name)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get node %q", name)
+	}
+
+	if !node.Spec.Unschedulable {
+		klog.Infof("Cordoning node %q", name)
+		node.Spec.Unschedulable = true
+		if err := r.Client.Update(ctx, node); err != nil {
+			return errors.Wrapf(err, "failed to cordon node %q", name)
+		}
+	}
+
+	pods, err := r.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String(),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list pods on node %q", name)
+	}
+
+	nodeReady := util.IsNodeReady(node)
+	remaining := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !shouldEvictPod(pod) {
+			continue
+		}
+
+		if pod.DeletionTimestamp != nil {
+			if !nodeReady && gracePeriodExpired(pod) {
+				klog.Infof("Not waiting for pod %s/%s stuck terminating on unreachable node %q", pod.Namespace, pod.Name, name)
+				continue
+			}
+			// Already evicted, wait for it to be gone.
+			remaining++
+			continue
+		}
+
+		remaining++
+
+		if err := r.evictPod(pod); err != nil {
+			return err
+		}
+	}
+
+	if remaining > 0 {
+		klog.Infof("Waiting for %d pods to be evicted from node %q", remaining, name)
+		return &controllerError.RequeueAfterError{RequeueAfter: DrainRetryInterval}
+	}
+
+	klog.Infof("Node %q drained", name)
+	return nil
+}
+
+// evictPod evicts the given pod. An eviction refused because of a PodDisruptionBudget is not an error,
+// it is retried when the node is drained again.
+func (r *ReconcileMachine) evictPod(pod *corev1.Pod) error {
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+
+	err := r.kubeClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(eviction)
+	switch {
+	case err == nil:
+		klog.Infof("Evicted pod %s/%s", pod.Namespace, pod.Name)
+		return nil
+	case apierrors.IsNotFound(err):
+		return nil
+	case apierrors.IsTooManyRequests(err):
+		klog.Infof("Eviction of pod %s/%s is blocked by its disruption budget: %v", pod.Namespace, pod.Name, err)
+		return nil
+	default:
+		return errors.Wrapf(err, "failed to evict pod %s/%s", pod.Namespace, pod.Name)
+	}
+}
+
+// gracePeriodExpired returns whether the given pod, which is being deleted, is still there after its
+// termination grace period.
+func gracePeriodExpired(pod *corev1.Pod) bool {
+	gracePeriod := time.Duration(0)
+	if pod.DeletionGracePeriodSeconds != nil {
+		gracePeriod = time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second
+	}
+	return time.Now().After(pod.DeletionTimestamp.Add(gracePeriod))
+}
+
+// shouldEvictPod returns whether the given pod must be evicted to drain its node. Mirror pods can't be
+// evicted through the API server and DaemonSet pods would be recreated on the node straight away.
+func shouldEvictPod(pod *corev1.Pod) bool {
+	if _, isMirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; isMirror {
+		return false
+	}
+
+	if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == "DaemonSet" {
+		return false
+	}
+
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newPod(name string, mutate func(*corev1.Pod)) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node",
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+		},
+	}
+	if mutate != nil {
+		mutate(pod)
+	}
+	return pod
+}
+
+func TestShouldEvictPod(t *testing.T) {
+	isController := true
+	testcases := []struct {
+		name     string
+		pod      *corev1.Pod
+		expected bool
+	}{
+		{
+			name:     "regular pod",
+			pod:      newPod("pod", nil),
+			expected: true,
+		},
+		{
+			name: "mirror pod",
+			pod: newPod("pod", func(p *corev1.Pod) {
+				p.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "mirror"}
+			}),
+		},
+		{
+			name: "daemonset pod",
+			pod: newPod("pod", func(p *corev1.Pod) {
+				p.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds", Controller: &isController}}
+			}),
+		},
+		{
+			name: "replicaset pod",
+			pod: newPod("pod", func(p *corev1.Pod) {
+				p.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", Controller: &isController}}
+			}),
+			expected: true,
+		},
+		{
+			name: "completed pod",
+			pod: newPod("pod", func(p *corev1.Pod) {
+				p.Status.Phase = corev1.PodSucceeded
+			}),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := shouldEvictPod(tc.pod); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestShouldDrainNode(t *testing.T) {
+	deletedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	testcases := []struct {
+		name        string
+		annotations map[string]string
+		nodeRef     *corev1.ObjectReference
+		expected    bool
+	}{
+		{
+			name:     "no node",
+			expected: false,
+		},
+		{
+			name:     "node",
+			nodeRef:  &corev1.ObjectReference{Name: "node"},
+			expected: true,
+		},
+		{
+			name:        "excluded",
+			annotations: map[string]string{v1alpha1.ExcludeNodeDrainingAnnotation: ""},
+			nodeRef:     &corev1.ObjectReference{Name: "node"},
+			expected:    false,
+		},
+		{
+			name:        "timeout not exceeded",
+			annotations: map[string]string{v1alpha1.NodeDrainTimeoutAnnotation: "2h"},
+			nodeRef:     &corev1.ObjectReference{Name: "node"},
+			expected:    true,
+		},
+		{
+			name:        "timeout exceeded",
+			annotations: map[string]string{v1alpha1.NodeDrainTimeoutAnnotation: "10m"},
+			nodeRef:     &corev1.ObjectReference{Name: "node"},
+			expected:    false,
+		},
+		{
+			name:        "invalid timeout",
+			annotations: map[string]string{v1alpha1.NodeDrainTimeoutAnnotation: "soon"},
+			nodeRef:     &corev1.ObjectReference{Name: "node"},
+			expected:    true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "machine",
+					Annotations:       tc.annotations,
+					DeletionTimestamp: &deletedAt,
+				},
+				Status: v1alpha1.MachineStatus{NodeRef: tc.nodeRef},
+			}
+			if got := shouldDrainNode(m); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDrainNode(t *testing.T) {
+	isController := true
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+	daemonSetPod := newPod("daemonset", func(p *corev1.Pod) {
+		p.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds", Controller: &isController}}
+	})
+	kubeClient := kubefake.NewSimpleClientset(newPod("evictable", nil), newPod("blocked", nil), daemonSetPod)
+
+	var evicted []string
+	kubeClient.PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(clienttesting.CreateAction).GetObject().(*policyv1beta1.Eviction)
+		if eviction.Name == "blocked" {
+			return true, nil, apierrors.NewTooManyRequests("disruption budget", 10)
+		}
+		evicted = append(evicted, eviction.Name)
+		return true, nil, kubeClient.Tracker().Delete(schema.GroupVersionResource{Version: "v1", Resource: "pods"}, eviction.Namespace, eviction.Name)
+	})
+
+	r := &ReconcileMachine{
+		Client:     fake.NewFakeClientWithScheme(scheme.Scheme, node),
+		kubeClient: kubeClient,
+		scheme:     scheme.Scheme,
+	}
+
+	err := r.drainNode(context.TODO(), "node")
+	if _, ok := err.(*controllerError.RequeueAfterError); !ok {
+		t.Fatalf("expected a requeue error while a pod is blocked, got %v", err)
+	}
+	if len(evicted) != 1 || evicted[0] != "evictable" {
+		t.Errorf("expected only pod %q to be evicted, got %v", "evictable", evicted)
+	}
+
+	updated := &corev1.Node{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Name: "node"}, updated); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if !updated.Spec.Unschedulable {
+		t.Error("expected node to be cordoned")
+	}
+
+	if err := kubeClient.CoreV1().Pods("default").Delete("blocked", nil); err != nil {
+		t.Fatalf("failed to delete pod: %v", err)
+	}
+	if err := r.drainNode(context.TODO(), "node"); err != nil {
+		t.Errorf("expected node to be drained, got %v", err)
+	}
+}
+
+func TestDrainNodeNotFound(t *testing.T) {
+	r := &ReconcileMachine{
+		Client:     fake.NewFakeClientWithScheme(scheme.Scheme),
+		kubeClient: kubefake.NewSimpleClientset(),
+		scheme:     scheme.Scheme,
+	}
+
+	if err := r.drainNode(context.TODO(), "node"); err != nil {
+		t.Errorf("expected no error for a missing node, got %v", err)
+	}
+}
+
+func TestDrainNodeUnreachable(t *testing.T) {
+	gracePeriod := int64(30)
+	deletedAt := metav1.NewTime(time.Now().Add(-time.Minute))
+	recentlyDeletedAt := metav1.NewTime(time.Now())
+	terminating := func(deletedAt *metav1.Time) func(*corev1.Pod) {
+		return func(p *corev1.Pod) {
+			p.DeletionTimestamp = deletedAt
+			p.DeletionGracePeriodSeconds = &gracePeriod
+		}
+	}
+
+	testcases := []struct {
+		name          string
+		ready         corev1.ConditionStatus
+		pod           *corev1.Pod
+		expectDrained bool
+	}{
+		{
+			name:          "unreachable node, grace period expired",
+			ready:         corev1.ConditionUnknown,
+			pod:           newPod("stuck", terminating(&deletedAt)),
+			expectDrained: true,
+		},
+		{
+			name:          "not ready node, grace period expired",
+			ready:         corev1.ConditionFalse,
+			pod:           newPod("stuck", terminating(&deletedAt)),
+			expectDrained: true,
+		},
+		{
+			name:  "unreachable node, within grace period",
+			ready: corev1.ConditionUnknown,
+			pod:   newPod("terminating", terminating(&recentlyDeletedAt)),
+		},
+		{
+			name:  "ready node, grace period expired",
+			ready: corev1.ConditionTrue,
+			pod:   newPod("terminating", terminating(&deletedAt)),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: tc.ready}},
+				},
+			}
+			r := &ReconcileMachine{
+				Client:     fake.NewFakeClientWithScheme(scheme.Scheme, node),
+				kubeClient: kubefake.NewSimpleClientset(tc.pod),
+				scheme:     scheme.Scheme,
+			}
+
+			err := r.drainNode(context.TODO(), "node")
+			if tc.expectDrained && err != nil {
+				t.Errorf("expected node to be drained, got %v", err)
+			}
+			if _, ok := err.(*controllerError.RequeueAfterError); !tc.expectDrained && !ok {
+				t.Errorf("expected a requeue error while the pod terminates, got %v", err)
+			}
+		})
+	}
+}
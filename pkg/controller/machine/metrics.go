@@ -0,0 +1,113 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// unknownErrorReason is the reason reported for actuator errors that aren't a MachineError.
+	unknownErrorReason = "Unknown"
+)
+
+var (
+	// actuatorOperationDuration is a prometheus metric which keeps track of the
+	// duration of the actuator operations.
+	actuatorOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clusterapi_actuator_operation_duration_seconds",
+		Help:    "Duration of the machine actuator operations",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"operation"})
+
+	// actuatorOperationErrors is a prometheus metric which counts the errors
+	// returned by the actuator operations, by the reason of the MachineError.
+	actuatorOperationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "clusterapi_actuator_operation_errors_total",
+		Help: "Total number of errors returned by the machine actuator operations",
+	}, []string{"operation", "reason"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		actuatorOperationDuration,
+		actuatorOperationErrors,
+	)
+}
+
+// instrumentedActuator records the duration and the errors of the operations
+// of the ActuatorV2 it wraps.
+type instrumentedActuator struct {
+	actuator ActuatorV2
+}
+
+var _ ActuatorV2 = &instrumentedActuator{}
+
+func (a *instrumentedActuator) Create(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (Result, error) {
+	defer observeActuatorOperation("create", time.Now())
+	result, err := a.actuator.Create(ctx, cluster, machine)
+	recordActuatorError("create", result, err)
+	return result, err
+}
+
+func (a *instrumentedActuator) Delete(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (Result, error) {
+	defer observeActuatorOperation("delete", time.Now())
+	result, err := a.actuator.Delete(ctx, cluster, machine)
+	recordActuatorError("delete", result, err)
+	return result, err
+}
+
+func (a *instrumentedActuator) Update(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (Result, error) {
+	defer observeActuatorOperation("update", time.Now())
+	result, err := a.actuator.Update(ctx, cluster, machine)
+	recordActuatorError("update", result, err)
+	return result, err
+}
+
+func (a *instrumentedActuator) Exists(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (bool, error) {
+	defer observeActuatorOperation("exists", time.Now())
+	exists, err := a.actuator.Exists(ctx, cluster, machine)
+	recordActuatorError("exists", Result{}, err)
+	return exists, err
+}
+
+func observeActuatorOperation(operation string, start time.Time) {
+	actuatorOperationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// recordActuatorError counts the error returned by an operation by its reason. Requeue
+// requests are part of the normal operation of an actuator and aren't counted.
+func recordActuatorError(operation string, result Result, err error) {
+	switch {
+	case err != nil:
+		actuatorOperationErrors.WithLabelValues(operation, actuatorErrorReason(err)).Inc()
+	case result.Error != nil:
+		actuatorOperationErrors.WithLabelValues(operation, string(result.Error.Reason)).Inc()
+	}
+}
+
+func actuatorErrorReason(err error) string {
+	if machineErr, ok := machineError(err); ok {
+		return string(machineErr.Reason)
+	}
+	return unknownErrorReason
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+)
+
+func TestRecordActuatorError(t *testing.T) {
+	testcases := []struct {
+		name           string
+		result         Result
+		err            error
+		expectedReason string
+	}{
+		{
+			name:           "terminal error",
+			result:         Result{Error: capierrors.CreateMachine("failed")},
+			expectedReason: "CreateError",
+		},
+		{
+			name:           "wrapped machine error",
+			err:            errors.Wrap(capierrors.InvalidMachineConfiguration("invalid"), "failed"),
+			expectedReason: "InvalidConfiguration",
+		},
+		{
+			name:           "other error",
+			err:            errors.New("failed"),
+			expectedReason: unknownErrorReason,
+		},
+		{
+			name:   "requeue",
+			result: Result{RequeueAfter: time.Second},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			actuatorOperationErrors.Reset()
+
+			recordActuatorError("create", tc.result, tc.err)
+
+			if tc.expectedReason == "" {
+				if count := testutil.ToFloat64(actuatorOperationErrors.WithLabelValues("create", unknownErrorReason)); count != 0 {
+					t.Errorf("expected no errors to be recorded, got %v", count)
+				}
+				return
+			}
+			if count := testutil.ToFloat64(actuatorOperationErrors.WithLabelValues("create", tc.expectedReason)); count != 1 {
+				t.Errorf("expected one error with reason %q to be recorded, got %v", tc.expectedReason, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// nodeStartupTimeLeft returns how long is left before the time set by the node startup timeout annotation of
+// the given machine has passed since the machine was created. ok is false if the machine has no valid timeout,
+// is already linked to a node, is being deleted or already reports an error.
+func nodeStartupTimeLeft(machine *clusterv1.Machine, now time.Time) (left time.Duration, ok bool) {
+	value, set := machine.Annotations[clusterv1.NodeStartupTimeoutAnnotation]
+	if !set || machine.Status.NodeRef != nil || !machine.DeletionTimestamp.IsZero() ||
+		machine.Status.ErrorReason != nil || machine.Status.ErrorMessage != nil {
+		return 0, false
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		klog.Warningf("Ignoring invalid %q annotation %q on machine %q: %v", clusterv1.NodeStartupTimeoutAnnotation, value, machine.Name, err)
+		return 0, false
+	}
+
+	return machine.CreationTimestamp.Add(timeout).Sub(now), true
+}
+
+// checkNodeStartup reports a JoinClusterTimeoutError in the status of the given machine if its node
+// startup timeout elapsed. Otherwise, it returns how long to wait before checking again, or zero if
+// the machine has no node startup timeout.
+func (r *ReconcileMachine) checkNodeStartup(ctx context.Context, m *clusterv1.Machine) (time.Duration, error) {
+	left, ok := nodeStartupTimeLeft(m, time.Now())
+	if !ok {
+		return 0, nil
+	}
+	if left > 0 {
+		return left, nil
+	}
+
+	klog.Warningf("Machine %q did not join the cluster within %s", m.Name, m.Annotations[clusterv1.NodeStartupTimeoutAnnotation])
+	base := m.DeepCopy()
+	reason := common.JoinClusterTimeoutMachineError
+	message := fmt.Sprintf("machine did not join the cluster as a node within %s", m.Annotations[clusterv1.NodeStartupTimeoutAnnotation])
+	m.Status.ErrorReason = &reason
+	m.Status.ErrorMessage = &message
+	setPhase(m, clusterv1.MachinePhaseFailed, nil)
+	return 0, r.patchStatus(ctx, m, base)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNodeStartupTimeLeft(t *testing.T) {
+	now := time.Now()
+	reason := common.CreateMachineError
+
+	testcases := []struct {
+		name         string
+		annotations  map[string]string
+		status       v1alpha1.MachineStatus
+		expectedOk   bool
+		expectedLeft time.Duration
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name:        "invalid annotation",
+			annotations: map[string]string{v1alpha1.NodeStartupTimeoutAnnotation: "soon"},
+		},
+		{
+			name:        "linked to a node",
+			annotations: map[string]string{v1alpha1.NodeStartupTimeoutAnnotation: "20m"},
+			status:      v1alpha1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: "node"}},
+		},
+		{
+			name:        "already failed",
+			annotations: map[string]string{v1alpha1.NodeStartupTimeoutAnnotation: "20m"},
+			status:      v1alpha1.MachineStatus{ErrorReason: &reason},
+		},
+		{
+			name:         "waiting for node",
+			annotations:  map[string]string{v1alpha1.NodeStartupTimeoutAnnotation: "20m"},
+			expectedOk:   true,
+			expectedLeft: 10 * time.Minute,
+		},
+		{
+			name:         "timed out",
+			annotations:  map[string]string{v1alpha1.NodeStartupTimeoutAnnotation: "5m"},
+			expectedOk:   true,
+			expectedLeft: -5 * time.Minute,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "machine",
+					Annotations:       tc.annotations,
+					CreationTimestamp: metav1.NewTime(now.Add(-10 * time.Minute)),
+				},
+				Status: tc.status,
+			}
+
+			left, ok := nodeStartupTimeLeft(machine, now)
+			if ok != tc.expectedOk {
+				t.Fatalf("expected ok %v, got %v", tc.expectedOk, ok)
+			}
+			if left != tc.expectedLeft {
+				t.Errorf("expected %v left, got %v", tc.expectedLeft, left)
+			}
+		})
+	}
+}
+
+func TestReconcileNodeStartupTimeout(t *testing.T) {
+	testcases := []struct {
+		name            string
+		timeout         string
+		expectedFailed  bool
+		expectedRequeue bool
+	}{
+		{
+			name:            "waiting for node",
+			timeout:         "1h",
+			expectedRequeue: true,
+		},
+		{
+			name:           "timed out",
+			timeout:        "5m",
+			expectedFailed: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "machine",
+					Namespace:         "default",
+					Finalizers:        []string{v1alpha1.MachineFinalizer},
+					Annotations:       map[string]string{v1alpha1.NodeStartupTimeoutAnnotation: tc.timeout},
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-10 * time.Minute)),
+				},
+			}
+			act := newTestActuator()
+			act.ExistsValue = true
+			r := &ReconcileMachine{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+				scheme:   scheme.Scheme,
+				actuator: NewV1Adapter(act),
+			}
+
+			key := client.ObjectKey{Namespace: "default", Name: "machine"}
+			result, err := r.Reconcile(reconcile.Request{NamespacedName: key})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if requeue := result.RequeueAfter > 0; requeue != tc.expectedRequeue {
+				t.Errorf("expected requeue %v, got %v", tc.expectedRequeue, result.RequeueAfter)
+			}
+
+			if err := r.Client.Get(context.TODO(), key, machine); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			failed := machine.Status.ErrorReason != nil && *machine.Status.ErrorReason == common.JoinClusterTimeoutMachineError
+			if failed != tc.expectedFailed {
+				t.Errorf("expected failed %v, got status %+v", tc.expectedFailed, machine.Status)
+			}
+			if tc.expectedFailed && machine.Status.GetTypedPhase() != v1alpha1.MachinePhaseFailed {
+				t.Errorf("expected phase %q, got %q", v1alpha1.MachinePhaseFailed, machine.Status.GetTypedPhase())
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+	"sigs.k8s.io/cluster-api/pkg/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// setPhase sets the phase of the machine. The given phase reflects the state of the
+// machine's infrastructure, it is overridden by the deletion of the machine, a terminal
+// error reported in its status, or its link to the given node once it is Ready. The
+// node is nil if the machine isn't linked to one or it is gone.
+func setPhase(m *clusterv1.Machine, phase clusterv1.MachinePhase, node *corev1.Node) {
+	switch {
+	case !m.DeletionTimestamp.IsZero():
+		phase = clusterv1.MachinePhaseDeleting
+	case m.Status.ErrorReason != nil || m.Status.ErrorMessage != nil:
+		phase = clusterv1.MachinePhaseFailed
+	case m.Status.NodeRef != nil && node != nil && util.IsNodeReady(node):
+		phase = clusterv1.MachinePhaseRunning
+	}
+	m.Status.SetTypedPhase(phase)
+}
+
+// setLastOperation records the result of an operation performed by the actuator
+// in the status of the machine. LastUpdated is only changed along with the type,
+// state or description of the operation, so that the idempotent updates of a
+// machine don't update its status every time.
+func setLastOperation(m *clusterv1.Machine, operation clusterv1.MachineOperationType, result Result, err error) {
+	state := clusterv1.MachineOperationSuccessful
+	description := fmt.Sprintf("%s operation succeeded", operation)
+	switch {
+	case err != nil:
+		state = clusterv1.MachineOperationFailed
+		description = fmt.Sprintf("%s operation failed: %v", operation, err)
+	case result.Error != nil:
+		state = clusterv1.MachineOperationFailed
+		description = fmt.Sprintf("%s operation failed: %v", operation, result.Error)
+	case result.RequeueAfter > 0:
+		state = clusterv1.MachineOperationProcessing
+		description = fmt.Sprintf("%s operation in progress", operation)
+	}
+
+	opType, opState := string(operation), string(state)
+	lastOperation := &clusterv1.LastOperation{
+		Type:        &opType,
+		State:       &opState,
+		Description: &description,
+	}
+	if old := m.Status.LastOperation; old != nil {
+		lastOperation.LastUpdated = old.LastUpdated
+		if reflect.DeepEqual(old, lastOperation) {
+			return
+		}
+	}
+
+	now := metav1.Now()
+	lastOperation.LastUpdated = &now
+	m.Status.LastOperation = lastOperation
+}
+
+// machineError returns the MachineError reported by an Actuator, if any.
+func machineError(err error) (*capierrors.MachineError, bool) {
+	machineErr, ok := errors.Cause(err).(*capierrors.MachineError)
+	return machineErr, ok && machineErr != nil
+}
+
+// setErrorStatus records the terminal error returned by the actuator in the
+// ErrorReason and ErrorMessage of the machine, or clears them once the actuator
+// succeeds. Transient errors leave them untouched. The JoinClusterTimeoutError is
+// not reported by the actuator, so a successful operation doesn't clear it.
+func setErrorStatus(m *clusterv1.Machine, result Result, err error) {
+	switch {
+	case result.Error != nil:
+		reason, message := result.Error.Reason, result.Error.Message
+		m.Status.ErrorReason = &reason
+		m.Status.ErrorMessage = &message
+	case err == nil:
+		if m.Status.ErrorReason == nil || *m.Status.ErrorReason != common.JoinClusterTimeoutMachineError {
+			m.Status.ErrorReason = nil
+			m.Status.ErrorMessage = nil
+		}
+	}
+}
+
+// setProviderStatus records the provider status and addresses returned by the
+// actuator in the status of the machine.
+func setProviderStatus(m *clusterv1.Machine, result Result) {
+	if result.ProviderStatus != nil {
+		m.Status.ProviderStatus = result.ProviderStatus
+	}
+	if result.Addresses != nil {
+		m.Status.Addresses = result.Addresses
+	}
+}
+
+// updateStatus sets the phase of the machine and, unless operation is empty, the
+// result of the last operation performed by the actuator, then patches the status
+// of the machine if it changed.
+func (r *ReconcileMachine) updateStatus(ctx context.Context, m *clusterv1.Machine, phase clusterv1.MachinePhase, operation clusterv1.MachineOperationType, result Result, err error) error {
+	base := m.DeepCopy()
+	if operation != "" {
+		setProviderStatus(m, result)
+		setErrorStatus(m, result, err)
+		setLastOperation(m, operation, result, err)
+	}
+	node, err := r.getNode(ctx, m)
+	if err != nil {
+		return err
+	}
+	setPhase(m, phase, node)
+	return r.patchStatus(ctx, m, base)
+}
+
+// getNode returns the node the machine is linked to, or nil if it isn't linked to
+// one or the node is gone.
+func (r *ReconcileMachine) getNode(ctx context.Context, m *clusterv1.Machine) (*corev1.Node, error) {
+	if m.Status.NodeRef == nil {
+		return nil, nil
+	}
+	node := &corev1.Node{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: m.Status.NodeRef.Name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get node %q of machine %q", m.Status.NodeRef.Name, m.Name)
+	}
+	return node, nil
+}
+
+// patchStatus patches the status of the machine if it changed from base. A merge
+// patch is used as actuators may have updated the machine during the reconciliation.
+func (r *ReconcileMachine) patchStatus(ctx context.Context, m, base *clusterv1.Machine) error {
+	if reflect.DeepEqual(m.Status, base.Status) {
+		return nil
+	}
+	return r.Client.Status().Patch(ctx, m, client.MergeFrom(base))
+}
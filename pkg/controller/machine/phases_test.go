@@ -0,0 +1,426 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pkgerrors "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSetPhase(t *testing.T) {
+	now := metav1.Now()
+	reason := common.InvalidConfigurationMachineError
+
+	linked := v1alpha1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: "node"}}
+
+	testcases := []struct {
+		name     string
+		machine  *v1alpha1.Machine
+		node     *corev1.Node
+		phase    v1alpha1.MachinePhase
+		expected v1alpha1.MachinePhase
+	}{
+		{
+			name:     "provisioning",
+			machine:  &v1alpha1.Machine{},
+			phase:    v1alpha1.MachinePhaseProvisioning,
+			expected: v1alpha1.MachinePhaseProvisioning,
+		},
+		{
+			name:     "linked to a ready node",
+			machine:  &v1alpha1.Machine{Status: linked},
+			node:     newNode("node", corev1.ConditionTrue),
+			phase:    v1alpha1.MachinePhaseProvisioned,
+			expected: v1alpha1.MachinePhaseRunning,
+		},
+		{
+			name:     "linked to a not ready node",
+			machine:  &v1alpha1.Machine{Status: linked},
+			node:     newNode("node", corev1.ConditionFalse),
+			phase:    v1alpha1.MachinePhaseProvisioned,
+			expected: v1alpha1.MachinePhaseProvisioned,
+		},
+		{
+			name:     "linked to a missing node",
+			machine:  &v1alpha1.Machine{Status: linked},
+			phase:    v1alpha1.MachinePhaseProvisioned,
+			expected: v1alpha1.MachinePhaseProvisioned,
+		},
+		{
+			name:     "error reason",
+			machine:  &v1alpha1.Machine{Status: v1alpha1.MachineStatus{ErrorReason: &reason}},
+			phase:    v1alpha1.MachinePhaseProvisioned,
+			expected: v1alpha1.MachinePhaseFailed,
+		},
+		{
+			name: "deleted",
+			machine: &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now},
+				Status:     v1alpha1.MachineStatus{ErrorReason: &reason},
+			},
+			phase:    v1alpha1.MachinePhaseProvisioned,
+			expected: v1alpha1.MachinePhaseDeleting,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			setPhase(tc.machine, tc.phase, tc.node)
+			if phase := tc.machine.Status.GetTypedPhase(); phase != tc.expected {
+				t.Errorf("expected phase %q, got %q", tc.expected, phase)
+			}
+		})
+	}
+}
+
+func newNode(name string, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+		},
+	}
+}
+
+func TestSetLastOperation(t *testing.T) {
+	testcases := []struct {
+		name          string
+		result        Result
+		err           error
+		expectedState v1alpha1.MachineOperationState
+	}{
+		{name: "successful", expectedState: v1alpha1.MachineOperationSuccessful},
+		{name: "requeue", result: Result{RequeueAfter: time.Second}, expectedState: v1alpha1.MachineOperationProcessing},
+		{name: "failed", err: errors.New("failed"), expectedState: v1alpha1.MachineOperationFailed},
+		{name: "terminal error", result: Result{Error: capierrors.CreateMachine("failed")}, expectedState: v1alpha1.MachineOperationFailed},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := &v1alpha1.Machine{}
+			setLastOperation(m, v1alpha1.MachineOperationCreate, tc.result, tc.err)
+
+			op := m.Status.LastOperation
+			if op == nil || *op.Type != string(v1alpha1.MachineOperationCreate) || *op.State != string(tc.expectedState) || op.LastUpdated == nil {
+				t.Fatalf("expected a %q create operation, got %+v", tc.expectedState, op)
+			}
+
+			lastUpdated := op.LastUpdated
+			setLastOperation(m, v1alpha1.MachineOperationCreate, tc.result, tc.err)
+			if m.Status.LastOperation.LastUpdated != lastUpdated {
+				t.Error("expected the last operation not to be updated when it didn't change")
+			}
+		})
+	}
+}
+
+func TestReconcileSetsPhase(t *testing.T) {
+	v1alpha1.AddToScheme(scheme.Scheme)
+
+	testcases := []struct {
+		name          string
+		finalizers    []string
+		exists        bool
+		node          *corev1.Node
+		expectedPhase v1alpha1.MachinePhase
+		expectedOp    v1alpha1.MachineOperationType
+	}{
+		{
+			name:          "new machine",
+			expectedPhase: v1alpha1.MachinePhasePending,
+		},
+		{
+			name:          "created machine",
+			finalizers:    []string{v1alpha1.MachineFinalizer},
+			expectedPhase: v1alpha1.MachinePhaseProvisioned,
+			expectedOp:    v1alpha1.MachineOperationCreate,
+		},
+		{
+			name:          "updated machine",
+			finalizers:    []string{v1alpha1.MachineFinalizer},
+			exists:        true,
+			expectedPhase: v1alpha1.MachinePhaseProvisioned,
+			expectedOp:    v1alpha1.MachineOperationUpdate,
+		},
+		{
+			name:          "machine linked to a ready node",
+			finalizers:    []string{v1alpha1.MachineFinalizer},
+			exists:        true,
+			node:          newNode("node", corev1.ConditionTrue),
+			expectedPhase: v1alpha1.MachinePhaseRunning,
+			expectedOp:    v1alpha1.MachineOperationUpdate,
+		},
+		{
+			name:          "machine linked to a not ready node",
+			finalizers:    []string{v1alpha1.MachineFinalizer},
+			exists:        true,
+			node:          newNode("node", corev1.ConditionUnknown),
+			expectedPhase: v1alpha1.MachinePhaseProvisioned,
+			expectedOp:    v1alpha1.MachineOperationUpdate,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default", Finalizers: tc.finalizers},
+			}
+			objs := []runtime.Object{machine}
+			if tc.node != nil {
+				machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: tc.node.Name}
+				objs = append(objs, tc.node)
+			}
+			act := newTestActuator()
+			act.ExistsValue = tc.exists
+			r := &ReconcileMachine{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+				scheme:   scheme.Scheme,
+				actuator: NewV1Adapter(act),
+			}
+
+			key := client.ObjectKey{Namespace: "default", Name: "machine"}
+			if _, err := r.Reconcile(reconcile.Request{NamespacedName: key}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := r.Client.Get(context.TODO(), key, machine); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if phase := machine.Status.GetTypedPhase(); phase != tc.expectedPhase {
+				t.Errorf("expected phase %q, got %q", tc.expectedPhase, phase)
+			}
+			if tc.expectedOp == "" {
+				if machine.Status.LastOperation != nil {
+					t.Errorf("expected no last operation, got %+v", machine.Status.LastOperation)
+				}
+				return
+			}
+			if op := machine.Status.LastOperation; op == nil || *op.Type != string(tc.expectedOp) || *op.State != string(v1alpha1.MachineOperationSuccessful) {
+				t.Errorf("expected a successful %q operation, got %+v", tc.expectedOp, op)
+			}
+		})
+	}
+}
+
+func TestSetErrorStatus(t *testing.T) {
+	updateReason := common.UpdateMachineError
+	timeoutReason := common.JoinClusterTimeoutMachineError
+	message := "previous error"
+
+	m := &v1alpha1.Machine{Status: v1alpha1.MachineStatus{ErrorReason: &updateReason, ErrorMessage: &message}}
+	setErrorStatus(m, Result{}, nil)
+	if m.Status.ErrorReason != nil || m.Status.ErrorMessage != nil {
+		t.Errorf("expected a success to clear the error, got %v: %v", m.Status.ErrorReason, m.Status.ErrorMessage)
+	}
+
+	m = &v1alpha1.Machine{Status: v1alpha1.MachineStatus{ErrorReason: &timeoutReason, ErrorMessage: &message}}
+	setErrorStatus(m, Result{}, nil)
+	if m.Status.ErrorReason == nil || *m.Status.ErrorReason != timeoutReason {
+		t.Errorf("expected a success to keep the join timeout error, got %v", m.Status.ErrorReason)
+	}
+
+	m = &v1alpha1.Machine{}
+	setErrorStatus(m, Result{RequeueAfter: time.Minute}, nil)
+	if m.Status.ErrorReason != nil || m.Status.ErrorMessage != nil {
+		t.Errorf("expected a requeue not to set an error, got %v: %v", m.Status.ErrorReason, m.Status.ErrorMessage)
+	}
+
+	setErrorStatus(m, Result{Error: capierrors.UpdateMachine("instance is gone")}, nil)
+	if !reflect.DeepEqual(m.Status.ErrorReason, &updateReason) || m.Status.ErrorMessage == nil || *m.Status.ErrorMessage != "instance is gone" {
+		t.Errorf("expected a terminal error to be recorded, got %v: %v", m.Status.ErrorReason, m.Status.ErrorMessage)
+	}
+
+	setErrorStatus(m, Result{}, errors.New("boom"))
+	if !reflect.DeepEqual(m.Status.ErrorReason, &updateReason) {
+		t.Errorf("expected a transient error to keep the terminal error, got %v", m.Status.ErrorReason)
+	}
+}
+
+type errorActuator struct {
+	*TestActuator
+	err error
+}
+
+func (a *errorActuator) Update(context.Context, *v1alpha1.Cluster, *v1alpha1.Machine) error {
+	return a.err
+}
+
+func TestReconcileErrorStatus(t *testing.T) {
+	timeoutReason := common.JoinClusterTimeoutMachineError
+	updateReason := common.UpdateMachineError
+	message := "previous error"
+
+	testcases := []struct {
+		name            string
+		reason          *common.MachineStatusError
+		err             error
+		expectedReason  *common.MachineStatusError
+		expectedMessage string
+		expectedPhase   v1alpha1.MachinePhase
+		expectedErr     bool
+	}{
+		{
+			name:            "machine error",
+			err:             capierrors.UpdateMachine("instance %s is gone", "i-1234"),
+			expectedReason:  &updateReason,
+			expectedMessage: "instance i-1234 is gone",
+			expectedPhase:   v1alpha1.MachinePhaseFailed,
+		},
+		{
+			name:            "wrapped machine error",
+			err:             pkgerrors.Wrap(capierrors.UpdateMachine("instance is gone"), "update failed"),
+			expectedReason:  &updateReason,
+			expectedMessage: "instance is gone",
+			expectedPhase:   v1alpha1.MachinePhaseFailed,
+		},
+		{
+			name:            "transient error keeps the previous error",
+			reason:          &updateReason,
+			err:             errors.New("boom"),
+			expectedReason:  &updateReason,
+			expectedMessage: message,
+			expectedPhase:   v1alpha1.MachinePhaseFailed,
+			expectedErr:     true,
+		},
+		{
+			name:            "success keeps the join timeout error",
+			reason:          &timeoutReason,
+			expectedReason:  &timeoutReason,
+			expectedMessage: message,
+			expectedPhase:   v1alpha1.MachinePhaseFailed,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := &v1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default", Finalizers: []string{v1alpha1.MachineFinalizer}},
+			}
+			if tc.reason != nil {
+				machine.Status.ErrorReason = tc.reason
+				machine.Status.ErrorMessage = &message
+			}
+			act := &errorActuator{TestActuator: newTestActuator(), err: tc.err}
+			act.ExistsValue = true
+			r := &ReconcileMachine{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+				scheme:   scheme.Scheme,
+				actuator: NewV1Adapter(act),
+			}
+
+			key := client.ObjectKey{Namespace: "default", Name: "machine"}
+			result, err := r.Reconcile(reconcile.Request{NamespacedName: key})
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if result.Requeue || result.RequeueAfter != 0 {
+				t.Errorf("expected no requeue, got %+v", result)
+			}
+
+			if err := r.Client.Get(context.TODO(), key, machine); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(machine.Status.ErrorReason, tc.expectedReason) {
+				t.Errorf("expected error reason %v, got %v", tc.expectedReason, machine.Status.ErrorReason)
+			}
+			if tc.expectedReason != nil && (machine.Status.ErrorMessage == nil || *machine.Status.ErrorMessage != tc.expectedMessage) {
+				t.Errorf("expected error message %q, got %v", tc.expectedMessage, machine.Status.ErrorMessage)
+			}
+			if tc.expectedReason == nil && machine.Status.ErrorMessage != nil {
+				t.Errorf("expected no error message, got %q", *machine.Status.ErrorMessage)
+			}
+			if phase := machine.Status.GetTypedPhase(); phase != tc.expectedPhase {
+				t.Errorf("expected phase %q, got %q", tc.expectedPhase, phase)
+			}
+		})
+	}
+}
+
+type resultActuator struct {
+	*TestActuator
+	result Result
+}
+
+func (a *resultActuator) Create(context.Context, *v1alpha1.Cluster, *v1alpha1.Machine) (Result, error) {
+	return a.result, nil
+}
+
+func (a *resultActuator) Delete(context.Context, *v1alpha1.Cluster, *v1alpha1.Machine) (Result, error) {
+	return a.result, nil
+}
+
+func (a *resultActuator) Update(context.Context, *v1alpha1.Cluster, *v1alpha1.Machine) (Result, error) {
+	return a.result, nil
+}
+
+func TestReconcileActuatorV2Result(t *testing.T) {
+	addresses := []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}}
+	providerStatus := &runtime.RawExtension{Raw: []byte(`{"instanceState":"pending"}`)}
+
+	machine := &v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default", Finalizers: []string{v1alpha1.MachineFinalizer}},
+	}
+	act := &resultActuator{
+		TestActuator: newTestActuator(),
+		result:       Result{RequeueAfter: time.Minute, ProviderStatus: providerStatus, Addresses: addresses},
+	}
+	r := &ReconcileMachine{
+		Client:   fake.NewFakeClientWithScheme(scheme.Scheme, machine),
+		scheme:   scheme.Scheme,
+		actuator: act,
+	}
+
+	key := client.ObjectKey{Namespace: "default", Name: "machine"}
+	result, err := r.Reconcile(reconcile.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != time.Minute {
+		t.Errorf("expected a requeue after %s, got %+v", time.Minute, result)
+	}
+
+	if err := r.Client.Get(context.TODO(), key, machine); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(machine.Status.Addresses, addresses) {
+		t.Errorf("expected addresses %v, got %v", addresses, machine.Status.Addresses)
+	}
+	if machine.Status.ProviderStatus == nil || string(machine.Status.ProviderStatus.Raw) != string(providerStatus.Raw) {
+		t.Errorf("expected provider status %s, got %v", providerStatus.Raw, machine.Status.ProviderStatus)
+	}
+	if phase := machine.Status.GetTypedPhase(); phase != v1alpha1.MachinePhaseProvisioning {
+		t.Errorf("expected phase %q, got %q", v1alpha1.MachinePhaseProvisioning, phase)
+	}
+	if op := machine.Status.LastOperation; op == nil || *op.State != string(v1alpha1.MachineOperationProcessing) {
+		t.Errorf("expected a create operation in progress, got %+v", op)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// machineProvider returns the name of the provider of the machine, from its
+// provider label or else from the reference to its MachineClass.
+func machineProvider(m *clusterv1.Machine) string {
+	if provider, ok := m.Labels[clusterv1.ProviderLabelName]; ok {
+		return provider
+	}
+	if valueFrom := m.Spec.ProviderSpec.ValueFrom; valueFrom != nil && valueFrom.MachineClass != nil {
+		return valueFrom.MachineClass.Provider
+	}
+	return ""
+}
+
+// multiplexer is an ActuatorV2 routing each machine to the actuator of its
+// provider. The actuator registered for the empty provider name handles the
+// machines which don't specify a provider.
+type multiplexer struct {
+	actuators map[string]ActuatorV2
+}
+
+var _ ActuatorV2 = &multiplexer{}
+
+func (a *multiplexer) Create(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (Result, error) {
+	actuator, err := a.actuatorFor(machine)
+	if err != nil {
+		return Result{}, err
+	}
+	return actuator.Create(ctx, cluster, machine)
+}
+
+func (a *multiplexer) Delete(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (Result, error) {
+	actuator, err := a.actuatorFor(machine)
+	if err != nil {
+		return Result{}, err
+	}
+	return actuator.Delete(ctx, cluster, machine)
+}
+
+func (a *multiplexer) Update(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (Result, error) {
+	actuator, err := a.actuatorFor(machine)
+	if err != nil {
+		return Result{}, err
+	}
+	return actuator.Update(ctx, cluster, machine)
+}
+
+func (a *multiplexer) Exists(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (bool, error) {
+	actuator, err := a.actuatorFor(machine)
+	if err != nil {
+		return false, err
+	}
+	return actuator.Exists(ctx, cluster, machine)
+}
+
+func (a *multiplexer) handles(m *clusterv1.Machine) bool {
+	_, ok := a.actuators[machineProvider(m)]
+	return ok
+}
+
+func (a *multiplexer) actuatorFor(m *clusterv1.Machine) (ActuatorV2, error) {
+	provider := machineProvider(m)
+	actuator, ok := a.actuators[provider]
+	if !ok {
+		return nil, errors.Errorf("no actuator registered for provider %q of machine %q", provider, m.Name)
+	}
+	return actuator, nil
+}
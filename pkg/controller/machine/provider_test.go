@@ -0,0 +1,111 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machine
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newProviderMachine(name, label, classProvider string) *v1alpha1.Machine {
+	m := &v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Finalizers: []string{v1alpha1.MachineFinalizer}},
+	}
+	if label != "" {
+		m.Labels = map[string]string{v1alpha1.ProviderLabelName: label}
+	}
+	if classProvider != "" {
+		m.Spec.ProviderSpec.ValueFrom = &v1alpha1.ProviderSpecSource{
+			MachineClass: &v1alpha1.MachineClassRef{
+				ObjectReference: &corev1.ObjectReference{Name: "class"},
+				Provider:        classProvider,
+			},
+		}
+	}
+	return m
+}
+
+func TestMachineProvider(t *testing.T) {
+	testcases := []struct {
+		name     string
+		machine  *v1alpha1.Machine
+		expected string
+	}{
+		{name: "no provider", machine: newProviderMachine("machine", "", "")},
+		{name: "label", machine: newProviderMachine("machine", "vsphere", ""), expected: "vsphere"},
+		{name: "machine class", machine: newProviderMachine("machine", "", "baremetal"), expected: "baremetal"},
+		{name: "label overrides machine class", machine: newProviderMachine("machine", "vsphere", "baremetal"), expected: "vsphere"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if provider := machineProvider(tc.machine); provider != tc.expected {
+				t.Errorf("expected provider %q, got %q", tc.expected, provider)
+			}
+		})
+	}
+}
+
+func TestReconcileRoutesByProvider(t *testing.T) {
+	vsphere, baremetal := newTestActuator(), newTestActuator()
+	mux := &multiplexer{actuators: map[string]ActuatorV2{
+		"vsphere":   NewV1Adapter(vsphere),
+		"baremetal": NewV1Adapter(baremetal),
+	}}
+	unhandled := newProviderMachine("aws", "aws", "")
+	unhandled.Finalizers = nil
+	r := &ReconcileMachine{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme,
+			newProviderMachine("vsphere", "vsphere", ""),
+			newProviderMachine("baremetal", "", "baremetal"),
+			unhandled,
+		),
+		scheme:   scheme.Scheme,
+		actuator: mux,
+		handles:  mux.handles,
+	}
+
+	for _, name := range []string{"vsphere", "baremetal", "aws"} {
+		if _, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: name}}); err != nil {
+			t.Fatalf("unexpected error reconciling machine %q: %v", name, err)
+		}
+	}
+
+	if vsphere.CreateCallCount != 1 || baremetal.CreateCallCount != 1 {
+		t.Errorf("expected each actuator to create one machine, got %d and %d", vsphere.CreateCallCount, baremetal.CreateCallCount)
+	}
+
+	m := &v1alpha1.Machine{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "aws"}, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Finalizers) != 0 || m.Status.Phase != nil {
+		t.Errorf("expected the machine of an unhandled provider to be left untouched, got %+v", m)
+	}
+
+	if _, err := mux.Exists(context.TODO(), nil, unhandled); err == nil {
+		t.Error("expected an error for a machine of an unhandled provider")
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"k8s.io/klog"
 	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
 	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/controller/config"
 	"sigs.k8s.io/cluster-api/pkg/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -69,7 +70,7 @@ func Add(mgr manager.Manager) error {
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
 func add(mgr manager.Manager, r reconcile.Reconciler, mapFn handler.ToRequestsFunc) error {
 	// Create a new controller.
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: config.ControllerConfig.WorkerCount})
 	if err != nil {
 		return err
 	}
@@ -176,6 +177,12 @@ func (r *ReconcileMachineDeployment) Reconcile(request reconcile.Request) (recon
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+
+	if util.IsPaused(d) {
+		klog.V(4).Infof("MachineDeployment %q is paused, not reconciling it", d.Name)
+		return reconcile.Result{}, nil
+	}
+
 	result, err := r.reconcile(ctx, d)
 	if err != nil {
 		klog.Errorf("Failed to reconcile MachineDeployment %q: %v", request.NamespacedName, err)
@@ -201,7 +208,8 @@ func (r *ReconcileMachineDeployment) reconcile(ctx context.Context, d *v1alpha1.
 	}
 
 	// Make sure that label selector can match template's labels.
-	// TODO(vincepri): Move to a validation (admission) webhook when supported.
+	// This is also enforced by the MachineDeployment validating webhook, but objects
+	// created while the webhook was not deployed still need to be checked.
 	selector, err := metav1.LabelSelectorAsSelector(&d.Spec.Selector)
 	if err != nil {
 		return reconcile.Result{}, errors.Wrapf(err, "failed to parse MachineDeployment %q label selector", d.Name)
@@ -260,13 +268,35 @@ func (r *ReconcileMachineDeployment) reconcile(ctx context.Context, d *v1alpha1.
 		return reconcile.Result{}, r.sync(d, msList, machineMap)
 	}
 
+	// Update deployment conditions with an Unknown condition when pausing/resuming
+	// a deployment. In this way, we can be sure that we won't timeout when a user
+	// resumes a Deployment with a set progressDeadlineSeconds.
+	if err := r.checkPausedConditions(d); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	if d.Spec.Paused {
 		return reconcile.Result{}, r.sync(d, msList, machineMap)
 	}
 
+	// rollback is not re-entrant in case the underlying machine sets are updated with a new
+	// revision so we should ensure that we won't proceed to update machine sets until we
+	// make sure that the deployment has cleaned up its rollback spec in subsequent enqueues.
+	if d.Spec.RollbackTo != nil {
+		return reconcile.Result{}, r.rollback(d, msList)
+	}
+
 	switch d.Spec.Strategy.Type {
 	case common.RollingUpdateMachineDeploymentStrategyType:
-		return reconcile.Result{}, r.rolloutRolling(d, msList, machineMap)
+		if err := r.rolloutRolling(d, msList, machineMap); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: progressCheckAfter(d)}, nil
+	case common.RecreateMachineDeploymentStrategyType:
+		if err := r.rolloutRecreate(d, msList, machineMap); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: progressCheckAfter(d)}, nil
 	}
 
 	return reconcile.Result{}, errors.Errorf("unexpected deployment strategy type: %s", d.Spec.Strategy.Type)
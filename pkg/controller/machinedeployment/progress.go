@@ -0,0 +1,179 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinedeployment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	dutil "sigs.k8s.io/cluster-api/pkg/controller/machinedeployment/util"
+)
+
+// syncProgressingCondition estimates the progress of a rollout and updates the Progressing
+// condition of newStatus accordingly. There are cases this helper will run that cannot be
+// prevented from the scaling detection, for example a resync of the deployment after it was
+// scaled up. In those cases, we shouldn't try to estimate any progress.
+func (r *ReconcileMachineDeployment) syncProgressingCondition(newMS *v1alpha1.MachineSet, d *v1alpha1.MachineDeployment, newStatus *v1alpha1.MachineDeploymentStatus) {
+	// If there is no progressDeadlineSeconds set, remove any Progressing condition.
+	if !dutil.HasProgressDeadline(d) {
+		dutil.RemoveMachineDeploymentCondition(newStatus, common.MachineDeploymentProgressing)
+		return
+	}
+
+	// If there is only one machine set that is active then that means we are not running
+	// a new rollout and this is a resync where we don't need to estimate any progress.
+	// In such a case, we should simply not estimate any progress for this deployment.
+	currentCond := dutil.GetMachineDeploymentCondition(d.Status, common.MachineDeploymentProgressing)
+	isCompleteDeployment := newStatus.Replicas == newStatus.UpdatedReplicas && currentCond != nil && currentCond.Reason == dutil.NewMSAvailableReason
+	if isCompleteDeployment {
+		return
+	}
+
+	switch {
+	case dutil.DeploymentComplete(d, newStatus):
+		// Update the deployment conditions with a message for the new machine set that
+		// was successfully deployed. If the condition already exists, we ignore this update.
+		msg := fmt.Sprintf("MachineDeployment %q has successfully progressed.", d.Name)
+		if newMS != nil {
+			msg = fmt.Sprintf("MachineSet %q has successfully progressed.", newMS.Name)
+		}
+		condition := dutil.NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionTrue, dutil.NewMSAvailableReason, msg)
+		dutil.SetMachineDeploymentCondition(newStatus, *condition)
+
+	case dutil.DeploymentProgressing(d, newStatus):
+		// If there is any progress made, continue by not checking if the deployment failed.
+		msg := fmt.Sprintf("MachineDeployment %q is progressing.", d.Name)
+		if newMS != nil {
+			msg = fmt.Sprintf("MachineSet %q is progressing.", newMS.Name)
+		}
+		condition := dutil.NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionTrue, dutil.MachineSetUpdatedReason, msg)
+		// Update the current Progressing condition or add a new one if it doesn't exist.
+		// If a Progressing condition with status=true already exists, we should update
+		// everything but lastTransitionTime. SetMachineDeploymentCondition already does that but
+		// it also is not updating conditions when the reason of the new condition is the
+		// same as the old. The Progressing condition is a special case because we want to
+		// update with the same reason and change just lastUpdateTime iff we notice any
+		// progress. That's why we handle it here.
+		if currentCond != nil {
+			if currentCond.Status == corev1.ConditionTrue {
+				condition.LastTransitionTime = currentCond.LastTransitionTime
+			}
+			dutil.RemoveMachineDeploymentCondition(newStatus, common.MachineDeploymentProgressing)
+		}
+		dutil.SetMachineDeploymentCondition(newStatus, *condition)
+
+	case dutil.DeploymentTimedOut(d, newStatus):
+		// Update the deployment with a timeout condition. If the condition already exists,
+		// we ignore this update.
+		msg := fmt.Sprintf("MachineDeployment %q has timed out progressing.", d.Name)
+		if newMS != nil {
+			msg = fmt.Sprintf("MachineSet %q has timed out progressing.", newMS.Name)
+		}
+		if currentCond == nil || currentCond.Reason != dutil.TimedOutReason {
+			r.recorder.Event(d, corev1.EventTypeWarning, dutil.TimedOutReason, msg)
+		}
+		condition := dutil.NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionFalse, dutil.TimedOutReason, msg)
+		dutil.SetMachineDeploymentCondition(newStatus, *condition)
+	}
+}
+
+// syncReplicaFailureCondition moves the errors reported by the machine sets of a deployment
+// into its ReplicaFailure condition, or removes the condition if none of them reports an error.
+func syncReplicaFailureCondition(allMSs []*v1alpha1.MachineSet, newStatus *v1alpha1.MachineDeploymentStatus) {
+	for _, ms := range allMSs {
+		// There will be only one ReplicaFailure condition on the deployment.
+		if cond := dutil.MachineSetToMachineDeploymentCondition(ms); cond != nil {
+			dutil.SetMachineDeploymentCondition(newStatus, *cond)
+			return
+		}
+	}
+
+	dutil.RemoveMachineDeploymentCondition(newStatus, common.MachineDeploymentReplicaFailure)
+}
+
+// checkPausedConditions checks if the given deployment is paused or not and adds an appropriate condition.
+// These conditions are needed so that we won't accidentally report lack of progress for resumed deployments
+// that were paused for longer than progressDeadlineSeconds.
+func (r *ReconcileMachineDeployment) checkPausedConditions(d *v1alpha1.MachineDeployment) error {
+	if !dutil.HasProgressDeadline(d) {
+		return nil
+	}
+
+	cond := dutil.GetMachineDeploymentCondition(d.Status, common.MachineDeploymentProgressing)
+	if cond != nil && cond.Reason == dutil.TimedOutReason {
+		// If we have reported lack of progress, do not overwrite it with a paused condition.
+		return nil
+	}
+	pausedCondExists := cond != nil && cond.Reason == dutil.PausedDeployReason
+
+	needsUpdate := false
+	if d.Spec.Paused && !pausedCondExists {
+		condition := dutil.NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionUnknown, dutil.PausedDeployReason, "Deployment is paused")
+		dutil.SetMachineDeploymentCondition(&d.Status, *condition)
+		needsUpdate = true
+	} else if !d.Spec.Paused && pausedCondExists {
+		condition := dutil.NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionUnknown, dutil.ResumedDeployReason, "Deployment is resumed")
+		dutil.SetMachineDeploymentCondition(&d.Status, *condition)
+		needsUpdate = true
+	}
+
+	if !needsUpdate {
+		return nil
+	}
+
+	return r.Status().Update(context.Background(), d)
+}
+
+// progressCheckAfter returns the duration after which the given deployment should be
+// reconciled again to check whether its rollout has exceeded progressDeadlineSeconds.
+// It returns zero if no progress check is needed.
+func progressCheckAfter(d *v1alpha1.MachineDeployment) time.Duration {
+	currentCond := dutil.GetMachineDeploymentCondition(d.Status, common.MachineDeploymentProgressing)
+	// Can't estimate progress if there is no deadline in the spec or progressing condition in the current status.
+	if !dutil.HasProgressDeadline(d) || currentCond == nil || d.Spec.Paused {
+		return 0
+	}
+
+	// No need to estimate progress if the rollout is complete or already timed out.
+	if dutil.DeploymentComplete(d, &d.Status) || currentCond.Reason == dutil.NewMSAvailableReason || currentCond.Reason == dutil.TimedOutReason {
+		return 0
+	}
+
+	// If there is no sign of progress at this point then there is a high chance that the
+	// deployment is stuck. We should resync this deployment at some point in the future
+	// and check whether it has timed out, otherwise we depend on the next event on one of
+	// its machine sets:
+	//
+	// ProgressingCondition.LastUpdateTime + progressDeadlineSeconds - time.Now()
+	after := currentCond.LastUpdateTime.Time.Add(time.Duration(*d.Spec.ProgressDeadlineSeconds) * time.Second).Sub(time.Now())
+
+	// If the remaining time is less than a second, then requeue the deployment shortly,
+	// eventually it should transition either to a Complete or to a TimedOut condition.
+	if after < time.Second {
+		klog.V(4).Infof("Queueing up MachineDeployment %q for a progress check now", d.Name)
+		return time.Second
+	}
+
+	klog.V(4).Infof("Queueing up MachineDeployment %q for a progress check after %ds", d.Name, int(after.Seconds()))
+	// Add a second to avoid milliseconds skew.
+	return after + time.Second
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinedeployment
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	dutil "sigs.k8s.io/cluster-api/pkg/controller/machinedeployment/util"
+)
+
+// rolloutRecreate implements the logic for recreating a machine set: all old machine sets
+// are scaled down to zero and the new machine set is only scaled up once every machine
+// owned by the old machine sets has been deleted.
+func (r *ReconcileMachineDeployment) rolloutRecreate(d *v1alpha1.MachineDeployment, msList []*v1alpha1.MachineSet, machineMap map[types.UID]*v1alpha1.MachineList) error {
+	// Don't create a new MS if not already existed, so that we avoid scaling up before scaling down.
+	newMS, oldMSs, err := r.getAllMachineSetsAndSyncRevision(d, msList, machineMap, false)
+	if err != nil {
+		return err
+	}
+
+	allMSs := append(oldMSs, newMS)
+	activeOldMSs := dutil.FilterActiveMachineSets(oldMSs)
+
+	// Scale down old machine sets.
+	scaledDown, err := r.scaleDownOldMachineSetsForRecreate(activeOldMSs, d)
+	if err != nil {
+		return err
+	}
+
+	if scaledDown {
+		// Update MachineDeploymentStatus.
+		return r.syncDeploymentStatus(allMSs, newMS, d)
+	}
+
+	// Do not process a deployment when it has old machines running.
+	if oldMachinesRunning(newMS, oldMSs, machineMap) {
+		klog.V(4).Infof("Waiting for old machines of deployment %q to be deleted", d.Name)
+		return r.syncDeploymentStatus(allMSs, newMS, d)
+	}
+
+	// If we need to create a new MS, create it now.
+	if newMS == nil {
+		newMS, oldMSs, err = r.getAllMachineSetsAndSyncRevision(d, msList, machineMap, true)
+		if err != nil {
+			return err
+		}
+
+		// newMS can be nil in case there are only changes in annotations or MinReadySeconds,
+		// see rolloutRolling.
+		if newMS == nil {
+			return nil
+		}
+
+		allMSs = append(oldMSs, newMS)
+	}
+
+	// Scale up new machine set.
+	if err := r.scaleUpNewMachineSetForRecreate(newMS, d); err != nil {
+		return err
+	}
+
+	if err := r.syncDeploymentStatus(allMSs, newMS, d); err != nil {
+		return err
+	}
+
+	if dutil.DeploymentComplete(d, &d.Status) {
+		if err := r.cleanupDeployment(oldMSs, d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scaleDownOldMachineSetsForRecreate scales down old machine sets when deployment strategy is "Recreate".
+func (r *ReconcileMachineDeployment) scaleDownOldMachineSetsForRecreate(oldMSs []*v1alpha1.MachineSet, deployment *v1alpha1.MachineDeployment) (bool, error) {
+	scaled := false
+	for _, ms := range oldMSs {
+		if ms.Spec.Replicas == nil {
+			return false, errors.Errorf("spec replicas for machine set %v is nil, this is unexpected", ms.Name)
+		}
+
+		// Scaling not required.
+		if *(ms.Spec.Replicas) == 0 {
+			continue
+		}
+
+		scaledMS, err := r.scaleMachineSet(ms, 0, deployment)
+		if err != nil {
+			return false, err
+		}
+
+		if scaledMS {
+			scaled = true
+		}
+	}
+
+	return scaled, nil
+}
+
+// oldMachinesRunning returns whether there are old machines still existing or any of the old
+// MachineSets thinks that it runs machines. Machines which are being deleted are still
+// considered as running until they are gone.
+func oldMachinesRunning(newMS *v1alpha1.MachineSet, oldMSs []*v1alpha1.MachineSet, machineMap map[types.UID]*v1alpha1.MachineList) bool {
+	if oldMachines := dutil.GetActualReplicaCountForMachineSets(oldMSs); oldMachines > 0 {
+		return true
+	}
+
+	for msUID, machineList := range machineMap {
+		// If the machines belong to the new MachineSet, ignore.
+		if newMS != nil && newMS.UID == msUID {
+			continue
+		}
+
+		if len(machineList.Items) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scaleUpNewMachineSetForRecreate scales up new machine set when deployment strategy is "Recreate".
+func (r *ReconcileMachineDeployment) scaleUpNewMachineSetForRecreate(newMS *v1alpha1.MachineSet, deployment *v1alpha1.MachineDeployment) error {
+	if deployment.Spec.Replicas == nil {
+		return errors.Errorf("spec replicas for deployment %v is nil, this is unexpected", deployment.Name)
+	}
+
+	_, err := r.scaleMachineSet(newMS, *(deployment.Spec.Replicas), deployment)
+	return err
+}
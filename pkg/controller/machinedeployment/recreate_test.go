@@ -0,0 +1,311 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinedeployment
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	dutil "sigs.k8s.io/cluster-api/pkg/controller/machinedeployment/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newRecreateDeployment(replicas int32) *v1alpha1.MachineDeployment {
+	return &v1alpha1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "recreate",
+			Namespace: "default",
+			UID:       "deployment-uid",
+		},
+		Spec: v1alpha1.MachineDeploymentSpec{
+			Replicas:        int32Ptr(replicas),
+			MinReadySeconds: int32Ptr(0),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Strategy: &v1alpha1.MachineDeploymentStrategy{
+				Type: common.RecreateMachineDeploymentStrategyType,
+			},
+			Template: v1alpha1.MachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+				},
+				Spec: v1alpha1.MachineSpec{
+					Versions: v1alpha1.MachineVersionInfo{Kubelet: "1.15.0"},
+				},
+			},
+		},
+	}
+}
+
+func newRecreateMachineSet(d *v1alpha1.MachineDeployment, name, kubelet string, replicas int32) *v1alpha1.MachineSet {
+	template := *d.Spec.Template.DeepCopy()
+	template.Spec.Versions.Kubelet = kubelet
+	return &v1alpha1.MachineSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "MachineSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       d.Namespace,
+			UID:             types.UID(name + "-uid"),
+			Labels:          map[string]string{"foo": "bar"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(d, controllerKind)},
+		},
+		Spec: v1alpha1.MachineSetSpec{
+			Replicas: int32Ptr(replicas),
+			Selector: d.Spec.Selector,
+			Template: template,
+		},
+		Status: v1alpha1.MachineSetStatus{
+			Replicas: replicas,
+		},
+	}
+}
+
+func newRecreateMachineList(names ...string) *v1alpha1.MachineList {
+	list := &v1alpha1.MachineList{}
+	for _, name := range names {
+		list.Items = append(list.Items, v1alpha1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		})
+	}
+	return list
+}
+
+func TestOldMachinesRunning(t *testing.T) {
+	d := newRecreateDeployment(3)
+	deleting := newRecreateMachineList("old-0")
+	deleting.Items[0].DeletionTimestamp = &metav1.Time{}
+
+	testCases := []struct {
+		name       string
+		newMS      *v1alpha1.MachineSet
+		oldMSs     []*v1alpha1.MachineSet
+		machineMap map[types.UID]*v1alpha1.MachineList
+		expected   bool
+	}{
+		{
+			name:       "no old machine sets",
+			newMS:      newRecreateMachineSet(d, "new", "1.15.0", 3),
+			machineMap: map[types.UID]*v1alpha1.MachineList{},
+			expected:   false,
+		},
+		{
+			name:       "old machine set reports running replicas",
+			newMS:      newRecreateMachineSet(d, "new", "1.15.0", 0),
+			oldMSs:     []*v1alpha1.MachineSet{newRecreateMachineSet(d, "old", "1.14.0", 2)},
+			machineMap: map[types.UID]*v1alpha1.MachineList{},
+			expected:   true,
+		},
+		{
+			name:   "old machine set scaled down but machines remain",
+			newMS:  newRecreateMachineSet(d, "new", "1.15.0", 0),
+			oldMSs: []*v1alpha1.MachineSet{newRecreateMachineSet(d, "old", "1.14.0", 0)},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": newRecreateMachineList("old-0", "old-1"),
+			},
+			expected: true,
+		},
+		{
+			name:   "old machine still being deleted",
+			newMS:  newRecreateMachineSet(d, "new", "1.15.0", 0),
+			oldMSs: []*v1alpha1.MachineSet{newRecreateMachineSet(d, "old", "1.14.0", 0)},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": deleting,
+			},
+			expected: true,
+		},
+		{
+			name:   "only machines of the new machine set",
+			newMS:  newRecreateMachineSet(d, "new", "1.15.0", 3),
+			oldMSs: []*v1alpha1.MachineSet{newRecreateMachineSet(d, "old", "1.14.0", 0)},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": newRecreateMachineList(),
+				"new-uid": newRecreateMachineList("new-0", "new-1", "new-2"),
+			},
+			expected: false,
+		},
+		{
+			name:   "no new machine set yet and old machines remain",
+			oldMSs: []*v1alpha1.MachineSet{newRecreateMachineSet(d, "old", "1.14.0", 0)},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": newRecreateMachineList("old-0"),
+			},
+			expected: true,
+		},
+		{
+			name:   "no new machine set yet and old machines gone",
+			oldMSs: []*v1alpha1.MachineSet{newRecreateMachineSet(d, "old", "1.14.0", 0)},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": newRecreateMachineList(),
+			},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := oldMachinesRunning(tc.newMS, tc.oldMSs, tc.machineMap); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestRolloutRecreate(t *testing.T) {
+	deleting := newRecreateMachineList("old-0")
+	deleting.Items[0].DeletionTimestamp = &metav1.Time{}
+
+	testCases := []struct {
+		name       string
+		oldMS      func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet
+		newMS      func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet
+		machineMap map[types.UID]*v1alpha1.MachineList
+		// expectedNewReplicas is nil when no new machine set is expected to exist.
+		expectedNewReplicas *int32
+	}{
+		{
+			name: "old machine set is scaled down before the new one is created",
+			oldMS: func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet {
+				return newRecreateMachineSet(d, "old", "1.14.0", 3)
+			},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": newRecreateMachineList("old-0", "old-1", "old-2"),
+			},
+			expectedNewReplicas: nil,
+		},
+		{
+			name: "no new machine set while old machines remain",
+			oldMS: func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet {
+				return newRecreateMachineSet(d, "old", "1.14.0", 0)
+			},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": newRecreateMachineList("old-0"),
+			},
+			expectedNewReplicas: nil,
+		},
+		{
+			name: "no new machine set while old machines are being deleted",
+			oldMS: func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet {
+				return newRecreateMachineSet(d, "old", "1.14.0", 0)
+			},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": deleting,
+			},
+			expectedNewReplicas: nil,
+		},
+		{
+			name: "existing new machine set is not scaled up while old machines are being deleted",
+			oldMS: func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet {
+				return newRecreateMachineSet(d, "old", "1.14.0", 0)
+			},
+			newMS: func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet {
+				ms := newRecreateMachineSet(d, "new", "1.15.0", 0)
+				dutil.SetNewMachineSetAnnotations(d, ms, "1", false)
+				return ms
+			},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": deleting,
+			},
+			expectedNewReplicas: int32Ptr(0),
+		},
+		{
+			name: "new machine set is created once old machines are gone",
+			oldMS: func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet {
+				return newRecreateMachineSet(d, "old", "1.14.0", 0)
+			},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": newRecreateMachineList(),
+			},
+			expectedNewReplicas: int32Ptr(3),
+		},
+		{
+			name: "existing new machine set is scaled up once old machines are gone",
+			oldMS: func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet {
+				return newRecreateMachineSet(d, "old", "1.14.0", 0)
+			},
+			newMS: func(d *v1alpha1.MachineDeployment) *v1alpha1.MachineSet {
+				ms := newRecreateMachineSet(d, "new", "1.15.0", 0)
+				dutil.SetNewMachineSetAnnotations(d, ms, "1", false)
+				return ms
+			},
+			machineMap: map[types.UID]*v1alpha1.MachineList{
+				"old-uid": newRecreateMachineList(),
+			},
+			expectedNewReplicas: int32Ptr(3),
+		},
+	}
+
+	v1alpha1.AddToScheme(scheme.Scheme)
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newRecreateDeployment(3)
+			oldMS := tc.oldMS(d)
+			msList := []*v1alpha1.MachineSet{oldMS}
+			objs := []runtime.Object{d, oldMS}
+			if tc.newMS != nil {
+				newMS := tc.newMS(d)
+				msList = append(msList, newMS)
+				objs = append(objs, newMS)
+			}
+
+			r := &ReconcileMachineDeployment{
+				Client: fake.NewFakeClient(objs...),
+				scheme: scheme.Scheme,
+			}
+
+			if err := r.rolloutRecreate(d, msList, tc.machineMap); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			msl := &v1alpha1.MachineSetList{}
+			if err := r.List(context.Background(), &client.ListOptions{Namespace: d.Namespace}, msl); err != nil {
+				t.Fatalf("failed to list machine sets: %v", err)
+			}
+
+			var newMS *v1alpha1.MachineSet
+			for i := range msl.Items {
+				ms := &msl.Items[i]
+				if ms.UID == oldMS.UID {
+					if *ms.Spec.Replicas != 0 {
+						t.Errorf("expected old machine set to be scaled to 0, got %d", *ms.Spec.Replicas)
+					}
+					continue
+				}
+				newMS = ms
+			}
+
+			switch {
+			case tc.expectedNewReplicas == nil && newMS != nil:
+				t.Errorf("expected no new machine set, got %q with %d replicas", newMS.Name, *newMS.Spec.Replicas)
+			case tc.expectedNewReplicas != nil && newMS == nil:
+				t.Errorf("expected new machine set with %d replicas, got none", *tc.expectedNewReplicas)
+			case tc.expectedNewReplicas != nil && *newMS.Spec.Replicas != *tc.expectedNewReplicas:
+				t.Errorf("expected new machine set with %d replicas, got %d", *tc.expectedNewReplicas, *newMS.Spec.Replicas)
+			}
+		})
+	}
+}
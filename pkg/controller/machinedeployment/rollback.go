@@ -0,0 +1,98 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinedeployment
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	dutil "sigs.k8s.io/cluster-api/pkg/controller/machinedeployment/util"
+)
+
+// rollback the deployment to the specified revision. In any case cleanup the rollback spec.
+//
+// msList should come from getMachineSetsForDeployment(d).
+func (r *ReconcileMachineDeployment) rollback(d *v1alpha1.MachineDeployment, msList []*v1alpha1.MachineSet) error {
+	revision := d.Spec.RollbackTo.Revision
+
+	// If rollback revision is 0, rollback to the last revision
+	if revision == 0 {
+		if revision = dutil.LastRevision(msList); revision == 0 {
+			// If we still can't find the last revision, gives up rollback
+			r.recorder.Eventf(d, corev1.EventTypeWarning, dutil.RollbackRevisionNotFound, "Unable to find last revision.")
+			// Gives up rollback
+			return r.updateDeploymentAndClearRollbackTo(d)
+		}
+	}
+
+	for _, ms := range msList {
+		v, err := dutil.Revision(ms)
+		if err != nil {
+			klog.V(4).Infof("Unable to extract revision from deployment's machine set %q: %v", ms.Name, err)
+			continue
+		}
+
+		if v == revision {
+			klog.V(4).Infof("Found machine set %q with desired revision %d", ms.Name, v)
+			// rollback by copying the machine template from the machine set.
+			// revision number will be incremented during the next getAllMachineSetsAndSyncRevision call
+			// no-op if the template matches current deployment's template.
+			performedRollback, err := r.rollbackToTemplate(d, ms)
+			if performedRollback && err == nil {
+				r.recorder.Eventf(d, corev1.EventTypeNormal, dutil.RollbackDone, "Rolled back deployment %q to revision %d", d.Name, revision)
+			}
+			return err
+		}
+	}
+
+	r.recorder.Eventf(d, corev1.EventTypeWarning, dutil.RollbackRevisionNotFound, "Unable to find the revision to rollback to.")
+	// Gives up rollback
+	return r.updateDeploymentAndClearRollbackTo(d)
+}
+
+// rollbackToTemplate compares the templates of the provided deployment and machine set and
+// updates the deployment with the machine set template in case they are different. It also
+// cleans up the rollback spec so subsequent requeues of the deployment won't end up in here.
+func (r *ReconcileMachineDeployment) rollbackToTemplate(d *v1alpha1.MachineDeployment, ms *v1alpha1.MachineSet) (bool, error) {
+	performedRollback := false
+	if !dutil.EqualIgnoreHash(&d.Spec.Template, &ms.Spec.Template) {
+		klog.V(4).Infof("Rolling back deployment %q to template spec %+v", d.Name, ms.Spec.Template.Spec)
+		dutil.SetFromMachineSetTemplate(d, ms.Spec.Template)
+		// set MS (the old MS we'll rolling back to) annotations back to the deployment;
+		// otherwise, the deployment's current annotations (should be the same as current new MS) will be copied to the MS after the rollback.
+		dutil.SetDeploymentAnnotationsTo(d, ms)
+		performedRollback = true
+	} else {
+		klog.V(4).Infof("Rolling back to a revision that contains the same template as current deployment %q, skipping rollback...", d.Name)
+		eventMsg := fmt.Sprintf("The rollback revision contains the same template as current deployment %q", d.Name)
+		r.recorder.Event(d, corev1.EventTypeWarning, dutil.RollbackTemplateUnchanged, eventMsg)
+	}
+
+	return performedRollback, r.updateDeploymentAndClearRollbackTo(d)
+}
+
+// updateDeploymentAndClearRollbackTo sets .spec.rollbackTo to nil and update the input deployment
+// It is assumed that the caller will have updated the deployment template appropriately (in case
+// we want to rollback).
+func (r *ReconcileMachineDeployment) updateDeploymentAndClearRollbackTo(d *v1alpha1.MachineDeployment) error {
+	klog.V(4).Infof("Cleans up rollbackTo of deployment %q", d.Name)
+	d.Spec.RollbackTo = nil
+	return r.Update(context.Background(), d)
+}
@@ -24,6 +24,7 @@ import (
 	"strconv"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,6 +32,7 @@ import (
 	apirand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
 	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 	dutil "sigs.k8s.io/cluster-api/pkg/controller/machinedeployment/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -115,7 +117,24 @@ func (r *ReconcileMachineDeployment) getNewMachineSet(d *clusterv1alpha1.Machine
 		err := r.updateMachineDeployment(d, func(innerDeployment *clusterv1alpha1.MachineDeployment) {
 			dutil.SetDeploymentRevision(d, msCopy.Annotations[dutil.RevisionAnnotation])
 		})
-		return msCopy, err
+		if err != nil {
+			return nil, err
+		}
+
+		// If no other Progressing condition has been recorded and we need to estimate the progress
+		// of this deployment then it is likely that old users started caring about progress. In that
+		// case we need to take into account the first time we noticed their new machine set.
+		cond := dutil.GetMachineDeploymentCondition(d.Status, common.MachineDeploymentProgressing)
+		if dutil.HasProgressDeadline(d) && cond == nil {
+			msg := fmt.Sprintf("Found new machine set %q", msCopy.Name)
+			condition := dutil.NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionTrue, dutil.FoundNewMSReason, msg)
+			dutil.SetMachineDeploymentCondition(&d.Status, *condition)
+			if err := r.Status().Update(context.Background(), d); err != nil {
+				return nil, err
+			}
+		}
+
+		return msCopy, nil
 	}
 
 	if !createIfNotExisted {
@@ -193,7 +212,15 @@ func (r *ReconcileMachineDeployment) getNewMachineSet(d *clusterv1alpha1.Machine
 
 		return nil, err
 	case err != nil:
-		klog.V(4).Infof("Failed to create new machine set %q: %v", newMS.Name, err)
+		msg := fmt.Sprintf("Failed to create new machine set %q: %v", newMS.Name, err)
+		klog.V(4).Info(msg)
+		if dutil.HasProgressDeadline(d) {
+			cond := dutil.NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionFalse, dutil.FailedMSCreateReason, msg)
+			dutil.SetMachineDeploymentCondition(&d.Status, *cond)
+			// We don't really care about this error at this point, since we have a bigger issue to report.
+			_ = r.Status().Update(context.Background(), d)
+		}
+		r.recorder.Eventf(d, corev1.EventTypeWarning, dutil.FailedMSCreateReason, msg)
 		return nil, err
 	}
 
@@ -204,8 +231,20 @@ func (r *ReconcileMachineDeployment) getNewMachineSet(d *clusterv1alpha1.Machine
 	err = r.updateMachineDeployment(d, func(innerDeployment *clusterv1alpha1.MachineDeployment) {
 		dutil.SetDeploymentRevision(d, newRevision)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !alreadyExists && dutil.HasProgressDeadline(d) {
+		msg := fmt.Sprintf("Created new machine set %q", createdMS.Name)
+		condition := dutil.NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionTrue, dutil.NewMachineSetReason, msg)
+		dutil.SetMachineDeploymentCondition(&d.Status, *condition)
+		if err := r.Status().Update(context.Background(), d); err != nil {
+			return nil, err
+		}
+	}
 
-	return createdMS, err
+	return createdMS, nil
 }
 
 // scale scales proportionally in order to mitigate risk. Otherwise, scaling up can increase the size
@@ -323,9 +362,17 @@ func (r *ReconcileMachineDeployment) scale(deployment *clusterv1alpha1.MachineDe
 	return nil
 }
 
-// syncDeploymentStatus checks if the status is up-to-date and sync it if necessary
+// syncDeploymentStatus checks if the status is up-to-date and sync it if necessary.
+// Unless the deployment is paused or being deleted, it also estimates the progress
+// of the rollout and updates the Progressing and ReplicaFailure conditions.
 func (r *ReconcileMachineDeployment) syncDeploymentStatus(allMSs []*clusterv1alpha1.MachineSet, newMS *clusterv1alpha1.MachineSet, d *clusterv1alpha1.MachineDeployment) error {
 	newStatus := calculateStatus(allMSs, newMS, d)
+
+	if !d.Spec.Paused && d.DeletionTimestamp == nil {
+		r.syncProgressingCondition(newMS, d, &newStatus)
+	}
+	syncReplicaFailureCondition(allMSs, &newStatus)
+
 	if reflect.DeepEqual(d.Status, newStatus) {
 		return nil
 	}
@@ -356,6 +403,20 @@ func calculateStatus(allMSs []*clusterv1alpha1.MachineSet, newMS *clusterv1alpha
 		UnavailableReplicas: unavailableReplicas,
 	}
 
+	// Copy conditions one by one so we won't mutate the original object.
+	conditions := deployment.Status.Conditions
+	for i := range conditions {
+		status.Conditions = append(status.Conditions, conditions[i])
+	}
+
+	if availableReplicas >= *(deployment.Spec.Replicas)-dutil.MaxUnavailable(*deployment) {
+		minAvailability := dutil.NewMachineDeploymentCondition(common.MachineDeploymentAvailable, corev1.ConditionTrue, dutil.MinimumReplicasAvailable, "Deployment has minimum availability.")
+		dutil.SetMachineDeploymentCondition(&status, *minAvailability)
+	} else {
+		noMinAvailability := dutil.NewMachineDeploymentCondition(common.MachineDeploymentAvailable, corev1.ConditionFalse, dutil.MinimumReplicasUnavailable, "Deployment does not have minimum availability.")
+		dutil.SetMachineDeploymentCondition(&status, *noMinAvailability)
+	}
+
 	return status
 }
 
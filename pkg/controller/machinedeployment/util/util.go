@@ -20,9 +20,11 @@ import (
 	"fmt"
 	"hash"
 	"hash/fnv"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	v1 "k8s.io/api/core/v1"
@@ -53,13 +55,31 @@ const (
 	// proportions in case the deployment has surge replicas.
 	MaxReplicasAnnotation = "machinedeployment.clusters.k8s.io/max-replicas"
 
+	//
+	// Progressing:
+	//
+	// MachineSetUpdatedReason is added in a machine deployment when one of its machine sets is updated as part
+	// of the rollout process.
+	MachineSetUpdatedReason = "MachineSetUpdated"
 	// FailedMSCreateReason is added in a machine deployment when it cannot create a new machine set.
 	FailedMSCreateReason = "MachineSetCreateError"
+	// NewMachineSetReason is added in a machine deployment when it creates a new machine set.
+	NewMachineSetReason = "NewMachineSetCreated"
 	// FoundNewMSReason is added in a machine deployment when it adopts an existing machine set.
 	FoundNewMSReason = "FoundNewMachineSet"
+	// NewMSAvailableReason is added in a machine deployment when its newest machine set is made available
+	// ie. the number of new machines that have become ready for at least minReadySeconds is at least
+	// the minimum available machines that need to run for the deployment.
+	NewMSAvailableReason = "NewMachineSetAvailable"
+	// TimedOutReason is added in a machine deployment when its newest machine set fails to show any progress
+	// within the given deadline (progressDeadlineSeconds).
+	TimedOutReason = "ProgressDeadlineExceeded"
 	// PausedDeployReason is added in a deployment when it is paused. Lack of progress shouldn't be
 	// estimated once a deployment is paused.
 	PausedDeployReason = "DeploymentPaused"
+	// ResumedDeployReason is added in a deployment when it is resumed. Useful for not failing accidentally
+	// deployments that paused amidst a rollout and are bounded by a deadline.
+	ResumedDeployReason = "DeploymentResumed"
 
 	//
 	// Available:
@@ -69,6 +89,16 @@ const (
 	// MinimumReplicasUnavailable is added in a deployment when it doesn't have the minimum required replicas
 	// available.
 	MinimumReplicasUnavailable = "MinimumReplicasUnavailable"
+
+	//
+	// Rollback:
+	//
+	// RollbackRevisionNotFound is not found rollback event reason
+	RollbackRevisionNotFound = "DeploymentRollbackRevisionNotFound"
+	// RollbackTemplateUnchanged is the template unchanged rollback event reason
+	RollbackTemplateUnchanged = "DeploymentRollbackTemplateUnchanged"
+	// RollbackDone is the done rollback event reason
+	RollbackDone = "DeploymentRollback"
 )
 
 // MachineSetsByCreationTimestamp sorts a list of MachineSet by creation timestamp, using their names as a tie breaker.
@@ -138,6 +168,23 @@ func MaxRevision(allMSs []*v1alpha1.MachineSet) int64 {
 	return max
 }
 
+// LastRevision finds the second max revision number in all machine sets (the last revision)
+func LastRevision(allMSs []*v1alpha1.MachineSet) int64 {
+	max, secMax := int64(0), int64(0)
+	for _, ms := range allMSs {
+		if v, err := Revision(ms); err != nil {
+			// Skip the machine sets when it failed to parse their revision information
+			klog.V(4).Infof("Error: %v. Couldn't parse revision for machine set %#v, deployment controller will skip it when reconciling revisions.", err, ms)
+		} else if v >= max {
+			secMax = max
+			max = v
+		} else if v > secMax {
+			secMax = v
+		}
+	}
+	return secMax
+}
+
 // Revision returns the revision number of the input object.
 func Revision(obj runtime.Object) (int64, error) {
 	acc, err := meta.Accessor(obj)
@@ -209,6 +256,38 @@ func getIntFromAnnotation(ms *v1alpha1.MachineSet, annotationKey string) (int32,
 	return int32(intValue), true
 }
 
+// SetDeploymentAnnotationsTo sets deployment's annotations as given MS's annotations.
+// This action should be done if and only if the deployment is rolling back to this ms.
+// Note that apply and revision annotations are not changed.
+func SetDeploymentAnnotationsTo(deployment *v1alpha1.MachineDeployment, rollbackToMS *v1alpha1.MachineSet) {
+	deployment.Annotations = getSkippedAnnotations(deployment.Annotations)
+	for k, v := range rollbackToMS.Annotations {
+		if !skipCopyAnnotation(k) {
+			deployment.Annotations[k] = v
+		}
+	}
+}
+
+func getSkippedAnnotations(annotations map[string]string) map[string]string {
+	skippedAnnotations := make(map[string]string)
+	for k, v := range annotations {
+		if skipCopyAnnotation(k) {
+			skippedAnnotations[k] = v
+		}
+	}
+	return skippedAnnotations
+}
+
+// SetFromMachineSetTemplate sets the desired MachineTemplateSpec from a machine set template to the given deployment.
+func SetFromMachineSetTemplate(deployment *v1alpha1.MachineDeployment, template v1alpha1.MachineTemplateSpec) *v1alpha1.MachineDeployment {
+	deployment.Spec.Template.ObjectMeta = template.ObjectMeta
+	deployment.Spec.Template.Spec = template.Spec
+	deployment.Spec.Template.ObjectMeta.Labels = CloneAndRemoveLabel(
+		deployment.Spec.Template.ObjectMeta.Labels,
+		DefaultMachineDeploymentUniqueLabelKey)
+	return deployment
+}
+
 // SetNewMachineSetAnnotations sets new machine set's annotations appropriately by updating its revision and
 // copying required deployment annotations to it; it returns true if machine set's annotation is changed.
 func SetNewMachineSetAnnotations(deployment *v1alpha1.MachineDeployment, newMS *v1alpha1.MachineSet, newRevision string, exists bool) bool {
@@ -487,6 +566,152 @@ func GetAvailableReplicaCountForMachineSets(machineSets []*v1alpha1.MachineSet)
 	return totalAvailableReplicas
 }
 
+// NewMachineDeploymentCondition creates a new deployment condition.
+func NewMachineDeploymentCondition(condType common.MachineDeploymentConditionType, status v1.ConditionStatus, reason, message string) *v1alpha1.MachineDeploymentCondition {
+	return &v1alpha1.MachineDeploymentCondition{
+		Type:               condType,
+		Status:             status,
+		LastUpdateTime:     metav1.NewTime(nowFn()),
+		LastTransitionTime: metav1.NewTime(nowFn()),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// GetMachineDeploymentCondition returns the condition with the provided type.
+func GetMachineDeploymentCondition(status v1alpha1.MachineDeploymentStatus, condType common.MachineDeploymentConditionType) *v1alpha1.MachineDeploymentCondition {
+	for i := range status.Conditions {
+		c := status.Conditions[i]
+		if c.Type == condType {
+			return &c
+		}
+	}
+	return nil
+}
+
+// SetMachineDeploymentCondition updates the deployment to include the provided condition. If the condition that
+// we are about to add already exists and has the same status and reason then we are not going to update.
+func SetMachineDeploymentCondition(status *v1alpha1.MachineDeploymentStatus, condition v1alpha1.MachineDeploymentCondition) {
+	currentCond := GetMachineDeploymentCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status && currentCond.Reason == condition.Reason {
+		return
+	}
+	// Do not update lastTransitionTime if the status of the condition doesn't change.
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	newConditions := filterOutCondition(status.Conditions, condition.Type)
+	status.Conditions = append(newConditions, condition)
+}
+
+// RemoveMachineDeploymentCondition removes the deployment condition with the provided type.
+func RemoveMachineDeploymentCondition(status *v1alpha1.MachineDeploymentStatus, condType common.MachineDeploymentConditionType) {
+	status.Conditions = filterOutCondition(status.Conditions, condType)
+}
+
+// filterOutCondition returns a new slice of deployment conditions without conditions with the provided type.
+func filterOutCondition(conditions []v1alpha1.MachineDeploymentCondition, condType common.MachineDeploymentConditionType) []v1alpha1.MachineDeploymentCondition {
+	var newConditions []v1alpha1.MachineDeploymentCondition
+	for _, c := range conditions {
+		if c.Type == condType {
+			continue
+		}
+		newConditions = append(newConditions, c)
+	}
+	return newConditions
+}
+
+// MachineSetToMachineDeploymentCondition converts the error reported by a machine set into a
+// ReplicaFailure deployment condition. It returns nil if the machine set reports no error.
+func MachineSetToMachineDeploymentCondition(ms *v1alpha1.MachineSet) *v1alpha1.MachineDeploymentCondition {
+	if ms == nil || (ms.Status.ErrorReason == nil && ms.Status.ErrorMessage == nil) {
+		return nil
+	}
+
+	reason := FailedMSCreateReason
+	if ms.Status.ErrorReason != nil {
+		reason = string(*ms.Status.ErrorReason)
+	}
+
+	message := fmt.Sprintf("MachineSet %q has failed", ms.Name)
+	if ms.Status.ErrorMessage != nil {
+		message = fmt.Sprintf("MachineSet %q has failed: %s", ms.Name, *ms.Status.ErrorMessage)
+	}
+
+	return NewMachineDeploymentCondition(common.MachineDeploymentReplicaFailure, v1.ConditionTrue, reason, message)
+}
+
+// HasProgressDeadline checks if the deployment has a progress deadline set.
+func HasProgressDeadline(deployment *v1alpha1.MachineDeployment) bool {
+	return deployment.Spec.ProgressDeadlineSeconds != nil && *deployment.Spec.ProgressDeadlineSeconds != math.MaxInt32
+}
+
+// DeploymentProgressing reports progress for a deployment. Progress is estimated by comparing the
+// current with the new status of the deployment that the controller is observing. More specifically,
+// when new machines are scaled up or become ready or available, or old machines are scaled down, then we
+// consider the deployment is progressing.
+func DeploymentProgressing(deployment *v1alpha1.MachineDeployment, newStatus *v1alpha1.MachineDeploymentStatus) bool {
+	oldStatus := deployment.Status
+
+	// Old replicas that need to be scaled down
+	oldStatusOldReplicas := oldStatus.Replicas - oldStatus.UpdatedReplicas
+	newStatusOldReplicas := newStatus.Replicas - newStatus.UpdatedReplicas
+
+	return (newStatus.UpdatedReplicas > oldStatus.UpdatedReplicas) ||
+		(newStatusOldReplicas < oldStatusOldReplicas) ||
+		newStatus.ReadyReplicas > deployment.Status.ReadyReplicas ||
+		newStatus.AvailableReplicas > deployment.Status.AvailableReplicas
+}
+
+// used for unit testing
+var nowFn = func() time.Time { return time.Now() }
+
+// DeploymentTimedOut considers a deployment to have timed out once its condition that reports progress
+// is older than progressDeadlineSeconds or a Progressing condition with a TimedOutReason reason already
+// exists.
+func DeploymentTimedOut(deployment *v1alpha1.MachineDeployment, newStatus *v1alpha1.MachineDeploymentStatus) bool {
+	if !HasProgressDeadline(deployment) {
+		return false
+	}
+
+	// Look for the Progressing condition. If it doesn't exist, we have no base to estimate progress.
+	// If it's already set with a TimedOutReason reason, we have already timed out, no need to check
+	// again.
+	condition := GetMachineDeploymentCondition(*newStatus, common.MachineDeploymentProgressing)
+	if condition == nil {
+		return false
+	}
+
+	// If the previous condition has been a successful rollout then we shouldn't try to
+	// estimate any progress. Scenario:
+	//
+	// * progressDeadlineSeconds is smaller than the difference between now and the time
+	//   the last rollout finished in the past.
+	// * the creation of a new MachineSet triggers a resync of the Deployment prior to the
+	//   cached copy of the Deployment getting updated with the status.condition that indicates
+	//   the creation of the new MachineSet.
+	//
+	// The Deployment will be resynced and eventually its Progressing condition will catch
+	// up with the state of the world.
+	if condition.Reason == NewMSAvailableReason {
+		return false
+	}
+	if condition.Reason == TimedOutReason {
+		return true
+	}
+
+	// Look at the difference in seconds between now and the last time we reported any
+	// progress or tried to create a machine set, or resumed a paused deployment and
+	// compare against progressDeadlineSeconds.
+	from := condition.LastUpdateTime
+	now := nowFn()
+	delta := time.Duration(*deployment.Spec.ProgressDeadlineSeconds) * time.Second
+	timedOut := from.Add(delta).Before(now)
+
+	klog.V(4).Infof("Deployment %q timed out (%t) [last progress check: %v - now: %v]", deployment.Name, timedOut, from, now)
+	return timedOut
+}
+
 // IsRollingUpdate returns true if the strategy type is a rolling update.
 func IsRollingUpdate(deployment *v1alpha1.MachineDeployment) bool {
 	return deployment.Spec.Strategy.Type == common.RollingUpdateMachineDeploymentStrategyType
@@ -525,6 +750,8 @@ func NewMSNewReplicas(deployment *v1alpha1.MachineDeployment, allMSs []*v1alpha1
 		// Do not exceed the number of desired replicas.
 		scaleUpCount = integer.Int32Min(scaleUpCount, *(deployment.Spec.Replicas)-*(newMS.Spec.Replicas))
 		return *(newMS.Spec.Replicas) + scaleUpCount, nil
+	case common.RecreateMachineDeploymentStrategyType:
+		return *(deployment.Spec.Replicas), nil
 	default:
 		// Check if we can scale up.
 		maxSurge, err := intstrutil.GetValueFromIntOrPercent(deployment.Spec.Strategy.RollingUpdate.MaxSurge, int(*(deployment.Spec.Replicas)), true)
@@ -632,6 +859,22 @@ func CloneAndAddLabel(labels map[string]string, labelKey, labelValue string) map
 	return newLabels
 }
 
+// CloneAndRemoveLabel clones the given map and returns a new map with the given key removed.
+// Returns the given map, if labelKey is empty.
+func CloneAndRemoveLabel(labels map[string]string, labelKey string) map[string]string {
+	if labelKey == "" {
+		// Don't need to remove a label.
+		return labels
+	}
+	// Clone.
+	newLabels := map[string]string{}
+	for key, value := range labels {
+		newLabels[key] = value
+	}
+	delete(newLabels, labelKey)
+	return newLabels
+}
+
 // Clones the given selector and returns a new selector with the given key and value added.
 // Returns the given selector, if labelKey is empty.
 func CloneSelectorAndAddLabel(selector *metav1.LabelSelector, labelKey, labelValue string) *metav1.LabelSelector {
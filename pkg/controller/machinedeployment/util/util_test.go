@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -531,6 +532,11 @@ func TestNewMSNewReplicas(t *testing.T) {
 			common.RollingUpdateMachineDeploymentStrategyType,
 			6, 2, 10, 6,
 		},
+		{
+			"recreate - to depReplicas",
+			common.RecreateMachineDeploymentStrategyType,
+			3, 1, 1, 3,
+		},
 	}
 	newDeployment := generateDeployment("nginx")
 	newRC := generateMS(newDeployment)
@@ -839,3 +845,259 @@ func TestReplicasAnnotationsNeedUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestMachineDeploymentConditions(t *testing.T) {
+	status := &v1alpha1.MachineDeploymentStatus{}
+
+	if cond := GetMachineDeploymentCondition(*status, common.MachineDeploymentProgressing); cond != nil {
+		t.Fatalf("expected no condition, got %+v", cond)
+	}
+
+	progressing := NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionTrue, NewMachineSetReason, "")
+	progressing.LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	SetMachineDeploymentCondition(status, *progressing)
+	SetMachineDeploymentCondition(status, *NewMachineDeploymentCondition(common.MachineDeploymentAvailable, corev1.ConditionTrue, MinimumReplicasAvailable, ""))
+	if len(status.Conditions) != 2 {
+		t.Fatalf("expected 2 conditions, got %d", len(status.Conditions))
+	}
+
+	// Same status, different reason: the transition time must be preserved.
+	SetMachineDeploymentCondition(status, *NewMachineDeploymentCondition(common.MachineDeploymentProgressing, corev1.ConditionTrue, MachineSetUpdatedReason, ""))
+	cond := GetMachineDeploymentCondition(*status, common.MachineDeploymentProgressing)
+	if cond == nil || cond.Reason != MachineSetUpdatedReason {
+		t.Fatalf("expected reason %q, got %+v", MachineSetUpdatedReason, cond)
+	}
+	if !cond.LastTransitionTime.Equal(&progressing.LastTransitionTime) {
+		t.Errorf("expected last transition time %v to be preserved, got %v", progressing.LastTransitionTime, cond.LastTransitionTime)
+	}
+
+	RemoveMachineDeploymentCondition(status, common.MachineDeploymentProgressing)
+	if cond := GetMachineDeploymentCondition(*status, common.MachineDeploymentProgressing); cond != nil {
+		t.Errorf("expected condition to be removed, got %+v", cond)
+	}
+	if len(status.Conditions) != 1 {
+		t.Errorf("expected 1 condition, got %d", len(status.Conditions))
+	}
+}
+
+func TestMachineSetToMachineDeploymentCondition(t *testing.T) {
+	reason := common.InvalidConfigurationMachineSetError
+	message := "bad template"
+
+	tests := []struct {
+		name string
+
+		ms *v1alpha1.MachineSet
+
+		expectedReason string
+	}{
+		{
+			name:           "no error",
+			ms:             &v1alpha1.MachineSet{},
+			expectedReason: "",
+		},
+		{
+			name: "error reason and message",
+			ms: &v1alpha1.MachineSet{
+				Status: v1alpha1.MachineSetStatus{ErrorReason: &reason, ErrorMessage: &message},
+			},
+			expectedReason: string(reason),
+		},
+		{
+			name: "error message only",
+			ms: &v1alpha1.MachineSet{
+				Status: v1alpha1.MachineSetStatus{ErrorMessage: &message},
+			},
+			expectedReason: FailedMSCreateReason,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cond := MachineSetToMachineDeploymentCondition(test.ms)
+			if test.expectedReason == "" {
+				if cond != nil {
+					t.Errorf("expected no condition, got %+v", cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatalf("expected condition with reason %q, got nil", test.expectedReason)
+			}
+			if cond.Type != common.MachineDeploymentReplicaFailure || cond.Status != corev1.ConditionTrue || cond.Reason != test.expectedReason {
+				t.Errorf("expected ReplicaFailure=True with reason %q, got %+v", test.expectedReason, cond)
+			}
+		})
+	}
+}
+
+func TestDeploymentProgressing(t *testing.T) {
+	deployment := func(current, updated, ready, available int32) *v1alpha1.MachineDeployment {
+		return &v1alpha1.MachineDeployment{
+			Status: v1alpha1.MachineDeploymentStatus{
+				Replicas:          current,
+				UpdatedReplicas:   updated,
+				ReadyReplicas:     ready,
+				AvailableReplicas: available,
+			},
+		}
+	}
+	newStatus := func(current, updated, ready, available int32) v1alpha1.MachineDeploymentStatus {
+		return v1alpha1.MachineDeploymentStatus{
+			Replicas:          current,
+			UpdatedReplicas:   updated,
+			ReadyReplicas:     ready,
+			AvailableReplicas: available,
+		}
+	}
+
+	tests := []struct {
+		name string
+
+		d         *v1alpha1.MachineDeployment
+		newStatus v1alpha1.MachineDeploymentStatus
+
+		expected bool
+	}{
+		{
+			name: "progressing: updated machines",
+
+			d:         deployment(10, 4, 4, 4),
+			newStatus: newStatus(10, 6, 4, 4),
+
+			expected: true,
+		},
+		{
+			name: "not progressing",
+
+			d:         deployment(10, 4, 4, 4),
+			newStatus: newStatus(10, 4, 4, 4),
+
+			expected: false,
+		},
+		{
+			name: "progressing: old machines removed",
+
+			d:         deployment(10, 4, 6, 6),
+			newStatus: newStatus(8, 4, 6, 6),
+
+			expected: true,
+		},
+		{
+			name: "progressing: more ready machines",
+
+			d:         deployment(10, 10, 4, 4),
+			newStatus: newStatus(10, 10, 5, 4),
+
+			expected: true,
+		},
+		{
+			name: "progressing: more available machines",
+
+			d:         deployment(10, 10, 10, 4),
+			newStatus: newStatus(10, 10, 10, 5),
+
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, exp := DeploymentProgressing(test.d, &test.newStatus), test.expected; got != exp {
+				t.Errorf("expected progressing: %t, got: %t", exp, got)
+			}
+		})
+	}
+}
+
+func TestDeploymentTimedOut(t *testing.T) {
+	var (
+		null *int32
+		ten  = int32(10)
+	)
+
+	timeFn := func(min, sec int) time.Time {
+		return time.Date(2016, 1, 1, 0, min, sec, 0, time.UTC)
+	}
+	deployment := func(condType common.MachineDeploymentConditionType, status corev1.ConditionStatus, reason string, pds *int32, from time.Time) v1alpha1.MachineDeployment {
+		return v1alpha1.MachineDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec: v1alpha1.MachineDeploymentSpec{
+				ProgressDeadlineSeconds: pds,
+			},
+			Status: v1alpha1.MachineDeploymentStatus{
+				Conditions: []v1alpha1.MachineDeploymentCondition{
+					{
+						Type:           condType,
+						Status:         status,
+						Reason:         reason,
+						LastUpdateTime: metav1.Time{Time: from},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+
+		d     v1alpha1.MachineDeployment
+		nowFn func() time.Time
+
+		expected bool
+	}{
+		{
+			name: "no progressDeadlineSeconds specified - no timeout",
+
+			d:        deployment(common.MachineDeploymentProgressing, corev1.ConditionTrue, "", null, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: false,
+		},
+		{
+			name: "progressDeadlineSeconds: 10s, now - started => 00:01:20 - 00:01:09 => 11s",
+
+			d:        deployment(common.MachineDeploymentProgressing, corev1.ConditionTrue, "", &ten, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: true,
+		},
+		{
+			name: "progressDeadlineSeconds: 10s, now - started => 00:01:20 - 00:01:11 => 9s",
+
+			d:        deployment(common.MachineDeploymentProgressing, corev1.ConditionTrue, "", &ten, timeFn(1, 11)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: false,
+		},
+		{
+			name: "previous status was a successful rollout w/o a time out",
+
+			d:        deployment(common.MachineDeploymentProgressing, corev1.ConditionTrue, NewMSAvailableReason, &ten, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: false,
+		},
+		{
+			name: "previous status was an unsuccessful rollout w/ a time out",
+
+			d:        deployment(common.MachineDeploymentProgressing, corev1.ConditionFalse, TimedOutReason, &ten, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: true,
+		},
+		{
+			name: "no Progressing condition",
+
+			d:        deployment(common.MachineDeploymentAvailable, corev1.ConditionTrue, MinimumReplicasAvailable, &ten, timeFn(1, 9)),
+			nowFn:    func() time.Time { return timeFn(1, 20) },
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nowFn = test.nowFn
+			defer func() { nowFn = time.Now }()
+
+			if got, exp := DeploymentTimedOut(&test.d, &test.d.Status), test.expected; got != exp {
+				t.Errorf("expected timeout: %t, got: %t", exp, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinehealthcheck
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/controller/config"
+	"sigs.k8s.io/cluster-api/pkg/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// controllerName is the name of this controller
+	controllerName = "machinehealthcheck-controller"
+
+	// EventRemediationRestricted is emitted when remediation is skipped because too many machines are unhealthy.
+	EventRemediationRestricted = "RemediationRestricted"
+
+	// EventMachineDeleted is emitted when an unhealthy machine is deleted to be replaced.
+	EventMachineDeleted = "MachineDeleted"
+
+	// EventSkippedNoController is emitted when an unhealthy machine can't be remediated because it is not owned by a MachineSet.
+	EventSkippedNoController = "SkippedNoController"
+
+	// machineNodeNameIndex is the name of the index of machines by the name of the node they are linked to.
+	machineNodeNameIndex = "status.nodeRef.name"
+)
+
+var machineSetKind = v1alpha1.SchemeGroupVersion.WithKind("MachineSet")
+
+// Add creates a new MachineHealthCheck Controller and adds it to the Manager with default RBAC.
+// The Manager will set fields on the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(&v1alpha1.Machine{}, machineNodeNameIndex, indexMachineByNodeName); err != nil {
+		return err
+	}
+	r := newReconciler(mgr)
+	return add(mgr, r, r.machineToMachineHealthChecks, r.nodeToMachineHealthChecks)
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager) *ReconcileMachineHealthCheck {
+	return &ReconcileMachineHealthCheck{
+		Client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor(controllerName),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler, machineMapFn, nodeMapFn handler.ToRequestsFunc) error {
+	// Create a new controller.
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: config.ControllerConfig.WorkerCount})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to MachineHealthCheck.
+	if err := c.Watch(&source.Kind{Type: &v1alpha1.MachineHealthCheck{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+
+	// Map Machine changes to the MachineHealthChecks selecting them.
+	if err := c.Watch(
+		&source.Kind{Type: &v1alpha1.Machine{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: machineMapFn},
+	); err != nil {
+		return err
+	}
+
+	// Map Node changes to the MachineHealthChecks selecting their Machine.
+	return c.Watch(
+		&source.Kind{Type: &corev1.Node{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: nodeMapFn},
+	)
+}
+
+var _ reconcile.Reconciler = &ReconcileMachineHealthCheck{}
+
+// ReconcileMachineHealthCheck reconciles a MachineHealthCheck object
+type ReconcileMachineHealthCheck struct {
+	client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+}
+
+// Reconcile checks the health of the machines selected by a MachineHealthCheck and deletes the
+// unhealthy ones owned by a MachineSet, so that they get replaced.
+// +kubebuilder:rbac:groups=cluster.k8s.io,resources=machinehealthchecks;machinehealthchecks/status,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=cluster.k8s.io,resources=machines,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=cluster.k8s.io,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+func (r *ReconcileMachineHealthCheck) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx := context.TODO()
+
+	mhc := &v1alpha1.MachineHealthCheck{}
+	if err := r.Get(ctx, request.NamespacedName, mhc); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Object not found, return. Created objects are automatically garbage collected.
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	if !mhc.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	if util.IsPaused(mhc) {
+		klog.V(4).Infof("MachineHealthCheck %q is paused, not reconciling it", mhc.Name)
+		return reconcile.Result{}, nil
+	}
+	paused, err := r.isClusterPaused(ctx, mhc)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if paused {
+		klog.V(4).Infof("Cluster of MachineHealthCheck %q is paused, not reconciling it", mhc.Name)
+		return reconcile.Result{}, nil
+	}
+
+	targets, err := r.getTargets(ctx, mhc)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	now := time.Now()
+	var unhealthy []target
+	var nextChecks []time.Duration
+	for _, t := range targets {
+		needsRemediation, nextCheck := t.needsRemediation(mhc.Spec.UnhealthyConditions, now)
+		if needsRemediation {
+			unhealthy = append(unhealthy, t)
+			continue
+		}
+		nextChecks = append(nextChecks, nextCheck)
+	}
+
+	mhc.Status.ExpectedMachines = int32(len(targets))
+	mhc.Status.CurrentHealthy = int32(len(targets) - len(unhealthy))
+	mhc.Status.ObservedGeneration = mhc.Generation
+	if err := r.Status().Update(ctx, mhc); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to update status of MachineHealthCheck %q", mhc.Name)
+	}
+
+	result := reconcile.Result{RequeueAfter: minDuration(nextChecks)}
+	if len(unhealthy) == 0 {
+		return result, nil
+	}
+
+	allowed, err := isAllowedRemediation(mhc.Spec.MaxUnhealthy, len(targets), len(unhealthy))
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to compute maxUnhealthy of MachineHealthCheck %q", mhc.Name)
+	}
+	if !allowed {
+		klog.Warningf("MachineHealthCheck %q: %d of %d machines are unhealthy, which exceeds maxUnhealthy %q, skipping remediation",
+			mhc.Name, len(unhealthy), len(targets), mhc.Spec.MaxUnhealthy.String())
+		r.recorder.Eventf(mhc, corev1.EventTypeWarning, EventRemediationRestricted,
+			"Remediation restricted: %d of %d machines are unhealthy (maxUnhealthy: %s)", len(unhealthy), len(targets), mhc.Spec.MaxUnhealthy.String())
+		return result, nil
+	}
+
+	for _, t := range unhealthy {
+		if util.IsPaused(t.machine) {
+			klog.V(4).Infof("MachineHealthCheck %q: machine %q is paused, skipping remediation", mhc.Name, t.machine.Name)
+			continue
+		}
+		if err := r.remediate(ctx, mhc, t.machine); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	return result, nil
+}
+
+// isClusterPaused returns true if the Cluster whose name the MachineHealthCheck is labeled with is paused.
+func (r *ReconcileMachineHealthCheck) isClusterPaused(ctx context.Context, mhc *v1alpha1.MachineHealthCheck) (bool, error) {
+	name := mhc.Labels[v1alpha1.MachineClusterLabelName]
+	if name == "" {
+		return false, nil
+	}
+	cluster := &v1alpha1.Cluster{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: mhc.Namespace, Name: name}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "failed to get cluster %q of MachineHealthCheck %q", name, mhc.Name)
+	}
+	return util.IsPaused(cluster), nil
+}
+
+// getTargets returns the machines selected by the given MachineHealthCheck along with their nodes.
+func (r *ReconcileMachineHealthCheck) getTargets(ctx context.Context, mhc *v1alpha1.MachineHealthCheck) ([]target, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&mhc.Spec.Selector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build selector of MachineHealthCheck %q", mhc.Name)
+	}
+	// An empty selector would select every machine of the namespace.
+	if selector.Empty() {
+		klog.Warningf("MachineHealthCheck %q has an empty selector, ignoring", mhc.Name)
+		return nil, nil
+	}
+
+	machines := &v1alpha1.MachineList{}
+	if err := r.List(ctx, machines, client.InNamespace(mhc.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, errors.Wrapf(err, "failed to list machines of MachineHealthCheck %q", mhc.Name)
+	}
+
+	targets := make([]target, 0, len(machines.Items))
+	for i := range machines.Items {
+		machine := &machines.Items[i]
+		if !machine.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		t := target{machine: machine}
+		if machine.Status.NodeRef != nil {
+			node := &corev1.Node{}
+			if err := r.Get(ctx, client.ObjectKey{Name: machine.Status.NodeRef.Name}, node); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return nil, errors.Wrapf(err, "failed to get node %q of machine %q", machine.Status.NodeRef.Name, machine.Name)
+				}
+			} else {
+				t.node = node
+			}
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, nil
+}
+
+// remediate deletes the given unhealthy machine if it is owned by a MachineSet, which then replaces it.
+func (r *ReconcileMachineHealthCheck) remediate(ctx context.Context, mhc *v1alpha1.MachineHealthCheck, machine *v1alpha1.Machine) error {
+	ref := metav1.GetControllerOf(machine)
+	if ref == nil || ref.Kind != machineSetKind.Kind {
+		klog.Infof("MachineHealthCheck %q: machine %q is unhealthy but is not owned by a MachineSet, skipping remediation", mhc.Name, machine.Name)
+		r.recorder.Eventf(mhc, corev1.EventTypeNormal, EventSkippedNoController, "Machine %q is unhealthy but is not owned by a MachineSet", machine.Name)
+		return nil
+	}
+
+	klog.Infof("MachineHealthCheck %q: deleting unhealthy machine %q", mhc.Name, machine.Name)
+	if err := r.Delete(ctx, machine); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete unhealthy machine %q", machine.Name)
+	}
+	r.recorder.Eventf(mhc, corev1.EventTypeNormal, EventMachineDeleted, "Deleted unhealthy machine %q", machine.Name)
+	return nil
+}
+
+// machineToMachineHealthChecks maps a Machine to the MachineHealthChecks selecting it.
+func (r *ReconcileMachineHealthCheck) machineToMachineHealthChecks(o handler.MapObject) []reconcile.Request {
+	machine, ok := o.Object.(*v1alpha1.Machine)
+	if !ok {
+		return nil
+	}
+	return r.machineHealthChecksFor(machine)
+}
+
+// nodeToMachineHealthChecks maps a Node to the MachineHealthChecks selecting its Machine, which is looked up
+// through the index of machines by node name rather than by listing every machine.
+func (r *ReconcileMachineHealthCheck) nodeToMachineHealthChecks(o handler.MapObject) []reconcile.Request {
+	node, ok := o.Object.(*corev1.Node)
+	if !ok {
+		return nil
+	}
+
+	machines := &v1alpha1.MachineList{}
+	if err := r.List(context.Background(), machines, client.MatchingFields{machineNodeNameIndex: node.Name}); err != nil {
+		klog.Errorf("Failed to list machines for node %q: %v", node.Name, err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range machines.Items {
+		machine := &machines.Items[i]
+		if machine.Status.NodeRef == nil || machine.Status.NodeRef.Name != node.Name {
+			continue
+		}
+		requests = append(requests, r.machineHealthChecksFor(machine)...)
+	}
+	return requests
+}
+
+func indexMachineByNodeName(o runtime.Object) []string {
+	machine, ok := o.(*v1alpha1.Machine)
+	if !ok || machine.Status.NodeRef == nil {
+		return nil
+	}
+	return []string{machine.Status.NodeRef.Name}
+}
+
+// machineHealthChecksFor returns requests for the MachineHealthChecks of the namespace of the given machine
+// which select it.
+func (r *ReconcileMachineHealthCheck) machineHealthChecksFor(machine *v1alpha1.Machine) []reconcile.Request {
+	mhcList := &v1alpha1.MachineHealthCheckList{}
+	if err := r.List(context.Background(), mhcList, client.InNamespace(machine.Namespace)); err != nil {
+		klog.Errorf("Failed to list MachineHealthChecks for machine %q: %v", machine.Name, err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, mhc := range mhcList.Items {
+		selector, err := metav1.LabelSelectorAsSelector(&mhc.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(machine.Labels)) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: mhc.Namespace, Name: mhc.Name}})
+		}
+	}
+	return requests
+}
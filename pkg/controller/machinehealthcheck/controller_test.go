@@ -0,0 +1,335 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinehealthcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const namespace = "default"
+
+func newMachineHealthCheck(maxUnhealthy *intstr.IntOrString) *v1alpha1.MachineHealthCheck {
+	return &v1alpha1.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "mhc", Namespace: namespace},
+		Spec: v1alpha1.MachineHealthCheckSpec{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}},
+			UnhealthyConditions: []v1alpha1.UnhealthyCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionUnknown, Timeout: metav1.Duration{Duration: 5 * time.Minute}},
+			},
+			MaxUnhealthy: maxUnhealthy,
+		},
+	}
+}
+
+func newMachine(name string, owned bool) *v1alpha1.Machine {
+	m := &v1alpha1.Machine{
+		TypeMeta: metav1.TypeMeta{Kind: "Machine", APIVersion: v1alpha1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"foo": "bar"},
+		},
+		Status: v1alpha1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: name},
+		},
+	}
+	if owned {
+		isController := true
+		m.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: machineSetKind.GroupVersion().String(),
+			Kind:       machineSetKind.Kind,
+			Name:       "ms",
+			Controller: &isController,
+		}}
+	}
+	return m
+}
+
+func newNode(name string, status corev1.ConditionStatus, since time.Duration) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{
+				Type:               corev1.NodeReady,
+				Status:             status,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-since)),
+			}},
+		},
+	}
+}
+
+func TestNeedsRemediation(t *testing.T) {
+	conditions := newMachineHealthCheck(nil).Spec.UnhealthyConditions
+	now := time.Now()
+
+	testcases := []struct {
+		name              string
+		target            target
+		expected          bool
+		expectedNextCheck bool
+	}{
+		{
+			name:   "no node ref",
+			target: target{machine: &v1alpha1.Machine{}},
+		},
+		{
+			name:     "node gone",
+			target:   target{machine: newMachine("m", true)},
+			expected: true,
+		},
+		{
+			name:   "healthy node",
+			target: target{machine: newMachine("m", true), node: newNode("m", corev1.ConditionTrue, time.Hour)},
+		},
+		{
+			name:              "unhealthy condition not timed out",
+			target:            target{machine: newMachine("m", true), node: newNode("m", corev1.ConditionUnknown, time.Minute)},
+			expectedNextCheck: true,
+		},
+		{
+			name:     "unhealthy condition timed out",
+			target:   target{machine: newMachine("m", true), node: newNode("m", corev1.ConditionUnknown, 10*time.Minute)},
+			expected: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, nextCheck := tc.target.needsRemediation(conditions, now)
+			if got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+			if (nextCheck > 0) != tc.expectedNextCheck {
+				t.Errorf("expected next check %v, got %v", tc.expectedNextCheck, nextCheck)
+			}
+		})
+	}
+}
+
+func TestIsAllowedRemediation(t *testing.T) {
+	testcases := []struct {
+		name         string
+		maxUnhealthy *intstr.IntOrString
+		unhealthy    int
+		expected     bool
+	}{
+		{name: "no limit", unhealthy: 10, expected: true},
+		{name: "within int", maxUnhealthy: intOrStrPtr(intstr.FromInt(2)), unhealthy: 2, expected: true},
+		{name: "above int", maxUnhealthy: intOrStrPtr(intstr.FromInt(2)), unhealthy: 3},
+		{name: "within percentage", maxUnhealthy: intOrStrPtr(intstr.FromString("40%")), unhealthy: 4, expected: true},
+		{name: "above percentage", maxUnhealthy: intOrStrPtr(intstr.FromString("40%")), unhealthy: 5},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := isAllowedRemediation(tc.maxUnhealthy, 10, tc.unhealthy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func intOrStrPtr(v intstr.IntOrString) *intstr.IntOrString {
+	return &v
+}
+
+func TestReconcile(t *testing.T) {
+	testcases := []struct {
+		name            string
+		maxUnhealthy    *intstr.IntOrString
+		expectedDeleted []string
+		expectedHealthy int32
+	}{
+		{
+			name:            "remediates unhealthy machines owned by a machine set",
+			expectedDeleted: []string{"unhealthy"},
+			expectedHealthy: 2,
+		},
+		{
+			name:            "maxUnhealthy exceeded",
+			maxUnhealthy:    intOrStrPtr(intstr.FromInt(1)),
+			expectedHealthy: 2,
+		},
+	}
+
+	v1alpha1.AddToScheme(scheme.Scheme)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := []runtime.Object{
+				newMachineHealthCheck(tc.maxUnhealthy),
+				newMachine("healthy", true), newNode("healthy", corev1.ConditionTrue, time.Hour),
+				newMachine("starting", true), newNode("starting", corev1.ConditionUnknown, time.Minute),
+				newMachine("unhealthy", true), newNode("unhealthy", corev1.ConditionUnknown, time.Hour),
+				newMachine("unowned", false), newNode("unowned", corev1.ConditionUnknown, time.Hour),
+			}
+			r := &ReconcileMachineHealthCheck{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			result, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: "mhc"}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.RequeueAfter <= 0 || result.RequeueAfter > 5*time.Minute {
+				t.Errorf("expected a requeue before the starting machine times out, got %v", result.RequeueAfter)
+			}
+
+			deleted := map[string]bool{}
+			for _, name := range []string{"healthy", "starting", "unhealthy", "unowned"} {
+				err := r.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: name}, &v1alpha1.Machine{})
+				if apierrors.IsNotFound(err) {
+					deleted[name] = true
+				} else if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if len(deleted) != len(tc.expectedDeleted) {
+				t.Errorf("expected machines %v to be deleted, got %v", tc.expectedDeleted, deleted)
+			}
+			for _, name := range tc.expectedDeleted {
+				if !deleted[name] {
+					t.Errorf("expected machine %q to be deleted", name)
+				}
+			}
+
+			mhc := &v1alpha1.MachineHealthCheck{}
+			if err := r.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "mhc"}, mhc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mhc.Status.ExpectedMachines != 4 || mhc.Status.CurrentHealthy != tc.expectedHealthy {
+				t.Errorf("expected 4 machines with %d healthy, got %+v", tc.expectedHealthy, mhc.Status)
+			}
+		})
+	}
+}
+
+func TestNodeToMachineHealthChecks(t *testing.T) {
+	v1alpha1.AddToScheme(scheme.Scheme)
+	r := &ReconcileMachineHealthCheck{
+		Client: fake.NewFakeClientWithScheme(scheme.Scheme, newMachineHealthCheck(nil), newMachine("machine", true)),
+		scheme: scheme.Scheme,
+	}
+
+	requests := r.nodeToMachineHealthChecks(handler.MapObject{Object: newNode("machine", corev1.ConditionTrue, 0)})
+	if len(requests) != 1 || requests[0].Name != "mhc" {
+		t.Errorf("expected a request for %q, got %v", "mhc", requests)
+	}
+
+	requests = r.nodeToMachineHealthChecks(handler.MapObject{Object: newNode("other", corev1.ConditionTrue, 0)})
+	if len(requests) != 0 {
+		t.Errorf("expected no requests, got %v", requests)
+	}
+}
+
+func TestIndexMachineByNodeName(t *testing.T) {
+	if keys := indexMachineByNodeName(newMachine("machine", true)); len(keys) != 1 || keys[0] != "machine" {
+		t.Errorf("expected the machine to be indexed by node %q, got %v", "machine", keys)
+	}
+	unlinked := newMachine("machine", true)
+	unlinked.Status.NodeRef = nil
+	if keys := indexMachineByNodeName(unlinked); len(keys) != 0 {
+		t.Errorf("expected a machine without a node not to be indexed, got %v", keys)
+	}
+}
+
+func TestReconcilePaused(t *testing.T) {
+	paused := map[string]string{v1alpha1.PausedAnnotation: "true"}
+	testcases := []struct {
+		name           string
+		mhc            func(*v1alpha1.MachineHealthCheck)
+		machine        func(*v1alpha1.Machine)
+		cluster        *v1alpha1.Cluster
+		expectedStatus bool
+	}{
+		{
+			name:           "paused machine",
+			machine:        func(m *v1alpha1.Machine) { m.Annotations = paused },
+			expectedStatus: true,
+		},
+		{
+			name: "paused MachineHealthCheck",
+			mhc:  func(mhc *v1alpha1.MachineHealthCheck) { mhc.Annotations = paused },
+		},
+		{
+			name: "paused cluster",
+			mhc: func(mhc *v1alpha1.MachineHealthCheck) {
+				mhc.Labels = map[string]string{v1alpha1.MachineClusterLabelName: "cluster"}
+			},
+			cluster: &v1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: namespace, Annotations: paused},
+			},
+		},
+	}
+
+	v1alpha1.AddToScheme(scheme.Scheme)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mhc := newMachineHealthCheck(nil)
+			if tc.mhc != nil {
+				tc.mhc(mhc)
+			}
+			machine := newMachine("unhealthy", true)
+			if tc.machine != nil {
+				tc.machine(machine)
+			}
+			objs := []runtime.Object{mhc, machine, newNode("unhealthy", corev1.ConditionUnknown, time.Hour)}
+			if tc.cluster != nil {
+				objs = append(objs, tc.cluster)
+			}
+			r := &ReconcileMachineHealthCheck{
+				Client:   fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(32),
+			}
+
+			if _, err := r.Reconcile(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: "mhc"}}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := r.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "unhealthy"}, &v1alpha1.Machine{}); err != nil {
+				t.Errorf("expected the unhealthy machine not to be deleted, got %v", err)
+			}
+			got := &v1alpha1.MachineHealthCheck{}
+			if err := r.Get(context.TODO(), client.ObjectKey{Namespace: namespace, Name: "mhc"}, got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got.Status.ExpectedMachines == 1) != tc.expectedStatus {
+				t.Errorf("expected status to be updated: %v, got %+v", tc.expectedStatus, got.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinehealthcheck
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// target is a machine selected by a MachineHealthCheck along with the node it is linked to.
+type target struct {
+	machine *v1alpha1.Machine
+	// node is nil if the machine has no node yet or if its node is gone.
+	node *corev1.Node
+}
+
+// needsRemediation returns whether the target is unhealthy according to the given conditions. If it is
+// still healthy, it also returns the duration after which it has to be checked again because one of the
+// conditions is met but has not timed out yet, or zero if no check is needed.
+func (t *target) needsRemediation(conditions []v1alpha1.UnhealthyCondition, now time.Time) (bool, time.Duration) {
+	if t.machine.Status.NodeRef == nil {
+		// The machine has not been linked to a node yet, there is nothing to check.
+		return false, 0
+	}
+
+	if t.node == nil {
+		// The node of the machine has been deleted.
+		return true, 0
+	}
+
+	var nextCheck time.Duration
+	for _, c := range conditions {
+		nodeCondition := getNodeCondition(t.node, c.Type)
+		if nodeCondition == nil || nodeCondition.Status != c.Status {
+			continue
+		}
+
+		unhealthyAt := nodeCondition.LastTransitionTime.Add(c.Timeout.Duration)
+		if !now.Before(unhealthyAt) {
+			return true, 0
+		}
+
+		if after := unhealthyAt.Sub(now); nextCheck == 0 || after < nextCheck {
+			nextCheck = after
+		}
+	}
+
+	return false, nextCheck
+}
+
+// getNodeCondition returns the condition of the given type of the node, or nil if there is none.
+func getNodeCondition(node *corev1.Node, conditionType corev1.NodeConditionType) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == conditionType {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// isAllowedRemediation returns whether the number of unhealthy machines is within the limit set by
+// maxUnhealthy. maxUnhealthy defaults to 100%, i.e. remediation is always allowed.
+func isAllowedRemediation(maxUnhealthy *intstr.IntOrString, total, unhealthy int) (bool, error) {
+	if maxUnhealthy == nil {
+		return true, nil
+	}
+
+	max, err := intstr.GetValueFromIntOrPercent(maxUnhealthy, total, false)
+	if err != nil {
+		return false, err
+	}
+
+	return unhealthy <= max, nil
+}
+
+// minDuration returns the smallest non-zero duration of the given ones, or zero if there is none.
+func minDuration(durations []time.Duration) time.Duration {
+	var min time.Duration
+	for _, d := range durations {
+		if d > 0 && (min == 0 || d < min) {
+			min = d
+		}
+	}
+	return min
+}
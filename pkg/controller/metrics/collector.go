@@ -0,0 +1,139 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	machinesDesc = prometheus.NewDesc(
+		"clusterapi_machines",
+		"Number of machines by namespace, cluster and phase",
+		[]string{"namespace", "cluster", "phase"}, nil,
+	)
+
+	machineDeploymentReplicasDesc = prometheus.NewDesc(
+		"clusterapi_machinedeployment_replicas",
+		"Number of replicas of a machine deployment by state",
+		[]string{"namespace", "name", "state"}, nil,
+	)
+
+	machineDeploymentConditionDesc = prometheus.NewDesc(
+		"clusterapi_machinedeployment_status_condition",
+		"The current status conditions of a machine deployment",
+		[]string{"namespace", "name", "condition", "status"}, nil,
+	)
+)
+
+// Add registers a collector reporting the state of the cluster-api objects
+// with the controller-runtime metrics registry, which is served by the manager.
+func Add(mgr manager.Manager) error {
+	return metrics.Registry.Register(NewCollector(mgr.GetClient()))
+}
+
+// NewCollector returns a prometheus collector which reports the state of the
+// Machines and MachineDeployments read from c each time it is scraped.
+func NewCollector(c client.Reader) prometheus.Collector {
+	return &collector{reader: c}
+}
+
+type collector struct {
+	reader client.Reader
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- machinesDesc
+	ch <- machineDeploymentReplicasDesc
+	ch <- machineDeploymentConditionDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.collectMachines(ch)
+	c.collectMachineDeployments(ch)
+}
+
+type machinesKey struct {
+	namespace string
+	cluster   string
+	phase     string
+}
+
+func (c *collector) collectMachines(ch chan<- prometheus.Metric) {
+	machines := &clusterv1.MachineList{}
+	if err := c.reader.List(context.Background(), machines); err != nil {
+		klog.Errorf("Failed to list machines for metrics: %v", err)
+		ch <- prometheus.NewInvalidMetric(machinesDesc, err)
+		return
+	}
+
+	counts := map[machinesKey]int{}
+	for _, m := range machines.Items {
+		key := machinesKey{
+			namespace: m.Namespace,
+			cluster:   m.Labels[clusterv1.MachineClusterLabelName],
+		}
+		if m.Status.Phase != nil {
+			key.phase = *m.Status.Phase
+		}
+		counts[key]++
+	}
+
+	for key, count := range counts {
+		ch <- prometheus.MustNewConstMetric(machinesDesc, prometheus.GaugeValue, float64(count), key.namespace, key.cluster, key.phase)
+	}
+}
+
+func (c *collector) collectMachineDeployments(ch chan<- prometheus.Metric) {
+	deployments := &clusterv1.MachineDeploymentList{}
+	if err := c.reader.List(context.Background(), deployments); err != nil {
+		klog.Errorf("Failed to list machine deployments for metrics: %v", err)
+		ch <- prometheus.NewInvalidMetric(machineDeploymentReplicasDesc, err)
+		return
+	}
+
+	for _, d := range deployments.Items {
+		var desired int32
+		if d.Spec.Replicas != nil {
+			desired = *d.Spec.Replicas
+		}
+
+		for state, replicas := range map[string]int32{
+			"desired":     desired,
+			"current":     d.Status.Replicas,
+			"updated":     d.Status.UpdatedReplicas,
+			"ready":       d.Status.ReadyReplicas,
+			"available":   d.Status.AvailableReplicas,
+			"unavailable": d.Status.UnavailableReplicas,
+		} {
+			ch <- prometheus.MustNewConstMetric(machineDeploymentReplicasDesc, prometheus.GaugeValue, float64(replicas), d.Namespace, d.Name, state)
+		}
+
+		for _, cond := range d.Status.Conditions {
+			ch <- prometheus.MustNewConstMetric(machineDeploymentConditionDesc, prometheus.GaugeValue, 1, d.Namespace, d.Name, string(cond.Type), string(cond.Status))
+		}
+	}
+}
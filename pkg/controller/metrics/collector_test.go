@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newMachine(name, cluster, phase string) *clusterv1.Machine {
+	m := &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{clusterv1.MachineClusterLabelName: cluster},
+		},
+	}
+	if phase != "" {
+		m.Status.Phase = &phase
+	}
+	return m
+}
+
+func TestCollectMachines(t *testing.T) {
+	clusterv1.AddToScheme(scheme.Scheme)
+	c := NewCollector(fake.NewFakeClientWithScheme(scheme.Scheme,
+		newMachine("a", "foo", "Running"),
+		newMachine("b", "foo", "Running"),
+		newMachine("c", "foo", ""),
+		newMachine("d", "bar", "Running"),
+	))
+
+	expected := `
+# HELP clusterapi_machines Number of machines by namespace, cluster and phase
+# TYPE clusterapi_machines gauge
+clusterapi_machines{cluster="bar",namespace="default",phase="Running"} 1
+clusterapi_machines{cluster="foo",namespace="default",phase=""} 1
+clusterapi_machines{cluster="foo",namespace="default",phase="Running"} 2
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "clusterapi_machines"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectMachineDeployments(t *testing.T) {
+	replicas := int32(3)
+	d := &clusterv1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "md", Namespace: "default"},
+		Spec:       clusterv1.MachineDeploymentSpec{Replicas: &replicas},
+		Status: clusterv1.MachineDeploymentStatus{
+			Replicas:            3,
+			UpdatedReplicas:     1,
+			ReadyReplicas:       2,
+			AvailableReplicas:   2,
+			UnavailableReplicas: 1,
+			Conditions: []clusterv1.MachineDeploymentCondition{
+				{Type: common.MachineDeploymentProgressing, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	clusterv1.AddToScheme(scheme.Scheme)
+	c := NewCollector(fake.NewFakeClientWithScheme(scheme.Scheme, d))
+
+	expected := `
+# HELP clusterapi_machinedeployment_replicas Number of replicas of a machine deployment by state
+# TYPE clusterapi_machinedeployment_replicas gauge
+clusterapi_machinedeployment_replicas{name="md",namespace="default",state="available"} 2
+clusterapi_machinedeployment_replicas{name="md",namespace="default",state="current"} 3
+clusterapi_machinedeployment_replicas{name="md",namespace="default",state="desired"} 3
+clusterapi_machinedeployment_replicas{name="md",namespace="default",state="ready"} 2
+clusterapi_machinedeployment_replicas{name="md",namespace="default",state="unavailable"} 1
+clusterapi_machinedeployment_replicas{name="md",namespace="default",state="updated"} 1
+# HELP clusterapi_machinedeployment_status_condition The current status conditions of a machine deployment
+# TYPE clusterapi_machinedeployment_status_condition gauge
+clusterapi_machinedeployment_status_condition{condition="Progressing",name="md",namespace="default",status="True"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "clusterapi_machinedeployment_replicas", "clusterapi_machinedeployment_status_condition"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/controller/noderefutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// MachineProviderIDIndex is the name of the index of machines by the key of their provider ID.
+	MachineProviderIDIndex = "spec.providerID"
+
+	// NodeProviderIDIndex is the name of the index of nodes by the key of their provider ID.
+	NodeProviderIDIndex = "spec.providerID"
+)
+
+// addIndexes indexes machines and nodes by their provider ID, so that they can be matched with each other.
+func addIndexes(mgr manager.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(&v1alpha1.Machine{}, MachineProviderIDIndex, indexMachineByProviderID); err != nil {
+		return err
+	}
+	return mgr.GetFieldIndexer().IndexField(&corev1.Node{}, NodeProviderIDIndex, indexNodeByProviderID)
+}
+
+func indexMachineByProviderID(o runtime.Object) []string {
+	machine, ok := o.(*v1alpha1.Machine)
+	if !ok || machine.Spec.ProviderID == nil {
+		return nil
+	}
+	return providerIDIndexKeys(*machine.Spec.ProviderID)
+}
+
+func indexNodeByProviderID(o runtime.Object) []string {
+	node, ok := o.(*corev1.Node)
+	if !ok {
+		return nil
+	}
+	return providerIDIndexKeys(node.Spec.ProviderID)
+}
+
+func providerIDIndexKeys(id string) []string {
+	if id == "" {
+		return nil
+	}
+	providerID, err := noderefutil.NewProviderID(id)
+	if err != nil {
+		return nil
+	}
+	return []string{providerID.IndexKey()}
+}
+
+// machineToNodes maps a Machine to the Node it is linked to, so that changes to the machine spec
+// are applied to the node, and to the Nodes with the same provider ID, so that a node is linked
+// as soon as the provider sets the provider ID of its machine.
+func (r *ReconcileNode) machineToNodes(o handler.MapObject) []reconcile.Request {
+	var requests []reconcile.Request
+	if machine, ok := o.Object.(*v1alpha1.Machine); ok && machine.Status.NodeRef != nil {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Name: machine.Status.NodeRef.Name}})
+	}
+
+	keys := indexMachineByProviderID(o.Object)
+	if len(keys) == 0 {
+		return requests
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := r.Client.List(context.Background(), nodeList, client.MatchingFields{NodeProviderIDIndex: keys[0]}); err != nil {
+		klog.Errorf("Error listing nodes with provider ID key %v: %v", keys[0], err)
+		return requests
+	}
+
+	for i := range nodeList.Items {
+		if nodeKeys := indexNodeByProviderID(&nodeList.Items[i]); len(nodeKeys) == 0 || nodeKeys[0] != keys[0] {
+			continue
+		}
+		request := reconcile.Request{NamespacedName: client.ObjectKey{Name: nodeList.Items[i].Name}}
+		if len(requests) > 0 && requests[0] == request {
+			continue
+		}
+		requests = append(requests, request)
+	}
+	return requests
+}
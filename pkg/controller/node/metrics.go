@@ -0,0 +1,36 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// machineNodeRefLatency is a prometheus metric which keeps track of the time
+	// between the creation of a Machine and its first link to a Node.
+	machineNodeRefLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "clusterapi_machine_noderef_latency_seconds",
+		Help:    "Time from the creation of a machine to its NodeRef being set",
+		Buckets: prometheus.ExponentialBuckets(15, 2, 10),
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(machineNodeRefLatency)
+}
@@ -18,6 +18,7 @@ package node
 
 import (
 	"context"
+	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -41,69 +42,82 @@ const (
 // name while the old node is being deleted.
 //
 // Currently, these annotations are added by the node itself as part of its
-// bootup script after "kubeadm join" succeeds.
+// bootup script after "kubeadm join" succeeds. Nodes without the annotation are
+// linked to the machine with the same provider ID, if any.
+//
+// Once linked, the labels, annotations, taints and config source of the machine spec are
+// applied to the node. Paused machines are neither linked nor synced.
 func (c *ReconcileNode) link(node *corev1.Node) error {
-	nodeReady := noderefutil.IsNodeReady(node)
-
-	// skip update if cached and no change in readiness.
-	if c.linkedNodes[node.ObjectMeta.Name] {
-		if cachedReady, ok := c.cachedReadiness[node.ObjectMeta.Name]; ok && cachedReady == nodeReady {
-			return nil
-		}
+	machine, err := c.getMachineForNode(node)
+	if err != nil || machine == nil {
+		return err
 	}
-
-	val, ok := node.ObjectMeta.Annotations[MachineAnnotationKey]
-	if !ok {
+	if util.IsPaused(machine) {
+		klog.V(4).Infof("Machine %q is paused, not linking it to node %v", machine.Name, node.ObjectMeta.Name)
 		return nil
 	}
 
-	namespace, mach, err := cache.SplitMetaNamespaceKey(val)
-	if err != nil {
-		klog.Errorf("Machine annotation format is incorrect %v: %v\n", val, err)
+	if err := c.updateNodeRef(node, machine); err != nil {
 		return err
 	}
-	namespace = util.GetNamespaceOrDefault(namespace)
-	key := client.ObjectKey{Namespace: namespace, Name: mach}
+	return c.syncNode(node, machine)
+}
 
-	machine := &v1alpha1.Machine{}
-	if err = c.Client.Get(context.Background(), key, machine); err != nil {
-		klog.Errorf("Error getting machine %v: %v\n", mach, err)
-		return err
+// updateNodeRef links the machine to the node, and copies the addresses of the node into the
+// machine status.
+func (c *ReconcileNode) updateNodeRef(node *corev1.Node, machine *v1alpha1.Machine) error {
+	nodeReady := noderefutil.IsNodeReady(node)
+
+	// skip update if cached and no change in readiness or addresses.
+	if c.isCached(node, nodeReady) {
+		return nil
 	}
 
+	firstLink := machine.Status.NodeRef == nil
+
 	t := metav1.Now()
 	machine.Status.LastUpdated = &t
 	machine.Status.NodeRef = objectRef(node)
-	if err = c.Client.Status().Update(context.Background(), machine); err != nil {
+	machine.Status.Addresses = node.Status.Addresses
+	err := c.Client.Status().Update(context.Background(), machine)
+	if err != nil {
 		klog.Errorf("Error updating machine to link to node: %v\n", err)
 	} else {
 		klog.Infof("Successfully linked machine %s to node %s\n",
 			machine.ObjectMeta.Name, node.ObjectMeta.Name)
+		if firstLink {
+			machineNodeRefLatency.Observe(t.Sub(machine.CreationTimestamp.Time).Seconds())
+		}
+		c.cacheLock.Lock()
 		c.linkedNodes[node.ObjectMeta.Name] = true
 		c.cachedReadiness[node.ObjectMeta.Name] = nodeReady
+		c.cachedAddresses[node.ObjectMeta.Name] = node.Status.Addresses
+		c.cacheLock.Unlock()
 	}
 	return err
 }
 
-func (c *ReconcileNode) unlink(node *corev1.Node) error {
-	val, ok := node.ObjectMeta.Annotations[MachineAnnotationKey]
-	if !ok {
-		return nil
-	}
+// isCached returns whether the node is linked and its readiness and addresses didn't change since.
+func (c *ReconcileNode) isCached(node *corev1.Node, nodeReady bool) bool {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
 
-	namespace, mach, err := cache.SplitMetaNamespaceKey(val)
-	if err != nil {
-		klog.Errorf("Machine annotation format is incorrect %v: %v\n", val, err)
-		return err
+	if !c.linkedNodes[node.ObjectMeta.Name] {
+		return false
 	}
-	namespace = util.GetNamespaceOrDefault(namespace)
-	key := client.ObjectKey{Namespace: namespace, Name: mach}
+	cachedReady, ok := c.cachedReadiness[node.ObjectMeta.Name]
+	return ok && cachedReady == nodeReady && reflect.DeepEqual(c.cachedAddresses[node.ObjectMeta.Name], node.Status.Addresses)
+}
 
-	machine := &v1alpha1.Machine{}
-	if err = c.Client.Get(context.Background(), key, machine); err != nil {
-		klog.Errorf("Error getting machine %v: %v\n", mach, err)
+func (c *ReconcileNode) unlink(node *corev1.Node) error {
+	machine, err := c.getMachineForNode(node)
+	if err != nil || machine == nil {
 		return err
 	}
+	if util.IsPaused(machine) {
+		klog.V(4).Infof("Machine %q is paused, not unlinking it from node %v", machine.Name, node.ObjectMeta.Name)
+		return nil
+	}
 
 	// This machine has no link to remove
 	if machine.Status.NodeRef == nil {
@@ -120,18 +134,90 @@ func (c *ReconcileNode) unlink(node *corev1.Node) error {
 	t := metav1.Now()
 	machine.Status.LastUpdated = &t
 	machine.Status.NodeRef = nil
+	machine.Status.Addresses = nil
 	if err = c.Client.Status().Update(context.Background(), machine); err != nil {
 		klog.Errorf("Error updating machine %s to unlink node %s: %v\n",
 			machine.ObjectMeta.Name, node.ObjectMeta.Name, err)
 	} else {
 		klog.Infof("Successfully unlinked node %s from machine %s\n",
 			node.ObjectMeta.Name, machine.ObjectMeta.Name)
+		c.cacheLock.Lock()
 		delete(c.cachedReadiness, node.ObjectMeta.Name)
+		delete(c.cachedAddresses, node.ObjectMeta.Name)
 		delete(c.linkedNodes, node.ObjectMeta.Name)
+		c.cacheLock.Unlock()
 	}
 	return err
 }
 
+// getMachineForNode returns the machine linked to the given node by the machine annotation or, if
+// the node doesn't have the annotation, by the provider ID. It returns nil if there is none.
+func (c *ReconcileNode) getMachineForNode(node *corev1.Node) (*v1alpha1.Machine, error) {
+	val, ok := node.ObjectMeta.Annotations[MachineAnnotationKey]
+	if !ok {
+		return c.getMachineByProviderID(node)
+	}
+
+	namespace, mach, err := cache.SplitMetaNamespaceKey(val)
+	if err != nil {
+		klog.Errorf("Machine annotation format is incorrect %v: %v\n", val, err)
+		return nil, err
+	}
+	namespace = util.GetNamespaceOrDefault(namespace)
+	key := client.ObjectKey{Namespace: namespace, Name: mach}
+
+	machine := &v1alpha1.Machine{}
+	if err = c.Client.Get(context.Background(), key, machine); err != nil {
+		klog.Errorf("Error getting machine %v: %v\n", mach, err)
+		return nil, err
+	}
+
+	return machine, nil
+}
+
+// getMachineByProviderID returns the machine whose provider ID matches the one of the given node.
+// It returns nil if the node has no provider ID or if no machine matches it.
+func (c *ReconcileNode) getMachineByProviderID(node *corev1.Node) (*v1alpha1.Machine, error) {
+	if node.Spec.ProviderID == "" {
+		return nil, nil
+	}
+
+	nodeProviderID, err := noderefutil.NewProviderID(node.Spec.ProviderID)
+	if err != nil {
+		klog.Errorf("Provider ID %q of node %v is invalid: %v\n", node.Spec.ProviderID, node.ObjectMeta.Name, err)
+		return nil, nil
+	}
+
+	machineList := &v1alpha1.MachineList{}
+	if err := c.Client.List(context.Background(), machineList, client.MatchingFields{MachineProviderIDIndex: nodeProviderID.IndexKey()}); err != nil {
+		klog.Errorf("Error listing machines with provider ID %v: %v\n", nodeProviderID, err)
+		return nil, err
+	}
+
+	var matches []*v1alpha1.Machine
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		if machine.Spec.ProviderID == nil {
+			continue
+		}
+		machineProviderID, err := noderefutil.NewProviderID(*machine.Spec.ProviderID)
+		if err != nil || !machineProviderID.Equals(nodeProviderID) {
+			continue
+		}
+		matches = append(matches, machine)
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	default:
+		klog.Errorf("Found %d machines with provider ID %v, unable to link node %v\n", len(matches), nodeProviderID, node.ObjectMeta.Name)
+		return nil, nil
+	}
+}
+
 func objectRef(node *corev1.Node) *corev1.ObjectReference {
 	return &corev1.ObjectReference{
 		Kind: "Node",
@@ -18,11 +18,14 @@ package node
 
 import (
 	"context"
+	"sync"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/controller/config"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -34,23 +37,28 @@ import (
 // Add creates a new Node Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+	if err := addIndexes(mgr); err != nil {
+		return err
+	}
+	r := newReconciler(mgr)
+	return add(mgr, r, r.machineToNodes)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager) *ReconcileNode {
 	return &ReconcileNode{
 		Client:          mgr.GetClient(),
 		scheme:          mgr.GetScheme(),
 		linkedNodes:     map[string]bool{},
 		cachedReadiness: map[string]bool{},
+		cachedAddresses: map[string][]corev1.NodeAddress{},
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, mapFn handler.ToRequestsFunc) error {
 	// Create a new controller
-	c, err := controller.New("node-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New("node-controller", mgr, controller.Options{Reconciler: r, MaxConcurrentReconciles: config.ControllerConfig.WorkerCount})
 	if err != nil {
 		return err
 	}
@@ -61,6 +69,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to Machine, to link nodes by provider ID
+	err = c.Watch(&source.Kind{Type: &v1alpha1.Machine{}}, &handler.EnqueueRequestsFromMapFunc{ToRequests: mapFn})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -71,8 +85,11 @@ type ReconcileNode struct {
 	client.Client
 	scheme *runtime.Scheme
 
+	// cacheLock protects the caches below, as nodes may be reconciled concurrently.
+	cacheLock       sync.Mutex
 	linkedNodes     map[string]bool
 	cachedReadiness map[string]bool
+	cachedAddresses map[string][]corev1.NodeAddress
 }
 
 // Reconcile reads that state of the cluster for a Node object and makes changes based on the state read
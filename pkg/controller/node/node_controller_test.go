@@ -47,8 +47,12 @@ func TestReconcile(t *testing.T) {
 	}
 	c = mgr.GetClient()
 
-	recFn, requests := SetupTestReconcile(newReconciler(mgr))
-	if err := add(mgr, recFn); err != nil {
+	if err := addIndexes(mgr); err != nil {
+		t.Errorf("error adding indexes to manager: %v", err)
+	}
+	r := newReconciler(mgr)
+	recFn, requests := SetupTestReconcile(r)
+	if err := add(mgr, recFn, r.machineToNodes); err != nil {
 		t.Errorf("error adding controller to manager: %v", err)
 	}
 	defer close(StartTestManager(mgr, t))
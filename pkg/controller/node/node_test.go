@@ -0,0 +1,301 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func newTestMachine(name, providerID string) *v1alpha1.Machine {
+	m := &v1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+	}
+	if providerID != "" {
+		m.Spec.ProviderID = &providerID
+	}
+	return m
+}
+
+func newTestReconciler(objs ...runtime.Object) *ReconcileNode {
+	return &ReconcileNode{
+		Client:          fake.NewFakeClientWithScheme(scheme.Scheme, objs...),
+		scheme:          scheme.Scheme,
+		linkedNodes:     map[string]bool{},
+		cachedReadiness: map[string]bool{},
+		cachedAddresses: map[string][]corev1.NodeAddress{},
+	}
+}
+
+func TestLinkByProviderID(t *testing.T) {
+	addresses := []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "node-uid"},
+		Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-1234"},
+		Status:     corev1.NodeStatus{Addresses: addresses},
+	}
+
+	testcases := []struct {
+		name     string
+		machines []runtime.Object
+		linked   []string
+	}{
+		{
+			name:     "matching machine",
+			machines: []runtime.Object{newTestMachine("match", "aws:////i-1234"), newTestMachine("other", "aws:////i-5678")},
+			linked:   []string{"match"},
+		},
+		{
+			name:     "no matching machine",
+			machines: []runtime.Object{newTestMachine("other", "aws:////i-5678"), newTestMachine("none", "")},
+		},
+		{
+			name:     "several matching machines",
+			machines: []runtime.Object{newTestMachine("first", "aws:////i-1234"), newTestMachine("second", "aws:///us-east-1a/i-1234")},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestReconciler(tc.machines...)
+			if err := r.link(node.DeepCopy()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			machines := &v1alpha1.MachineList{}
+			if err := r.Client.List(context.TODO(), machines); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var linked []string
+			for _, m := range machines.Items {
+				if m.Status.NodeRef == nil {
+					continue
+				}
+				linked = append(linked, m.Name)
+				if m.Status.NodeRef.Name != node.Name || m.Status.NodeRef.UID != node.UID {
+					t.Errorf("expected machine %q to reference node %q, got %+v", m.Name, node.Name, m.Status.NodeRef)
+				}
+				if !reflect.DeepEqual(m.Status.Addresses, addresses) {
+					t.Errorf("expected machine %q to have addresses %v, got %v", m.Name, addresses, m.Status.Addresses)
+				}
+			}
+			if !reflect.DeepEqual(linked, tc.linked) {
+				t.Errorf("expected linked machines %v, got %v", tc.linked, linked)
+			}
+		})
+	}
+}
+
+func TestLinkByAnnotation(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node",
+			Annotations: map[string]string{MachineAnnotationKey: "default/annotated"},
+		},
+		Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-1234"},
+	}
+	r := newTestReconciler(newTestMachine("annotated", ""), newTestMachine("match", "aws:////i-1234"))
+
+	if err := r.link(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, expectLinked := range map[string]bool{"annotated": true, "match": false} {
+		m := &v1alpha1.Machine{}
+		if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: name}, m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if linked := m.Status.NodeRef != nil; linked != expectLinked {
+			t.Errorf("expected machine %q linked to be %v, got %v", name, expectLinked, linked)
+		}
+	}
+}
+
+func TestLinkPausedMachine(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-1234"},
+	}
+	machine := newTestMachine("paused", "aws:////i-1234")
+	machine.Annotations = map[string]string{v1alpha1.PausedAnnotation: ""}
+	r := newTestReconciler(machine)
+
+	if err := r.link(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := &v1alpha1.Machine{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "paused"}, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Status.NodeRef != nil {
+		t.Errorf("expected the paused machine not to be linked, got %+v", m.Status.NodeRef)
+	}
+}
+
+func TestUnlinkByProviderID(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-1234"},
+	}
+	machine := newTestMachine("match", "aws:////i-1234")
+	machine.Status.NodeRef = objectRef(node)
+	machine.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}}
+	r := newTestReconciler(machine)
+
+	if err := r.unlink(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := &v1alpha1.Machine{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Namespace: "default", Name: "match"}, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Status.NodeRef != nil || len(m.Status.Addresses) != 0 {
+		t.Errorf("expected machine to be unlinked, got %+v", m.Status)
+	}
+}
+
+func TestMachineToNodes(t *testing.T) {
+	r := newTestReconciler(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "match"}, Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-1234"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "other"}, Spec: corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-5678"}},
+	)
+
+	requests := r.machineToNodes(handler.MapObject{Object: newTestMachine("machine", "aws:////i-1234")})
+	if len(requests) != 1 || requests[0].Name != "match" {
+		t.Errorf("expected a request for node %q, got %v", "match", requests)
+	}
+
+	if requests := r.machineToNodes(handler.MapObject{Object: newTestMachine("machine", "")}); len(requests) != 0 {
+		t.Errorf("expected no requests for a machine without provider ID, got %v", requests)
+	}
+
+	linked := newTestMachine("machine", "aws:////i-1234")
+	linked.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "match"}
+	requests = r.machineToNodes(handler.MapObject{Object: linked})
+	if len(requests) != 1 || requests[0].Name != "match" {
+		t.Errorf("expected a single request for node %q, got %v", "match", requests)
+	}
+
+	linked.Spec.ProviderID = nil
+	linked.Status.NodeRef.Name = "annotated"
+	requests = r.machineToNodes(handler.MapObject{Object: linked})
+	if len(requests) != 1 || requests[0].Name != "annotated" {
+		t.Errorf("expected a request for linked node %q, got %v", "annotated", requests)
+	}
+}
+
+func TestApplyMachineSpec(t *testing.T) {
+	configSource := &corev1.NodeConfigSource{
+		ConfigMap: &corev1.ConfigMapNodeConfigSource{Namespace: "kube-system", Name: "kubelet-config", KubeletConfigKey: "kubelet"},
+	}
+	machine := newTestMachine("machine", "")
+	machine.Spec.Labels = map[string]string{"role": "worker", "zone": "a"}
+	machine.Spec.Annotations = map[string]string{"owner": "team-a"}
+	machine.Spec.Taints = []corev1.Taint{
+		{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "new", Effect: corev1.TaintEffectNoExecute},
+	}
+	machine.Spec.ConfigSource = configSource
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node",
+			Labels:      map[string]string{"kubernetes.io/hostname": "node", "removed": "true", "zone": "b"},
+			Annotations: map[string]string{ManagedLabelsAnnotationKey: "removed,zone"},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{Key: "dedicated", Value: "cpu", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "other", Effect: corev1.TaintEffectNoSchedule},
+			},
+		},
+	}
+
+	applyMachineSpec(node, machine)
+
+	expectedLabels := map[string]string{"kubernetes.io/hostname": "node", "role": "worker", "zone": "a"}
+	if !reflect.DeepEqual(node.Labels, expectedLabels) {
+		t.Errorf("expected labels %v, got %v", expectedLabels, node.Labels)
+	}
+	expectedAnnotations := map[string]string{
+		"owner":                         "team-a",
+		ManagedLabelsAnnotationKey:      "role,zone",
+		ManagedAnnotationsAnnotationKey: "owner",
+	}
+	if !reflect.DeepEqual(node.Annotations, expectedAnnotations) {
+		t.Errorf("expected annotations %v, got %v", expectedAnnotations, node.Annotations)
+	}
+	expectedTaints := []corev1.Taint{
+		{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "other", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "new", Effect: corev1.TaintEffectNoExecute},
+	}
+	if !reflect.DeepEqual(node.Spec.Taints, expectedTaints) {
+		t.Errorf("expected taints %v, got %v", expectedTaints, node.Spec.Taints)
+	}
+	if !reflect.DeepEqual(node.Spec.ConfigSource, configSource) {
+		t.Errorf("expected config source %v, got %v", configSource, node.Spec.ConfigSource)
+	}
+
+	// Removing the labels and annotations from the machine removes them from the node.
+	machine.Spec.Labels = nil
+	machine.Spec.Annotations = nil
+	applyMachineSpec(node, machine)
+	expectedLabels = map[string]string{"kubernetes.io/hostname": "node"}
+	if !reflect.DeepEqual(node.Labels, expectedLabels) {
+		t.Errorf("expected labels %v, got %v", expectedLabels, node.Labels)
+	}
+	if len(node.Annotations) != 0 {
+		t.Errorf("expected no annotations, got %v", node.Annotations)
+	}
+}
+
+func TestLinkSyncsNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{"kubernetes.io/hostname": "node"}},
+		Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-1234"},
+	}
+	machine := newTestMachine("match", "aws:////i-1234")
+	machine.Spec.Labels = map[string]string{"role": "worker"}
+	r := newTestReconciler(machine, node)
+
+	if err := r.link(node.DeepCopy()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := &corev1.Node{}
+	if err := r.Client.Get(context.TODO(), client.ObjectKey{Name: "node"}, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedLabels := map[string]string{"kubernetes.io/hostname": "node", "role": "worker"}
+	if !reflect.DeepEqual(got.Labels, expectedLabels) {
+		t.Errorf("expected labels %v, got %v", expectedLabels, got.Labels)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ManagedLabelsAnnotationKey annotation lists the keys of the node labels which were set from
+	// the metadata of the linked machine, so that they can be removed from the node once they are
+	// removed from the machine, without removing the labels set by other actors.
+	ManagedLabelsAnnotationKey = "cluster.k8s.io/managed-labels"
+
+	// ManagedAnnotationsAnnotationKey annotation lists the keys of the node annotations which were
+	// set from the metadata of the linked machine.
+	ManagedAnnotationsAnnotationKey = "cluster.k8s.io/managed-annotations"
+)
+
+// syncNode applies the labels, annotations, taints and config source of the machine spec to
+// the node linked to it. Taints are only added or updated, never removed, as documented in the
+// machine spec. The node is only patched when it changed.
+func (c *ReconcileNode) syncNode(node *corev1.Node, machine *v1alpha1.Machine) error {
+	base := node.DeepCopy()
+	applyMachineSpec(node, machine)
+	if equality.Semantic.DeepEqual(node, base) {
+		return nil
+	}
+
+	if err := c.Client.Patch(context.Background(), node, client.MergeFrom(base)); err != nil {
+		klog.Errorf("Error syncing node %s with machine %s: %v\n", node.ObjectMeta.Name, machine.ObjectMeta.Name, err)
+		return err
+	}
+	klog.Infof("Successfully synced node %s with machine %s\n", node.ObjectMeta.Name, machine.ObjectMeta.Name)
+	return nil
+}
+
+// applyMachineSpec sets the labels, annotations, taints and config source of the machine spec on the node.
+func applyMachineSpec(node *corev1.Node, machine *v1alpha1.Machine) {
+	node.Labels = applyManagedKeys(node.Labels, machine.Spec.Labels, node.Annotations[ManagedLabelsAnnotationKey])
+	node.Annotations = applyManagedKeys(node.Annotations, machine.Spec.Annotations, node.Annotations[ManagedAnnotationsAnnotationKey])
+	node.Annotations = setManagedKeysAnnotation(node.Annotations, ManagedLabelsAnnotationKey, managedKeys(machine.Spec.Labels))
+	node.Annotations = setManagedKeysAnnotation(node.Annotations, ManagedAnnotationsAnnotationKey, managedKeys(machine.Spec.Annotations))
+
+	for _, taint := range machine.Spec.Taints {
+		setTaint(node, taint)
+	}
+
+	if machine.Spec.ConfigSource != nil {
+		node.Spec.ConfigSource = machine.Spec.ConfigSource.DeepCopy()
+	}
+}
+
+// applyManagedKeys sets the desired entries in current, and removes the previously managed keys
+// which are not desired anymore. Other entries are left untouched.
+func applyManagedKeys(current, desired map[string]string, previouslyManaged string) map[string]string {
+	if len(desired) == 0 && previouslyManaged == "" {
+		return current
+	}
+	if current == nil {
+		current = map[string]string{}
+	}
+	for _, key := range strings.Split(previouslyManaged, ",") {
+		if _, ok := desired[key]; !ok && key != "" {
+			delete(current, key)
+		}
+	}
+	for key, value := range desired {
+		current[key] = value
+	}
+	return current
+}
+
+// managedKeys returns the sorted, comma separated keys of m.
+func managedKeys(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// setManagedKeysAnnotation records the managed keys in the annotation with the given key, or
+// removes the annotation if no key is managed.
+func setManagedKeysAnnotation(annotations map[string]string, key, keys string) map[string]string {
+	if keys == "" {
+		delete(annotations, key)
+		return annotations
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = keys
+	return annotations
+}
+
+// setTaint adds the taint to the node, or updates the value of the node taint with the same key
+// and effect.
+func setTaint(node *corev1.Node, taint corev1.Taint) {
+	for i := range node.Spec.Taints {
+		if node.Spec.Taints[i].MatchTaint(&taint) {
+			node.Spec.Taints[i].Value = taint.Value
+			return
+		}
+	}
+	node.Spec.Taints = append(node.Spec.Taints, taint)
+}
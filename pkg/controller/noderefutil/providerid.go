@@ -0,0 +1,92 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderefutil
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrEmptyProviderID means that the provider id is empty.
+	ErrEmptyProviderID = errors.New("providerID is empty")
+
+	// ErrInvalidProviderID means that the provider id has an invalid form.
+	ErrInvalidProviderID = errors.New("providerID must be of the form <cloudProvider>://<optional>/<segments>/<provider id>")
+
+	// providerIDRegex matches a provider id of the form <cloudProvider>://<optional>/<segments>/<provider id>.
+	providerIDRegex = regexp.MustCompile(`^[^:]+://.*[^/]$`)
+)
+
+// ProviderID is a struct representation of a Kubernetes ProviderID.
+// Format: cloudProvider://optional/segments/etc/id
+type ProviderID struct {
+	original      string
+	cloudProvider string
+	id            string
+}
+
+// NewProviderID parses the input string and returns a new ProviderID.
+func NewProviderID(id string) (*ProviderID, error) {
+	if id == "" {
+		return nil, ErrEmptyProviderID
+	}
+
+	if !providerIDRegex.MatchString(id) {
+		return nil, ErrInvalidProviderID
+	}
+
+	colonIndex := strings.Index(id, ":")
+	cloudProvider := id[0:colonIndex]
+
+	lastSlashIndex := strings.LastIndex(id, "/")
+	instance := id[lastSlashIndex+1:]
+
+	return &ProviderID{
+		original:      id,
+		cloudProvider: cloudProvider,
+		id:            instance,
+	}, nil
+}
+
+// CloudProvider returns the cloud provider portion of the ProviderID.
+func (p *ProviderID) CloudProvider() string {
+	return p.cloudProvider
+}
+
+// ID returns the identifier portion of the ProviderID.
+func (p *ProviderID) ID() string {
+	return p.id
+}
+
+// Equals returns true if both the CloudProvider and ID match. The optional segments in
+// between are ignored, as they are not always reported the same way by nodes and providers.
+func (p *ProviderID) Equals(o *ProviderID) bool {
+	return p.IndexKey() == o.IndexKey()
+}
+
+// IndexKey returns a string concatenating the cloudProvider and the ID parts of the providerID.
+// It is used as the key to index objects by their ProviderID.
+func (p *ProviderID) IndexKey() string {
+	return p.cloudProvider + "://" + p.id
+}
+
+// String returns the string representation of this object.
+func (p *ProviderID) String() string {
+	return p.original
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderefutil
+
+import (
+	"testing"
+)
+
+func TestNewProviderID(t *testing.T) {
+	tests := []struct {
+		name                  string
+		input                 string
+		expectedErr           error
+		expectedCloudProvider string
+		expectedID            string
+	}{
+		{
+			name:        "empty",
+			input:       "",
+			expectedErr: ErrEmptyProviderID,
+		},
+		{
+			name:        "no cloud provider",
+			input:       "i-1234",
+			expectedErr: ErrInvalidProviderID,
+		},
+		{
+			name:        "trailing slash",
+			input:       "aws:///us-east-1a/",
+			expectedErr: ErrInvalidProviderID,
+		},
+		{
+			name:                  "no segments",
+			input:                 "aws://i-1234",
+			expectedCloudProvider: "aws",
+			expectedID:            "i-1234",
+		},
+		{
+			name:                  "segments",
+			input:                 "aws:///us-east-1a/i-1234",
+			expectedCloudProvider: "aws",
+			expectedID:            "i-1234",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := NewProviderID(tc.input)
+			if err != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if id.CloudProvider() != tc.expectedCloudProvider {
+				t.Errorf("expected cloud provider %q, got %q", tc.expectedCloudProvider, id.CloudProvider())
+			}
+			if id.ID() != tc.expectedID {
+				t.Errorf("expected id %q, got %q", tc.expectedID, id.ID())
+			}
+			if id.String() != tc.input {
+				t.Errorf("expected string %q, got %q", tc.input, id.String())
+			}
+		})
+	}
+}
+
+func TestProviderIDEquals(t *testing.T) {
+	a, err := NewProviderID("aws:///us-east-1a/i-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := NewProviderID("aws:////i-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c, err := NewProviderID("gce:///us-east-1a/i-1234")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !a.Equals(b) {
+		t.Errorf("expected %q to equal %q", a, b)
+	}
+	if a.Equals(c) {
+		t.Errorf("expected %q not to equal %q", a, c)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"sigs.k8s.io/cluster-api/pkg/actuator"
+	pb "sigs.k8s.io/cluster-api/pkg/actuator/v1alpha1"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+const unixScheme = "unix://"
+
+// Dial connects to the actuators served at target, which is either a unix:///path/to/socket
+// or a host:port address. The connection is not encrypted, it is meant to reach a provider
+// running next to the manager, e.g. in a sidecar container sharing a socket with it.
+func Dial(target string) (*grpc.ClientConn, error) {
+	return grpc.Dial(target,
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			network, address := splitTarget(addr)
+			return (&net.Dialer{}).DialContext(ctx, network, address)
+		}),
+	)
+}
+
+// splitTarget returns the network and the address of target.
+func splitTarget(target string) (string, string) {
+	if strings.HasPrefix(target, unixScheme) {
+		return "unix", strings.TrimPrefix(target, unixScheme)
+	}
+	return "tcp", target
+}
+
+// NewMachineActuator returns a machine actuator calling the MachineActuator service
+// served on conn. The deadline of the context passed to the actuator is propagated
+// to the service.
+func NewMachineActuator(conn *grpc.ClientConn) actuator.Machine {
+	return &machineActuatorClient{client: pb.NewMachineActuatorClient(conn)}
+}
+
+type machineActuatorClient struct {
+	client pb.MachineActuatorClient
+}
+
+type machineCall func(context.Context, *pb.MachineRequest, ...grpc.CallOption) (*pb.MachineResponse, error)
+
+func (c *machineActuatorClient) Create(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (actuator.MachineResult, error) {
+	return c.call(ctx, c.client.Create, cluster, m)
+}
+
+func (c *machineActuatorClient) Update(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (actuator.MachineResult, error) {
+	return c.call(ctx, c.client.Update, cluster, m)
+}
+
+func (c *machineActuatorClient) Delete(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (actuator.MachineResult, error) {
+	return c.call(ctx, c.client.Delete, cluster, m)
+}
+
+func (c *machineActuatorClient) Exists(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (bool, error) {
+	req, err := newMachineRequest(cluster, m)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.client.Exists(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	return resp.Exists, nil
+}
+
+func (c *machineActuatorClient) call(ctx context.Context, call machineCall, cluster *clusterv1.Cluster, m *clusterv1.Machine) (actuator.MachineResult, error) {
+	req, err := newMachineRequest(cluster, m)
+	if err != nil {
+		return actuator.MachineResult{}, err
+	}
+	resp, err := call(ctx, req)
+	if err != nil {
+		return actuator.MachineResult{}, err
+	}
+	return machineResult(resp)
+}
+
+// NewClusterActuator returns a cluster actuator calling the ClusterActuator service
+// served on conn. The deadline of the context passed to the actuator is propagated
+// to the service.
+func NewClusterActuator(conn *grpc.ClientConn) actuator.Cluster {
+	return &clusterActuatorClient{client: pb.NewClusterActuatorClient(conn)}
+}
+
+type clusterActuatorClient struct {
+	client pb.ClusterActuatorClient
+}
+
+type clusterCall func(context.Context, *pb.ClusterRequest, ...grpc.CallOption) (*pb.ClusterResponse, error)
+
+func (c *clusterActuatorClient) Reconcile(ctx context.Context, cl *clusterv1.Cluster) (actuator.ClusterResult, error) {
+	return c.call(ctx, c.client.Reconcile, cl)
+}
+
+func (c *clusterActuatorClient) Delete(ctx context.Context, cl *clusterv1.Cluster) (actuator.ClusterResult, error) {
+	return c.call(ctx, c.client.Delete, cl)
+}
+
+func (c *clusterActuatorClient) call(ctx context.Context, call clusterCall, cl *clusterv1.Cluster) (actuator.ClusterResult, error) {
+	req, err := newClusterRequest(cl)
+	if err != nil {
+		return actuator.ClusterResult{}, err
+	}
+	resp, err := call(ctx, req)
+	if err != nil {
+		return actuator.ClusterResult{}, err
+	}
+	return clusterResult(resp)
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/pkg/actuator"
+	pb "sigs.k8s.io/cluster-api/pkg/actuator/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+)
+
+// encodeCluster encodes cluster as JSON, with its apiVersion and kind set. A nil
+// cluster is encoded as no bytes.
+func encodeCluster(cluster *clusterv1.Cluster) ([]byte, error) {
+	if cluster == nil {
+		return nil, nil
+	}
+	cluster = cluster.DeepCopy()
+	cluster.APIVersion, cluster.Kind = clusterv1.SchemeGroupVersion.WithKind("Cluster").ToAPIVersionAndKind()
+	return json.Marshal(cluster)
+}
+
+// decodeCluster is the reverse of encodeCluster.
+func decodeCluster(data []byte) (*clusterv1.Cluster, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	cluster := &clusterv1.Cluster{}
+	if err := json.Unmarshal(data, cluster); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the cluster")
+	}
+	return cluster, nil
+}
+
+// encodeMachine encodes machine as JSON, with its apiVersion and kind set.
+func encodeMachine(machine *clusterv1.Machine) ([]byte, error) {
+	machine = machine.DeepCopy()
+	machine.APIVersion, machine.Kind = clusterv1.SchemeGroupVersion.WithKind("Machine").ToAPIVersionAndKind()
+	return json.Marshal(machine)
+}
+
+// decodeMachine is the reverse of encodeMachine.
+func decodeMachine(data []byte) (*clusterv1.Machine, error) {
+	machine := &clusterv1.Machine{}
+	if err := json.Unmarshal(data, machine); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the machine")
+	}
+	return machine, nil
+}
+
+func newMachineRequest(cluster *clusterv1.Cluster, machine *clusterv1.Machine) (*pb.MachineRequest, error) {
+	clusterData, err := encodeCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	machineData, err := encodeMachine(machine)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MachineRequest{Cluster: clusterData, Machine: machineData}, nil
+}
+
+func newClusterRequest(cluster *clusterv1.Cluster) (*pb.ClusterRequest, error) {
+	clusterData, err := encodeCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ClusterRequest{Cluster: clusterData}, nil
+}
+
+func newMachineResponse(result actuator.MachineResult) *pb.MachineResponse {
+	resp := &pb.MachineResponse{
+		RequeueAfter:   requeueAfterProto(result.RequeueAfter),
+		ProviderStatus: rawBytes(result.ProviderStatus),
+	}
+	if result.Error != nil {
+		resp.Error = &pb.TerminalError{Reason: string(result.Error.Reason), Message: result.Error.Message}
+	}
+	for _, address := range result.Addresses {
+		resp.Addresses = append(resp.Addresses, &pb.NodeAddress{Type: string(address.Type), Address: address.Address})
+	}
+	return resp
+}
+
+func machineResult(resp *pb.MachineResponse) (actuator.MachineResult, error) {
+	requeueAfter, err := requeueAfter(resp.RequeueAfter)
+	if err != nil {
+		return actuator.MachineResult{}, err
+	}
+	result := actuator.MachineResult{
+		RequeueAfter:   requeueAfter,
+		ProviderStatus: rawExtension(resp.ProviderStatus),
+	}
+	if resp.Error != nil {
+		result.Error = &capierrors.MachineError{Reason: common.MachineStatusError(resp.Error.Reason), Message: resp.Error.Message}
+	}
+	for _, address := range resp.Addresses {
+		result.Addresses = append(result.Addresses, corev1.NodeAddress{Type: corev1.NodeAddressType(address.Type), Address: address.Address})
+	}
+	return result, nil
+}
+
+func newClusterResponse(result actuator.ClusterResult) *pb.ClusterResponse {
+	resp := &pb.ClusterResponse{
+		RequeueAfter:   requeueAfterProto(result.RequeueAfter),
+		ProviderStatus: rawBytes(result.ProviderStatus),
+	}
+	if result.Error != nil {
+		resp.Error = &pb.TerminalError{Reason: string(result.Error.Reason), Message: result.Error.Message}
+	}
+	for _, endpoint := range result.APIEndpoints {
+		resp.ApiEndpoints = append(resp.ApiEndpoints, &pb.APIEndpoint{Host: endpoint.Host, Port: int32(endpoint.Port)})
+	}
+	return resp
+}
+
+func clusterResult(resp *pb.ClusterResponse) (actuator.ClusterResult, error) {
+	requeueAfter, err := requeueAfter(resp.RequeueAfter)
+	if err != nil {
+		return actuator.ClusterResult{}, err
+	}
+	result := actuator.ClusterResult{
+		RequeueAfter:   requeueAfter,
+		ProviderStatus: rawExtension(resp.ProviderStatus),
+	}
+	if resp.Error != nil {
+		result.Error = &capierrors.ClusterError{Reason: common.ClusterStatusError(resp.Error.Reason), Message: resp.Error.Message}
+	}
+	for _, endpoint := range resp.ApiEndpoints {
+		result.APIEndpoints = append(result.APIEndpoints, clusterv1.APIEndpoint{Host: endpoint.Host, Port: int(endpoint.Port)})
+	}
+	return result, nil
+}
+
+// requeueAfterProto returns d as a Duration message, or nil if it is zero.
+func requeueAfterProto(d time.Duration) *duration.Duration {
+	if d == 0 {
+		return nil
+	}
+	return ptypes.DurationProto(d)
+}
+
+// requeueAfter is the reverse of requeueAfterProto.
+func requeueAfter(d *duration.Duration) (time.Duration, error) {
+	if d == nil {
+		return 0, nil
+	}
+	requeueAfter, err := ptypes.Duration(d)
+	return requeueAfter, errors.Wrap(err, "invalid requeue delay")
+}
+
+// rawBytes returns the JSON bytes of raw, or nil if it is not set.
+func rawBytes(raw *runtime.RawExtension) []byte {
+	if raw == nil {
+		return nil
+	}
+	return raw.Raw
+}
+
+// rawExtension is the reverse of rawBytes.
+func rawExtension(data []byte) *runtime.RawExtension {
+	if len(data) == 0 {
+		return nil
+	}
+	return &runtime.RawExtension{Raw: data}
+}
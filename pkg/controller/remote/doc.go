@@ -0,0 +1,26 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package remote runs machine and cluster actuators out of process, behind a gRPC
+// service. The manager calls the actuators of a provider through the clients of this
+// package, and the provider serves its actuators with the servers of this package,
+// so that both binaries can be built and upgraded independently.
+//
+// The services are defined in pkg/actuator/v1alpha1/actuator.proto, from which clients
+// and servers can be generated for any language. The Cluster and Machine objects are
+// carried as JSON, as they are served by the API server. This package only depends on
+// the API types and on package actuator, not on the controllers and their manager.
+package remote
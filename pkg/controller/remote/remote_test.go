@@ -0,0 +1,191 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/pkg/actuator"
+	pb "sigs.k8s.io/cluster-api/pkg/actuator/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+)
+
+type fakeMachineActuator struct {
+	result   actuator.MachineResult
+	err      error
+	exists   bool
+	machine  *clusterv1.Machine
+	deadline bool
+}
+
+func (a *fakeMachineActuator) Create(ctx context.Context, _ *clusterv1.Cluster, m *clusterv1.Machine) (actuator.MachineResult, error) {
+	a.machine = m
+	_, a.deadline = ctx.Deadline()
+	return a.result, a.err
+}
+
+func (a *fakeMachineActuator) Update(ctx context.Context, c *clusterv1.Cluster, m *clusterv1.Machine) (actuator.MachineResult, error) {
+	return a.Create(ctx, c, m)
+}
+
+func (a *fakeMachineActuator) Delete(ctx context.Context, c *clusterv1.Cluster, m *clusterv1.Machine) (actuator.MachineResult, error) {
+	return a.Create(ctx, c, m)
+}
+
+func (a *fakeMachineActuator) Exists(context.Context, *clusterv1.Cluster, *clusterv1.Machine) (bool, error) {
+	return a.exists, a.err
+}
+
+type fakeClusterActuator struct {
+	result actuator.ClusterResult
+}
+
+func (a *fakeClusterActuator) Reconcile(context.Context, *clusterv1.Cluster) (actuator.ClusterResult, error) {
+	return a.result, nil
+}
+
+func (a *fakeClusterActuator) Delete(context.Context, *clusterv1.Cluster) (actuator.ClusterResult, error) {
+	return a.result, nil
+}
+
+func serve(t *testing.T, machineActuator actuator.Machine, clusterActuator actuator.Cluster) (actuator.Machine, actuator.Cluster, func()) {
+	conn, stop := serveConn(t, machineActuator, clusterActuator)
+	return NewMachineActuator(conn), NewClusterActuator(conn), stop
+}
+
+func serveConn(t *testing.T, machineActuator actuator.Machine, clusterActuator actuator.Cluster) (*grpc.ClientConn, func()) {
+	dir, err := ioutil.TempDir("", "remote")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	target := unixScheme + filepath.Join(dir, "actuator.sock")
+
+	lis, err := Listen(target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := NewServer(machineActuator, clusterActuator)
+	go s.Serve(lis)
+
+	conn, err := Dial(target)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return conn, func() {
+		conn.Close()
+		s.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestMachineActuator(t *testing.T) {
+	expected := actuator.MachineResult{
+		RequeueAfter:   time.Minute,
+		Error:          capierrors.CreateMachine("no quota"),
+		ProviderStatus: &runtime.RawExtension{Raw: []byte(`{"instanceID":"i-1234"}`)},
+		Addresses:      []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+	}
+	server := &fakeMachineActuator{result: expected, exists: true}
+	client, _, stop := serve(t, server, nil)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	m := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default"}}
+	result, err := client.Create(ctx, nil, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected result %+v, got %+v", expected, result)
+	}
+	if server.machine == nil || server.machine.Name != "machine" {
+		t.Errorf("expected the machine to be sent to the server, got %+v", server.machine)
+	}
+	if !server.deadline {
+		t.Error("expected the deadline of the context to be propagated to the server")
+	}
+
+	exists, err := client.Exists(ctx, nil, m)
+	if err != nil || !exists {
+		t.Errorf("expected the machine to exist, got %v, %v", exists, err)
+	}
+
+	server.err = errors.New("cloud unavailable")
+	if _, err := client.Update(ctx, nil, m); err == nil || !strings.Contains(err.Error(), "cloud unavailable") {
+		t.Errorf("expected a transient error, got %v", err)
+	}
+}
+
+func TestClusterActuator(t *testing.T) {
+	expected := actuator.ClusterResult{
+		Error:        &capierrors.ClusterError{Reason: common.CreateClusterError, Message: "no quota"},
+		APIEndpoints: []clusterv1.APIEndpoint{{Host: "10.0.0.1", Port: 6443}},
+	}
+	_, client, stop := serve(t, nil, &fakeClusterActuator{result: expected})
+	defer stop()
+
+	result, err := client.Reconcile(context.Background(), &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected result %+v, got %+v", expected, result)
+	}
+}
+
+func TestMachineActuatorService(t *testing.T) {
+	server := &fakeMachineActuator{result: actuator.MachineResult{RequeueAfter: time.Minute}}
+	conn, stop := serveConn(t, server, nil)
+	defer stop()
+	client := pb.NewMachineActuatorClient(conn)
+
+	req := &pb.MachineRequest{
+		Machine: []byte(`{"apiVersion":"cluster.k8s.io/v1alpha1","kind":"Machine","metadata":{"name":"machine"}}`),
+	}
+	resp, err := client.Create(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.RequeueAfter == nil || resp.RequeueAfter.Seconds != 60 {
+		t.Errorf("expected a requeue after 60s, got %v", resp.RequeueAfter)
+	}
+	if server.machine == nil || server.machine.Name != "machine" {
+		t.Errorf("expected the machine to be decoded by the server, got %+v", server.machine)
+	}
+
+	_, err = client.Create(context.Background(), &pb.MachineRequest{Machine: []byte("not json")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected an InvalidArgument error for a machine which can't be decoded, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/cluster-api/pkg/actuator"
+	pb "sigs.k8s.io/cluster-api/pkg/actuator/v1alpha1"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// NewServer returns a gRPC server serving the given actuators, either of which may be
+// nil if the provider doesn't implement it. Actuators implementing the v1 interfaces
+// can be served through machine.NewV1Adapter and cluster.NewV1Adapter.
+func NewServer(machineActuator actuator.Machine, clusterActuator actuator.Cluster, opts ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opts...)
+	if machineActuator != nil {
+		pb.RegisterMachineActuatorServer(s, NewMachineActuatorServer(machineActuator))
+	}
+	if clusterActuator != nil {
+		pb.RegisterClusterActuatorServer(s, NewClusterActuatorServer(clusterActuator))
+	}
+	return s
+}
+
+// Listen listens on target, which is either a unix:///path/to/socket or a host:port
+// address. A stale socket left by a previous server is removed.
+func Listen(target string) (net.Listener, error) {
+	network, address := splitTarget(target)
+	if network == "unix" {
+		if err := os.Remove(address); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return net.Listen(network, address)
+}
+
+// NewMachineActuatorServer returns a MachineActuatorServer calling the given actuator.
+func NewMachineActuatorServer(machineActuator actuator.Machine) pb.MachineActuatorServer {
+	return &machineActuatorServer{actuator: machineActuator}
+}
+
+type machineActuatorServer struct {
+	actuator actuator.Machine
+}
+
+type machineMethod func(context.Context, *clusterv1.Cluster, *clusterv1.Machine) (actuator.MachineResult, error)
+
+func (s *machineActuatorServer) Create(ctx context.Context, req *pb.MachineRequest) (*pb.MachineResponse, error) {
+	return s.call(ctx, s.actuator.Create, req)
+}
+
+func (s *machineActuatorServer) Update(ctx context.Context, req *pb.MachineRequest) (*pb.MachineResponse, error) {
+	return s.call(ctx, s.actuator.Update, req)
+}
+
+func (s *machineActuatorServer) Delete(ctx context.Context, req *pb.MachineRequest) (*pb.MachineResponse, error) {
+	return s.call(ctx, s.actuator.Delete, req)
+}
+
+func (s *machineActuatorServer) Exists(ctx context.Context, req *pb.MachineRequest) (*pb.ExistsResponse, error) {
+	cluster, machine, err := decodeMachineRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.actuator.Exists(ctx, cluster, machine)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ExistsResponse{Exists: exists}, nil
+}
+
+func (s *machineActuatorServer) call(ctx context.Context, method machineMethod, req *pb.MachineRequest) (*pb.MachineResponse, error) {
+	cluster, machine, err := decodeMachineRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := method(ctx, cluster, machine)
+	if err != nil {
+		return nil, err
+	}
+	return newMachineResponse(result), nil
+}
+
+// decodeMachineRequest returns the cluster and the machine of req. Objects which
+// can't be decoded are reported with the InvalidArgument code.
+func decodeMachineRequest(req *pb.MachineRequest) (*clusterv1.Cluster, *clusterv1.Machine, error) {
+	cluster, err := decodeCluster(req.Cluster)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	machine, err := decodeMachine(req.Machine)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return cluster, machine, nil
+}
+
+// NewClusterActuatorServer returns a ClusterActuatorServer calling the given actuator.
+func NewClusterActuatorServer(clusterActuator actuator.Cluster) pb.ClusterActuatorServer {
+	return &clusterActuatorServer{actuator: clusterActuator}
+}
+
+type clusterActuatorServer struct {
+	actuator actuator.Cluster
+}
+
+type clusterMethod func(context.Context, *clusterv1.Cluster) (actuator.ClusterResult, error)
+
+func (s *clusterActuatorServer) Reconcile(ctx context.Context, req *pb.ClusterRequest) (*pb.ClusterResponse, error) {
+	return s.call(ctx, s.actuator.Reconcile, req)
+}
+
+func (s *clusterActuatorServer) Delete(ctx context.Context, req *pb.ClusterRequest) (*pb.ClusterResponse, error) {
+	return s.call(ctx, s.actuator.Delete, req)
+}
+
+func (s *clusterActuatorServer) call(ctx context.Context, method clusterMethod, req *pb.ClusterRequest) (*pb.ClusterResponse, error) {
+	cluster, err := decodeCluster(req.Cluster)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if cluster == nil {
+		return nil, status.Error(codes.InvalidArgument, "the cluster is required")
+	}
+	result, err := method(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return newClusterResponse(result), nil
+}
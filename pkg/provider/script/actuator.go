@@ -0,0 +1,271 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package script implements a machine actuator that delegates to executables,
+// allowing providers to be written as scripts.
+//
+// Each command receives the Machine and its Cluster serialised as JSON on
+// stdin and reports its outcome through its exit code. On success the create
+// and update commands may print a JSON Output on stdout.
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/cmdrunner"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Exit codes with a special meaning, taken from sysexits.h. Any other non-zero
+// exit code is a transient error and the operation is retried with backoff.
+const (
+	// ExitCodeNotFound (EX_NOHOST) is returned by the exists command when the
+	// machine does not exist. It is distinct from the generic failure code 1,
+	// so that a command failing for other reasons isn't mistaken for a
+	// missing machine.
+	ExitCodeNotFound = 68
+	// ExitCodeRequeue requests the operation to be retried after
+	// RequeueAfter, for example while waiting for the machine to boot.
+	ExitCodeRequeue = 75
+	// ExitCodeInvalidConfiguration reports a terminal
+	// InvalidConfiguration error.
+	ExitCodeInvalidConfiguration = 78
+	// ExitCodeUnsupportedChange reports a terminal UnsupportedChange error.
+	ExitCodeUnsupportedChange = 65
+	// ExitCodeInsufficientResources reports a terminal
+	// InsufficientResources error.
+	ExitCodeInsufficientResources = 69
+	// ExitCodeFailed reports a terminal CreateError, UpdateError or
+	// DeleteError, depending on the operation. The exists command has no
+	// terminal failure, for it this is a transient error.
+	ExitCodeFailed = 70
+)
+
+// DefaultRequeueAfter is used when ActuatorParams.RequeueAfter is not set.
+const DefaultRequeueAfter = 30 * time.Second
+
+// Commands holds the command lines run for each operation. The first element
+// is the executable, the rest are its arguments.
+type Commands struct {
+	Create []string
+	// Update is optional, machines are left unchanged when it is empty.
+	Update []string
+	Delete []string
+	Exists []string
+}
+
+// Input is written as JSON on the stdin of every command.
+type Input struct {
+	Cluster *clusterv1.Cluster `json:"cluster"`
+	Machine *clusterv1.Machine `json:"machine"`
+}
+
+// Output may be printed as JSON on stdout by the create and update commands.
+// Fields that are not set leave the machine unchanged.
+type Output struct {
+	ProviderID     *string               `json:"providerID,omitempty"`
+	Addresses      []corev1.NodeAddress  `json:"addresses,omitempty"`
+	ProviderStatus *runtime.RawExtension `json:"providerStatus,omitempty"`
+}
+
+// ActuatorParams holds parameter information for Actuator.
+type ActuatorParams struct {
+	Commands Commands
+	// Client is used to persist the Output of the commands.
+	Client client.Client
+	// Runner defaults to cmdrunner.New().
+	Runner cmdrunner.InputRunner
+	// RequeueAfter defaults to DefaultRequeueAfter.
+	RequeueAfter time.Duration
+}
+
+// Actuator is a machine actuator running the configured Commands.
+type Actuator struct {
+	commands     Commands
+	client       client.Client
+	runner       cmdrunner.InputRunner
+	requeueAfter time.Duration
+}
+
+// NewActuator returns an Actuator running the commands in params.
+func NewActuator(params ActuatorParams) (*Actuator, error) {
+	if len(params.Commands.Create) == 0 || len(params.Commands.Delete) == 0 || len(params.Commands.Exists) == 0 {
+		return nil, errors.New("the create, delete and exists commands are required")
+	}
+	if params.Client == nil {
+		return nil, errors.New("a client is required")
+	}
+	a := &Actuator{
+		commands:     params.Commands,
+		client:       params.Client,
+		runner:       params.Runner,
+		requeueAfter: params.RequeueAfter,
+	}
+	if a.runner == nil {
+		a.runner = cmdrunner.New()
+	}
+	if a.requeueAfter <= 0 {
+		a.requeueAfter = DefaultRequeueAfter
+	}
+	return a, nil
+}
+
+// Create runs the create command and persists its Output.
+func (a *Actuator) Create(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	stdout, err := a.run(ctx, a.commands.Create, common.CreateMachineError, cluster, machine)
+	if err != nil {
+		return err
+	}
+	return a.apply(ctx, machine, stdout)
+}
+
+// Update runs the update command, if any, and persists its Output.
+func (a *Actuator) Update(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	if len(a.commands.Update) == 0 {
+		return nil
+	}
+	stdout, err := a.run(ctx, a.commands.Update, common.UpdateMachineError, cluster, machine)
+	if err != nil {
+		return err
+	}
+	return a.apply(ctx, machine, stdout)
+}
+
+// Delete runs the delete command.
+func (a *Actuator) Delete(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) error {
+	_, err := a.run(ctx, a.commands.Delete, common.DeleteMachineError, cluster, machine)
+	return err
+}
+
+// Exists runs the exists command. The machine exists if it exits with 0 and
+// does not if it exits with ExitCodeNotFound. ExitCodeFailed and the generic
+// failure code 1 are retried like any other transient error.
+func (a *Actuator) Exists(ctx context.Context, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (bool, error) {
+	_, err := a.run(ctx, a.commands.Exists, "", cluster, machine)
+	if err == nil {
+		return true, nil
+	}
+	if exitErr, ok := errors.Cause(err).(*exitError); ok && exitErr.code == ExitCodeNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// run runs command with the cluster and machine on stdin, returning its stdout
+// on success. Exit codes are mapped to errors by toError, an empty
+// failedReason makes ExitCodeFailed a transient error.
+func (a *Actuator) run(ctx context.Context, command []string, failedReason common.MachineStatusError, cluster *clusterv1.Cluster, machine *clusterv1.Machine) (string, error) {
+	input, err := json.Marshal(&Input{Cluster: cluster, Machine: machine})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to serialise machine %q", machine.Name)
+	}
+
+	klog.V(4).Infof("Running %q for machine %q", strings.Join(command, " "), machine.Name)
+	stdout, stderr, err := a.runner.Output(ctx, string(input), command[0], command[1:]...)
+	if err == nil {
+		return stdout, nil
+	}
+	return "", a.toError(command[0], failedReason, stderr, err)
+}
+
+// toError maps the error returned by a command to a RequeueAfterError, a
+// terminal MachineError or a transient error.
+func (a *Actuator) toError(name string, failedReason common.MachineStatusError, stderr string, err error) error {
+	code, ok := cmdrunner.ExitCode(err)
+	if !ok {
+		return errors.Wrapf(err, "failed to run %q", name)
+	}
+
+	message := strings.TrimSpace(stderr)
+	if message == "" {
+		message = fmt.Sprintf("%q exited with code %d", name, code)
+	}
+
+	switch code {
+	case ExitCodeRequeue:
+		return &controllerError.RequeueAfterError{RequeueAfter: a.requeueAfter}
+	case ExitCodeInvalidConfiguration:
+		return &capierrors.MachineError{Reason: common.InvalidConfigurationMachineError, Message: message}
+	case ExitCodeUnsupportedChange:
+		return &capierrors.MachineError{Reason: common.UnsupportedChangeMachineError, Message: message}
+	case ExitCodeInsufficientResources:
+		return &capierrors.MachineError{Reason: common.InsufficientResourcesMachineError, Message: message}
+	case ExitCodeFailed:
+		if failedReason == "" {
+			break
+		}
+		return &capierrors.MachineError{Reason: failedReason, Message: message}
+	}
+	return &exitError{name: name, code: code, message: message}
+}
+
+// exitError is a transient error reported by a command exiting with a
+// non-zero code.
+type exitError struct {
+	name    string
+	code    int
+	message string
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("%q exited with code %d: %s", e.name, e.code, e.message)
+}
+
+// apply persists the Output printed on stdout to the machine.
+func (a *Actuator) apply(ctx context.Context, machine *clusterv1.Machine, stdout string) error {
+	if strings.TrimSpace(stdout) == "" {
+		return nil
+	}
+	output := &Output{}
+	if err := json.Unmarshal([]byte(stdout), output); err != nil {
+		return errors.Wrapf(err, "failed to parse the output for machine %q", machine.Name)
+	}
+
+	if output.ProviderID != nil && (machine.Spec.ProviderID == nil || *machine.Spec.ProviderID != *output.ProviderID) {
+		base := machine.DeepCopy()
+		machine.Spec.ProviderID = output.ProviderID
+		if err := a.client.Patch(ctx, machine, client.MergeFrom(base)); err != nil {
+			return errors.Wrapf(err, "failed to set the provider ID of machine %q", machine.Name)
+		}
+	}
+
+	if output.Addresses == nil && output.ProviderStatus == nil {
+		return nil
+	}
+	base := machine.DeepCopy()
+	if output.Addresses != nil {
+		machine.Status.Addresses = output.Addresses
+	}
+	if output.ProviderStatus != nil {
+		machine.Status.ProviderStatus = output.ProviderStatus
+	}
+	if err := a.client.Status().Patch(ctx, machine, client.MergeFrom(base)); err != nil {
+		return errors.Wrapf(err, "failed to update the status of machine %q", machine.Name)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	controllerError "sigs.k8s.io/cluster-api/pkg/controller/error"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+	"sigs.k8s.io/cluster-api/pkg/testcmdrunner"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var commands = Commands{
+	Create: []string{"provision", "create"},
+	Update: []string{"provision", "update"},
+	Delete: []string{"provision", "delete"},
+	Exists: []string{"provision", "exists"},
+}
+
+func newTestActuator(t *testing.T, machine *clusterv1.Machine, callback func(input string, cmd string, args ...string) (string, string, error)) (*Actuator, client.Client) {
+	if err := clusterv1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewFakeClientWithScheme(scheme.Scheme, machine)
+	a, err := NewActuator(ActuatorParams{
+		Commands:     commands,
+		Client:       c,
+		Runner:       testcmdrunner.NewInput(callback),
+		RequeueAfter: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, c
+}
+
+func newMachine() *clusterv1.Machine {
+	return &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default"},
+	}
+}
+
+func TestNewActuatorRequiresCommands(t *testing.T) {
+	if _, err := NewActuator(ActuatorParams{Commands: Commands{Create: []string{"create"}}, Client: fake.NewFakeClient()}); err == nil {
+		t.Error("expected an error when the delete and exists commands are missing")
+	}
+}
+
+func TestCreateAppliesOutput(t *testing.T) {
+	machine := newMachine()
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+	var input Input
+	a, c := newTestActuator(t, machine, func(stdin string, cmd string, args ...string) (string, string, error) {
+		if cmd != "provision" || len(args) != 1 || args[0] != "create" {
+			t.Errorf("unexpected command %q %v", cmd, args)
+		}
+		if err := json.Unmarshal([]byte(stdin), &input); err != nil {
+			t.Errorf("failed to parse input: %v", err)
+		}
+		return `{"providerID": "script:///machine", "addresses": [{"type": "InternalIP", "address": "10.0.0.1"}], "providerStatus": {"state": "running"}}`, "", nil
+	})
+
+	if err := a.Create(context.Background(), cluster, machine); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.Machine == nil || input.Machine.Name != "machine" || input.Cluster == nil || input.Cluster.Name != "cluster" {
+		t.Errorf("unexpected input: %+v", input)
+	}
+
+	got := &clusterv1.Machine{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "machine", Namespace: "default"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.ProviderID == nil || *got.Spec.ProviderID != "script:///machine" {
+		t.Errorf("expected the provider ID to be set, got %v", got.Spec.ProviderID)
+	}
+	if len(got.Status.Addresses) != 1 || got.Status.Addresses[0].Type != corev1.NodeInternalIP || got.Status.Addresses[0].Address != "10.0.0.1" {
+		t.Errorf("unexpected addresses: %v", got.Status.Addresses)
+	}
+	if got.Status.ProviderStatus == nil || string(got.Status.ProviderStatus.Raw) != `{"state":"running"}` {
+		t.Errorf("unexpected provider status: %v", got.Status.ProviderStatus)
+	}
+}
+
+func TestCreateRejectsInvalidOutput(t *testing.T) {
+	machine := newMachine()
+	a, _ := newTestActuator(t, machine, func(string, string, ...string) (string, string, error) {
+		return "not json", "", nil
+	})
+	if err := a.Create(context.Background(), nil, machine); err == nil {
+		t.Error("expected an error for output that is not JSON")
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	testCases := []struct {
+		name         string
+		code         int
+		stderr       string
+		expectReason common.MachineStatusError
+		expectError  string
+	}{
+		{name: "invalid configuration", code: ExitCodeInvalidConfiguration, stderr: "bad image\n", expectReason: common.InvalidConfigurationMachineError, expectError: "bad image"},
+		{name: "unsupported change", code: ExitCodeUnsupportedChange, expectReason: common.UnsupportedChangeMachineError, expectError: `"provision" exited with code 65`},
+		{name: "insufficient resources", code: ExitCodeInsufficientResources, expectReason: common.InsufficientResourcesMachineError},
+		{name: "failed", code: ExitCodeFailed, expectReason: common.CreateMachineError},
+		{name: "transient", code: 2, stderr: "timeout", expectError: `"provision" exited with code 2: timeout`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := newMachine()
+			a, _ := newTestActuator(t, machine, func(string, string, ...string) (string, string, error) {
+				return "", tc.stderr, &testcmdrunner.ExitError{Code: tc.code}
+			})
+			err := a.Create(context.Background(), nil, machine)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			machineErr, isMachineErr := err.(*capierrors.MachineError)
+			if tc.expectReason != "" {
+				if !isMachineErr || machineErr.Reason != tc.expectReason {
+					t.Errorf("expected a MachineError with reason %q, got %v", tc.expectReason, err)
+				}
+			} else if isMachineErr {
+				t.Errorf("expected a transient error, got %v", err)
+			}
+			if tc.expectError != "" && err.Error() != tc.expectError {
+				t.Errorf("expected error %q, got %q", tc.expectError, err.Error())
+			}
+		})
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	machine := newMachine()
+	a, _ := newTestActuator(t, machine, func(string, string, ...string) (string, string, error) {
+		return "", "", &testcmdrunner.ExitError{Code: ExitCodeRequeue}
+	})
+	err := a.Delete(context.Background(), nil, machine)
+	requeueErr, ok := err.(*controllerError.RequeueAfterError)
+	if !ok || requeueErr.RequeueAfter != time.Minute {
+		t.Errorf("expected a RequeueAfterError after 1m, got %v", err)
+	}
+}
+
+func TestExists(t *testing.T) {
+	testCases := []struct {
+		name        string
+		err         error
+		expected    bool
+		expectError bool
+	}{
+		{name: "exists", expected: true},
+		{name: "not found", err: &testcmdrunner.ExitError{Code: ExitCodeNotFound}},
+		{name: "transient", err: &testcmdrunner.ExitError{Code: 2}, expectError: true},
+		{name: "generic failure is transient", err: &testcmdrunner.ExitError{Code: 1}, expectError: true},
+		{name: "failed is transient", err: &testcmdrunner.ExitError{Code: ExitCodeFailed}, expectError: true},
+		{name: "not run", err: errors.New("executable not found"), expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			machine := newMachine()
+			a, _ := newTestActuator(t, machine, func(string, string, ...string) (string, string, error) {
+				return "", "", tc.err
+			})
+			exists, err := a.Exists(context.Background(), nil, machine)
+			if (err != nil) != tc.expectError {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if _, ok := err.(*capierrors.MachineError); ok {
+				t.Errorf("expected a transient error, got %v", err)
+			}
+			if exists != tc.expected {
+				t.Errorf("expected exists to be %v, got %v", tc.expected, exists)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulated
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/controller/cluster"
+	capierrors "sigs.k8s.io/cluster-api/pkg/errors"
+)
+
+// ClusterActuator returns a cluster actuator reporting the APIEndpoint of the
+// provider as the API endpoint of every cluster.
+func (p *Provider) ClusterActuator() cluster.ActuatorV2 {
+	return &clusterActuator{provider: p}
+}
+
+type clusterActuator struct {
+	provider *Provider
+}
+
+func (a *clusterActuator) Reconcile(ctx context.Context, c *clusterv1.Cluster) (cluster.Result, error) {
+	host, port, err := net.SplitHostPort(a.provider.options.APIEndpoint)
+	if err != nil {
+		return cluster.Result{Error: capierrors.InvalidClusterConfiguration("invalid API endpoint %q: %v", a.provider.options.APIEndpoint, err)}, nil
+	}
+	portNumber, err := strconv.Atoi(port)
+	if err != nil {
+		return cluster.Result{Error: capierrors.InvalidClusterConfiguration("invalid API endpoint port %q: %v", port, err)}, nil
+	}
+	return cluster.Result{
+		APIEndpoints: []clusterv1.APIEndpoint{{Host: host, Port: portNumber}},
+	}, nil
+}
+
+func (a *clusterActuator) Delete(ctx context.Context, c *clusterv1.Cluster) (cluster.Result, error) {
+	return cluster.Result{}, nil
+}
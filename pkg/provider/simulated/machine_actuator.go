@@ -0,0 +1,236 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulated
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/controller/machine"
+	"sigs.k8s.io/cluster-api/pkg/controller/node"
+	"sigs.k8s.io/cluster-api/pkg/controller/noderefutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// MachineActuator returns a machine actuator backed by the instances of the
+// provider. Creating a machine creates an instance and its node, which becomes
+// Ready after ReadyDelay.
+func (p *Provider) MachineActuator() machine.ActuatorV2 {
+	return &machineActuator{provider: p}
+}
+
+type machineActuator struct {
+	provider *Provider
+}
+
+func (a *machineActuator) Create(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (machine.Result, error) {
+	instance, ok, err := a.instance(ctx, m)
+	if err != nil {
+		return machine.Result{}, err
+	}
+	if !ok {
+		if err := a.reserveIDs(ctx); err != nil {
+			return machine.Result{}, err
+		}
+		instance, err = a.provider.createInstance(machineKey(m))
+		if err != nil {
+			return machine.Result{}, err
+		}
+		klog.Infof("Created simulated instance %q for machine %q", instance.ID, machineKey(m))
+	}
+	return a.reconcile(ctx, m, instance)
+}
+
+func (a *machineActuator) Update(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (machine.Result, error) {
+	instance, ok, err := a.instance(ctx, m)
+	if err != nil {
+		return machine.Result{}, err
+	}
+	if !ok {
+		return machine.Result{}, errors.Errorf("simulated instance of machine %q not found", machineKey(m))
+	}
+	return a.reconcile(ctx, m, instance)
+}
+
+func (a *machineActuator) Delete(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (machine.Result, error) {
+	instance, ok, err := a.instance(ctx, m)
+	if err != nil || !ok {
+		return machine.Result{}, err
+	}
+	if remaining := a.provider.deleteInstance(machineKey(m)); remaining > 0 {
+		return machine.Result{RequeueAfter: remaining}, nil
+	}
+
+	n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: instance.ID}}
+	if err := a.provider.options.NodeClient.Delete(ctx, n); err != nil && !apierrors.IsNotFound(err) {
+		return machine.Result{}, errors.Wrapf(err, "failed to delete node %q", instance.ID)
+	}
+	klog.Infof("Deleted simulated instance %q of machine %q", instance.ID, machineKey(m))
+	return machine.Result{}, nil
+}
+
+func (a *machineActuator) Exists(ctx context.Context, cluster *clusterv1.Cluster, m *clusterv1.Machine) (bool, error) {
+	_, ok, err := a.instance(ctx, m)
+	return ok, err
+}
+
+// instance returns the instance of the machine. An instance the provider
+// doesn't know about, because the process restarted or the machine was moved
+// to another management cluster, is rebuilt from the provider ID of the
+// machine if the node of the instance still exists.
+func (a *machineActuator) instance(ctx context.Context, m *clusterv1.Machine) (Instance, bool, error) {
+	if instance, ok := a.provider.getInstance(machineKey(m)); ok {
+		return instance, true, nil
+	}
+	if m.Spec.ProviderID == nil {
+		return Instance{}, false, nil
+	}
+	providerID, err := noderefutil.NewProviderID(*m.Spec.ProviderID)
+	if err != nil || providerID.CloudProvider() != ProviderName {
+		return Instance{}, false, nil
+	}
+	number, ok := parseInstanceID(providerID.ID())
+	if !ok {
+		return Instance{}, false, nil
+	}
+
+	n := &corev1.Node{}
+	if err := a.provider.options.NodeClient.Get(ctx, client.ObjectKey{Name: providerID.ID()}, n); err != nil {
+		if apierrors.IsNotFound(err) {
+			return Instance{}, false, nil
+		}
+		return Instance{}, false, errors.Wrapf(err, "failed to get node %q", providerID.ID())
+	}
+	if n.Spec.ProviderID != providerID.String() {
+		return Instance{}, false, nil
+	}
+	instance := a.provider.addInstance(machineKey(m), *newInstance(number, n.CreationTimestamp.Time))
+	klog.Infof("Recovered simulated instance %q of machine %q from its node", instance.ID, machineKey(m))
+	return instance, true, nil
+}
+
+// reserveIDs reserves the IDs of the instances of the existing nodes before
+// the first instance is created, so that the instances created after a restart
+// don't take over the nodes of other machines.
+func (a *machineActuator) reserveIDs(ctx context.Context) error {
+	if a.provider.hasReservedIDs() {
+		return nil
+	}
+	nodes := &corev1.NodeList{}
+	if err := a.provider.options.NodeClient.List(ctx, nodes); err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+	var ids []string
+	for _, n := range nodes.Items {
+		providerID, err := noderefutil.NewProviderID(n.Spec.ProviderID)
+		if err == nil && providerID.CloudProvider() == ProviderName {
+			ids = append(ids, providerID.ID())
+		}
+	}
+	a.provider.reserveIDs(ids)
+	return nil
+}
+
+// reconcile sets the provider ID of the machine and the node of the instance,
+// requeueing the machine until the node is Ready.
+func (a *machineActuator) reconcile(ctx context.Context, m *clusterv1.Machine, instance Instance) (machine.Result, error) {
+	if m.Spec.ProviderID == nil || *m.Spec.ProviderID != instance.ProviderID {
+		base := m.DeepCopy()
+		m.Spec.ProviderID = &instance.ProviderID
+		if err := a.provider.options.Client.Patch(ctx, m, client.MergeFrom(base)); err != nil {
+			return machine.Result{}, errors.Wrapf(err, "failed to set the provider ID of machine %q", machineKey(m))
+		}
+	}
+
+	now := a.provider.now()
+	state := instance.State(a.provider.options.ReadyDelay, now)
+	if err := a.reconcileNode(ctx, m, instance, state == "running"); err != nil {
+		return machine.Result{}, err
+	}
+
+	providerStatus, err := json.Marshal(map[string]string{
+		"instanceID":    instance.ID,
+		"instanceState": state,
+	})
+	if err != nil {
+		return machine.Result{}, err
+	}
+	result := machine.Result{
+		Addresses:      instance.addresses(),
+		ProviderStatus: &runtime.RawExtension{Raw: providerStatus},
+	}
+	if state == "pending" {
+		result.RequeueAfter = instance.CreatedAt.Add(a.provider.options.ReadyDelay).Sub(now)
+	}
+	return result, nil
+}
+
+// reconcileNode creates the node of the instance if it doesn't exist and sets
+// its Ready condition.
+func (a *machineActuator) reconcileNode(ctx context.Context, m *clusterv1.Machine, instance Instance, ready bool) error {
+	c := a.provider.options.NodeClient
+	n := &corev1.Node{}
+	err := c.Get(ctx, client.ObjectKey{Name: instance.ID}, n)
+	if apierrors.IsNotFound(err) {
+		n = &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: instance.ID},
+			Spec:       corev1.NodeSpec{ProviderID: instance.ProviderID},
+		}
+		if !a.provider.options.LinkByProviderID {
+			n.Annotations = map[string]string{node.MachineAnnotationKey: machineKey(m)}
+		}
+		if err := c.Create(ctx, n); err != nil {
+			return errors.Wrapf(err, "failed to create node %q", instance.ID)
+		}
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to get node %q", instance.ID)
+	}
+
+	if len(n.Status.Addresses) > 0 && noderefutil.IsNodeReady(n) == ready {
+		return nil
+	}
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	now := metav1.NewTime(a.provider.now())
+	n.Status.Addresses = instance.addresses()
+	n.Status.Conditions = []corev1.NodeCondition{{
+		Type:               corev1.NodeReady,
+		Status:             status,
+		LastHeartbeatTime:  now,
+		LastTransitionTime: now,
+		Reason:             "Simulated",
+		Message:            fmt.Sprintf("simulated instance %s", instance.State(a.provider.options.ReadyDelay, now.Time)),
+	}}
+	if err := c.Status().Update(ctx, n); err != nil {
+		return errors.Wrapf(err, "failed to update the status of node %q", instance.ID)
+	}
+	return nil
+}
+
+func machineKey(m *clusterv1.Machine) string {
+	return fmt.Sprintf("%s/%s", m.Namespace, m.Name)
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulated implements an infrastructure provider keeping fake
+// instances in memory, so that machines, nodes and clusters can be exercised
+// end to end without a cloud, for example against envtest. The instances are
+// rebuilt from the provider IDs of the machines and from their nodes, so that
+// they survive restarts and moves.
+package simulated
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ProviderName is the cloud provider portion of the provider IDs of the
+// simulated instances.
+const ProviderName = "simulated"
+
+// Options configures the behaviour of a Provider.
+type Options struct {
+	// Client is used to set the provider ID of machines.
+	Client client.Client
+	// NodeClient is used to manage the nodes of the instances. It defaults to
+	// Client, i.e. the nodes are created in the management cluster.
+	NodeClient client.Client
+
+	// ReadyDelay is the time it takes for the node of a new instance to
+	// become Ready.
+	ReadyDelay time.Duration
+	// DeleteDelay is the time it takes to delete an instance.
+	DeleteDelay time.Duration
+	// CreateFailures is the number of times the creation of each machine
+	// fails with a transient error before succeeding.
+	CreateFailures int
+	// LinkByProviderID, if set, leaves out the machine annotation of the
+	// nodes so that they are linked to their machines by provider ID only.
+	LinkByProviderID bool
+
+	// APIEndpoint is reported as the API endpoint of the clusters. It
+	// defaults to 127.0.0.1:6443.
+	APIEndpoint string
+}
+
+// Instance is a simulated instance backing a machine.
+type Instance struct {
+	ID         string
+	ProviderID string
+	Address    string
+	CreatedAt  time.Time
+	// DeletingSince is set once the deletion of the instance has started.
+	DeletingSince *time.Time
+}
+
+// State returns the state of the instance at the given time, one of
+// "pending", "running" or "terminating".
+func (i *Instance) State(readyDelay time.Duration, now time.Time) string {
+	switch {
+	case i.DeletingSince != nil:
+		return "terminating"
+	case now.Before(i.CreatedAt.Add(readyDelay)):
+		return "pending"
+	default:
+		return "running"
+	}
+}
+
+// Provider keeps track of the simulated instances, shared by its machine and
+// cluster actuators. It is safe for concurrent use.
+type Provider struct {
+	options Options
+	now     func() time.Time
+
+	lock sync.Mutex
+	// instances are indexed by the namespace/name key of their machine.
+	instances map[string]*Instance
+	// createAttempts counts the failed creations of each machine.
+	createAttempts map[string]int
+	lastID         int
+	// idsReserved is set once the IDs of the instances of the existing nodes
+	// have been reserved, so that new instances don't reuse them.
+	idsReserved bool
+}
+
+// NewProvider returns a Provider with the given options.
+func NewProvider(options Options) *Provider {
+	if options.NodeClient == nil {
+		options.NodeClient = options.Client
+	}
+	if options.APIEndpoint == "" {
+		options.APIEndpoint = "127.0.0.1:6443"
+	}
+	return &Provider{
+		options:        options,
+		now:            time.Now,
+		instances:      map[string]*Instance{},
+		createAttempts: map[string]int{},
+	}
+}
+
+// Instances returns a copy of the current instances, indexed by the
+// namespace/name key of their machine.
+func (p *Provider) Instances() map[string]Instance {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	instances := make(map[string]Instance, len(p.instances))
+	for key, instance := range p.instances {
+		instances[key] = *instance
+	}
+	return instances
+}
+
+func (p *Provider) getInstance(key string) (Instance, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	instance, ok := p.instances[key]
+	if !ok {
+		return Instance{}, false
+	}
+	return *instance, true
+}
+
+// createInstance returns the instance of the machine, creating it if needed.
+// It fails the first CreateFailures times it is called for a machine.
+func (p *Provider) createInstance(key string) (Instance, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if instance, ok := p.instances[key]; ok {
+		return *instance, nil
+	}
+	if p.createAttempts[key] < p.options.CreateFailures {
+		p.createAttempts[key]++
+		return Instance{}, fmt.Errorf("simulated failure %d/%d creating the instance of machine %q",
+			p.createAttempts[key], p.options.CreateFailures, key)
+	}
+	delete(p.createAttempts, key)
+
+	p.lastID++
+	instance := newInstance(p.lastID, p.now())
+	p.instances[key] = instance
+	return *instance, nil
+}
+
+// addInstance records the instance of the machine rebuilt from its node, unless
+// the machine already has one, and returns the instance of the machine.
+func (p *Provider) addInstance(key string, instance Instance) Instance {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if existing, ok := p.instances[key]; ok {
+		return *existing
+	}
+	if n, ok := parseInstanceID(instance.ID); ok && n > p.lastID {
+		p.lastID = n
+	}
+	p.instances[key] = &instance
+	return instance
+}
+
+// reserveIDs makes sure that new instances don't reuse the given IDs, which
+// are those of instances created before a restart.
+func (p *Provider) reserveIDs(ids []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, id := range ids {
+		if n, ok := parseInstanceID(id); ok && n > p.lastID {
+			p.lastID = n
+		}
+	}
+	p.idsReserved = true
+}
+
+func (p *Provider) hasReservedIDs() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.idsReserved
+}
+
+// deleteInstance starts the deletion of the instance of the machine and
+// returns how long is left until it is gone.
+func (p *Provider) deleteInstance(key string) time.Duration {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	instance, ok := p.instances[key]
+	if !ok {
+		return 0
+	}
+	now := p.now()
+	if instance.DeletingSince == nil {
+		instance.DeletingSince = &now
+	}
+	if remaining := instance.DeletingSince.Add(p.options.DeleteDelay).Sub(now); remaining > 0 {
+		return remaining
+	}
+	delete(p.instances, key)
+	return 0
+}
+
+func newInstance(n int, createdAt time.Time) *Instance {
+	id := fmt.Sprintf("i-%08x", n)
+	return &Instance{
+		ID:         id,
+		ProviderID: fmt.Sprintf("%s:///%s", ProviderName, id),
+		Address:    fmt.Sprintf("10.%d.%d.%d", (n>>16)&0xff, (n>>8)&0xff, n&0xff),
+		CreatedAt:  createdAt,
+	}
+}
+
+// parseInstanceID returns the number of an instance ID such as i-00000001.
+func parseInstanceID(id string) (int, bool) {
+	if !strings.HasPrefix(id, "i-") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(id, "i-"), 16, 31)
+	if err != nil {
+		return 0, false
+	}
+	return int(n), true
+}
+
+func (i *Instance) addresses() []corev1.NodeAddress {
+	return []corev1.NodeAddress{
+		{Type: corev1.NodeInternalIP, Address: i.Address},
+		{Type: corev1.NodeHostName, Address: i.ID},
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulated
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/cluster-api/pkg/conformance"
+	"sigs.k8s.io/cluster-api/pkg/controller/node"
+	"sigs.k8s.io/cluster-api/pkg/controller/noderefutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestProvider(t *testing.T, options Options, objs ...runtime.Object) (*Provider, *fakeClock) {
+	if err := clusterv1.AddToScheme(scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	options.Client = fake.NewFakeClientWithScheme(scheme.Scheme, objs...)
+	p := NewProvider(options)
+	clock := &fakeClock{now: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	p.now = clock.Now
+	return p, clock
+}
+
+func newMachine() *clusterv1.Machine {
+	return &clusterv1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine", Namespace: "default"},
+	}
+}
+
+func getNode(t *testing.T, c client.Client, name string) *corev1.Node {
+	n := &corev1.Node{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: name}, n); err != nil {
+		t.Fatalf("failed to get node %q: %v", name, err)
+	}
+	return n
+}
+
+func TestCreateNodeBecomesReady(t *testing.T) {
+	m := newMachine()
+	p, clock := newTestProvider(t, Options{ReadyDelay: time.Minute}, m)
+	actuator := p.MachineActuator()
+	ctx := context.Background()
+
+	result, err := actuator.Create(ctx, nil, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != time.Minute {
+		t.Errorf("expected a requeue after 1m, got %v", result.RequeueAfter)
+	}
+	if len(result.Addresses) == 0 || result.ProviderStatus == nil {
+		t.Errorf("expected addresses and a provider status, got %+v", result)
+	}
+
+	instance, ok := p.Instances()["default/machine"]
+	if !ok {
+		t.Fatal("expected an instance for the machine")
+	}
+	got := &clusterv1.Machine{}
+	if err := p.options.Client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "machine"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.ProviderID == nil || *got.Spec.ProviderID != instance.ProviderID {
+		t.Errorf("expected provider ID %q, got %v", instance.ProviderID, got.Spec.ProviderID)
+	}
+	if _, err := noderefutil.NewProviderID(instance.ProviderID); err != nil {
+		t.Errorf("invalid provider ID %q: %v", instance.ProviderID, err)
+	}
+
+	n := getNode(t, p.options.NodeClient, instance.ID)
+	if n.Spec.ProviderID != instance.ProviderID || n.Annotations[node.MachineAnnotationKey] != "default/machine" {
+		t.Errorf("node isn't linked to the machine: %+v", n)
+	}
+	if noderefutil.IsNodeReady(n) {
+		t.Error("expected the node not to be ready yet")
+	}
+
+	if exists, _ := actuator.Exists(ctx, nil, m); !exists {
+		t.Error("expected the machine to exist")
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	result, err = actuator.Update(ctx, nil, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != 0 {
+		t.Errorf("expected no requeue, got %v", result.RequeueAfter)
+	}
+	if n := getNode(t, p.options.NodeClient, instance.ID); !noderefutil.IsNodeReady(n) {
+		t.Error("expected the node to be ready")
+	}
+}
+
+func TestLinkByProviderID(t *testing.T) {
+	m := newMachine()
+	p, _ := newTestProvider(t, Options{LinkByProviderID: true}, m)
+	if _, err := p.MachineActuator().Create(context.Background(), nil, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	n := getNode(t, p.options.NodeClient, p.Instances()["default/machine"].ID)
+	if _, ok := n.Annotations[node.MachineAnnotationKey]; ok {
+		t.Error("expected the node not to have the machine annotation")
+	}
+	if !noderefutil.IsNodeReady(n) {
+		t.Error("expected the node to be ready without a ReadyDelay")
+	}
+}
+
+func TestCreateFailures(t *testing.T) {
+	m := newMachine()
+	p, _ := newTestProvider(t, Options{CreateFailures: 2}, m)
+	actuator := p.MachineActuator()
+
+	for i := 0; i < 2; i++ {
+		if _, err := actuator.Create(context.Background(), nil, m); err == nil {
+			t.Fatalf("expected creation %d to fail", i+1)
+		}
+	}
+	if _, err := actuator.Create(context.Background(), nil, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(p.Instances()) != 1 {
+		t.Errorf("expected 1 instance, got %d", len(p.Instances()))
+	}
+}
+
+func TestSlowDelete(t *testing.T) {
+	m := newMachine()
+	p, clock := newTestProvider(t, Options{DeleteDelay: 30 * time.Second}, m)
+	actuator := p.MachineActuator()
+	ctx := context.Background()
+
+	if _, err := actuator.Create(ctx, nil, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	instance := p.Instances()["default/machine"]
+
+	result, err := actuator.Delete(ctx, nil, m)
+	if err != nil || result.RequeueAfter != 30*time.Second {
+		t.Fatalf("expected a requeue after 30s, got %v, %v", result.RequeueAfter, err)
+	}
+	if instance := p.Instances()["default/machine"]; instance.State(0, clock.now) != "terminating" {
+		t.Errorf("expected the instance to be terminating, got %q", instance.State(0, clock.now))
+	}
+
+	clock.now = clock.now.Add(30 * time.Second)
+	result, err = actuator.Delete(ctx, nil, m)
+	if err != nil || result.RequeueAfter != 0 {
+		t.Fatalf("expected the deletion to complete, got %v, %v", result.RequeueAfter, err)
+	}
+	if exists, _ := actuator.Exists(ctx, nil, m); exists {
+		t.Error("expected the machine not to exist")
+	}
+	err = p.options.NodeClient.Get(ctx, client.ObjectKey{Name: instance.ID}, &corev1.Node{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the node to be deleted, got %v", err)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	m := newMachine()
+	p, clock := newTestProvider(t, Options{}, m)
+	ctx := context.Background()
+	if _, err := p.MachineActuator().Create(ctx, nil, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	instance := p.Instances()["default/machine"]
+
+	// A new provider sharing the clients stands for the provider after a
+	// restart, or in the management cluster the machine was moved to.
+	restarted := NewProvider(p.options)
+	restarted.now = clock.Now
+	actuator := restarted.MachineActuator()
+
+	other := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}}
+	if err := p.options.Client.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := actuator.Create(ctx, nil, other); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id := restarted.Instances()["default/other"].ID; id == instance.ID {
+		t.Errorf("expected the new instance not to reuse ID %q", id)
+	}
+
+	if err := p.options.Client.Get(ctx, client.ObjectKey{Namespace: "default", Name: "machine"}, m); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := actuator.Exists(ctx, nil, m); err != nil || !exists {
+		t.Fatalf("expected the machine to exist, got %v, %v", exists, err)
+	}
+	if _, err := actuator.Update(ctx, nil, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := restarted.Instances()["default/machine"]; got.ID != instance.ID || got.Address != instance.Address {
+		t.Errorf("expected instance %+v to be recovered, got %+v", instance, got)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	p, clock := newTestProvider(t, Options{ReadyDelay: time.Minute, DeleteDelay: time.Minute})
+	options := conformance.Options{
+		Advance: func(d time.Duration) { clock.now = clock.now.Add(d) },
+	}
+
+	i := 0
+	conformance.TestMachineActuator(t, p.MachineActuator(), conformance.MachineFixture{
+		Options: options,
+		NewMachine: func() (*clusterv1.Cluster, *clusterv1.Machine) {
+			i++
+			m := &clusterv1.Machine{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("machine-%d", i), Namespace: "default"}}
+			if err := p.options.Client.Create(context.Background(), m); err != nil {
+				t.Fatal(err)
+			}
+			return nil, m
+		},
+		CountInstances: func() (int, error) {
+			return len(p.Instances()), nil
+		},
+	})
+	conformance.TestClusterActuator(t, p.ClusterActuator(), conformance.ClusterFixture{
+		Options: options,
+		NewCluster: func() *clusterv1.Cluster {
+			return &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"}}
+		},
+	})
+}
+
+func TestClusterActuator(t *testing.T) {
+	testCases := []struct {
+		name        string
+		apiEndpoint string
+		expected    clusterv1.APIEndpoint
+		expectError bool
+	}{
+		{name: "default", expected: clusterv1.APIEndpoint{Host: "127.0.0.1", Port: 6443}},
+		{name: "custom", apiEndpoint: "10.0.0.1:443", expected: clusterv1.APIEndpoint{Host: "10.0.0.1", Port: 443}},
+		{name: "invalid", apiEndpoint: "10.0.0.1", expectError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, _ := newTestProvider(t, Options{APIEndpoint: tc.apiEndpoint})
+			result, err := p.ClusterActuator().Reconcile(context.Background(), &clusterv1.Cluster{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectError {
+				if result.Error == nil {
+					t.Error("expected a terminal error")
+				}
+				return
+			}
+			if len(result.APIEndpoints) != 1 || result.APIEndpoints[0] != tc.expected {
+				t.Errorf("expected API endpoint %v, got %v", tc.expected, result.APIEndpoints)
+			}
+		})
+	}
+}
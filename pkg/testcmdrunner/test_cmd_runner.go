@@ -17,6 +17,8 @@ limitations under the License.
 package testcmdrunner
 
 import (
+	"context"
+	"fmt"
 	"testing"
 )
 
@@ -44,3 +46,34 @@ func NewOrDie(t *testing.T, callback func(cmd string, args ...string) (string, e
 func (runner *TestRunner) CombinedOutput(cmd string, args ...string) (string, error) {
 	return runner.callback(cmd, args...)
 }
+
+// TestInputRunner mocks out the execution of commands reading from stdin.
+type TestInputRunner struct {
+	callback func(input string, cmd string, args ...string) (stdout, stderr string, err error)
+}
+
+// NewInput builds a TestInputRunner to mock out command execution.
+func NewInput(callback func(input string, cmd string, args ...string) (stdout, stderr string, err error)) *TestInputRunner {
+	return &TestInputRunner{
+		callback: callback,
+	}
+}
+
+func (runner *TestInputRunner) Output(ctx context.Context, input string, cmd string, args ...string) (string, string, error) {
+	return runner.callback(input, cmd, args...)
+}
+
+// ExitError can be returned by callbacks to mock out a command exiting with a
+// non-zero exit code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the mocked exit code.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
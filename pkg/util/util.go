@@ -30,6 +30,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/yaml"
@@ -136,6 +137,12 @@ func IsControlPlaneMachine(machine *clusterv1.Machine) bool {
 	return machine.Spec.Versions.ControlPlane != ""
 }
 
+// IsPaused returns true if the object has the paused annotation, in which case it must not be reconciled.
+func IsPaused(obj metav1.Object) bool {
+	_, ok := obj.GetAnnotations()[clusterv1.PausedAnnotation]
+	return ok
+}
+
 // IsNodeReady returns true if a node is ready.
 func IsNodeReady(node *v1.Node) bool {
 	for _, condition := range node.Status.Conditions {
@@ -0,0 +1,61 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook serves the defaulting and validating admission webhooks of the
+// cluster.k8s.io types. The webhooks are implemented by the API types themselves,
+// which are kept free of controller-runtime so that providers importing them are not
+// tied to its version.
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var (
+	_ admission.Defaulter = &v1alpha1.Cluster{}
+	_ admission.Validator = &v1alpha1.Cluster{}
+	_ admission.Validator = &v1alpha1.Machine{}
+	_ admission.Validator = &v1alpha1.MachineClass{}
+	_ admission.Defaulter = &v1alpha1.MachineDeployment{}
+	_ admission.Validator = &v1alpha1.MachineDeployment{}
+	_ admission.Validator = &v1alpha1.MachineHealthCheck{}
+	_ admission.Defaulter = &v1alpha1.MachineSet{}
+	_ admission.Validator = &v1alpha1.MachineSet{}
+)
+
+// AddToManager registers the defaulting and validating admission webhooks of all
+// cluster.k8s.io types with the manager's webhook server. The paths they are served
+// on are derived from the group, version and kind of each type, and must match the
+// ones in config/webhook/manifests.yaml.
+func AddToManager(mgr manager.Manager) error {
+	for _, obj := range []runtime.Object{
+		&v1alpha1.Cluster{},
+		&v1alpha1.Machine{},
+		&v1alpha1.MachineClass{},
+		&v1alpha1.MachineDeployment{},
+		&v1alpha1.MachineHealthCheck{},
+		&v1alpha1.MachineSet{},
+	} {
+		if err := builder.WebhookManagedBy(mgr).For(obj).Complete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}